@@ -1,9 +1,6 @@
 package csv
 
 import (
-	"errors"
-	"strconv"
-
 	. "github.com/shellyln/takenoco/base"
 	. "github.com/shellyln/takenoco/string"
 )
@@ -130,22 +127,12 @@ func document() ParserFn {
 func Parse(s string) ([][]string, error) {
 	out, err := documentParser(*NewStringParserContext(s))
 	if err != nil {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return nil, errors.New(
-			err.Error() +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+		return nil, ToParseError(out.SourcePosition, "", err).Locate(s, 4)
 	}
 
 	if out.MatchStatus == MatchStatus_Matched {
 		return out.AstStack[0].Value.([][]string), nil
 	} else {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return nil, errors.New(
-			"Parse failed" +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+		return nil, NewParseError(out.SourcePosition, out.ClassName, "Parse failed").Locate(s, 4)
 	}
 }
@@ -1,7 +1,6 @@
 package formula
 
 import (
-	"errors"
 	"strconv"
 
 	. "github.com/shellyln/takenoco/base"
@@ -51,10 +50,10 @@ func number() ParserFn {
 }
 
 // Unary operators
-func unaryOperator() ParserFn {
+func unaryOperator(ops ...string) ParserFn {
 	return Trans(
 		FlatGroup(
-			CharClass("-"),
+			CharClass(ops...),
 			erase(sp0()),
 		),
 		ChangeClassName("UnaryOperator"),
@@ -62,27 +61,16 @@ func unaryOperator() ParserFn {
 }
 
 // Binary operators
-func binaryOperator() ParserFn {
+func binaryOperator(ops ...string) ParserFn {
 	return Trans(
 		FlatGroup(
-			CharClass("+", "-", "*", "/"),
+			CharClass(ops...),
 			erase(sp0()),
 		),
 		ChangeClassName("BinaryOperator"),
 	)
 }
 
-// Expression without parentheses
-func simpleExpression() ParserFn {
-	return FlatGroup(
-		number(),
-		ZeroOrMoreTimes(
-			binaryOperator(),
-			number(),
-		),
-	)
-}
-
 // Expression enclosed in parenthesis
 func groupedExpresion() ParserFn {
 	return FlatGroup(
@@ -99,36 +87,19 @@ func groupedExpresion() ParserFn {
 	)
 }
 
-// Expression before applying production rules
-func expressionInner() ParserFn {
-	return FlatGroup(
-		ZeroOrMoreTimes(unaryOperator()),
-		First(
-			simpleExpression(),
-			Indirect(groupedExpresion),
-			Error("Value required"),
-		),
-		ZeroOrMoreTimes(
-			binaryOperator(),
-			First(
-				FlatGroup(
-					ZeroOrMoreTimes(unaryOperator()),
-					First(
-						simpleExpression(),
-						Indirect(groupedExpresion),
-					),
-				),
-				Error("Error in the expression after the binary operator"),
-			),
-		),
+// Number or expression enclosed in parenthesis
+func operand() ParserFn {
+	return First(
+		number(),
+		Indirect(groupedExpresion),
 	)
 }
 
 // Single expression
 func expression() ParserFn {
-	return Trans(
-		expressionInner(),
-		formulaProductionRules(),
+	return First(
+		Pratt(operand(), operatorTable(), transformBinaryOp),
+		Error("Value required"),
 	)
 }
 
@@ -146,22 +117,12 @@ func program() ParserFn {
 func Parse(s string) (int64, error) {
 	out, err := rootParser(*NewStringParserContext(s))
 	if err != nil {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return 0, errors.New(
-			err.Error() +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+		return 0, ToParseError(out.SourcePosition, "", err).Locate(s, 4)
 	}
 
 	if out.MatchStatus == MatchStatus_Matched {
 		return out.AstStack[0].Value.(int64), nil
 	} else {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return 0, errors.New(
-			"Parse failed" +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+		return 0, NewParseError(out.SourcePosition, out.ClassName, "Parse failed").Locate(s, 4)
 	}
 }
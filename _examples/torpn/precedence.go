@@ -118,6 +118,8 @@ var precedences = []Precedence{
 }
 
 // Production rules
+// This example shows ProductionRule() for the introduction document.
+// See the formula example for the same grammar by Pratt().
 func formulaProductionRules() TransformerFn {
 	return ProductionRule(
 		precedences,
@@ -146,12 +146,7 @@ func program() ParserFn {
 func Parse(s string) ([]interface{}, error) {
 	out, err := rootParser(*NewStringParserContext(s))
 	if err != nil {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return nil, errors.New(
-			err.Error() +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+		return nil, ToParseError(out.SourcePosition, "", err).Locate(s, 4)
 	}
 
 	if out.MatchStatus == MatchStatus_Matched {
@@ -169,11 +164,6 @@ func Parse(s string) ([]interface{}, error) {
 			return nil, errors.New("Unexpected: no result")
 		}
 	} else {
-		pos := GetLineAndColPosition(s, out.SourcePosition, 4)
-		return nil, errors.New(
-			"Parse failed" +
-				"\n --> Line " + strconv.Itoa(pos.Line) +
-				", Col " + strconv.Itoa(pos.Col) + "\n" +
-				pos.ErrSource)
+		return nil, NewParseError(out.SourcePosition, out.ClassName, "Parse failed").Locate(s, 4)
 	}
 }
@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"reflect"
 	"unsafe"
 )
@@ -87,7 +90,155 @@ type Ast struct {
 	SourcePosition `json:",omitempty"`
 }
 
-// TODO: Ast.UnmarshalJSON()
+// JSON representation of Ast for unmarshalling.
+type astJSON struct {
+	OpCode    AstOpCodeType   `json:"op"`
+	ClassName string          `json:"cn"`
+	Type      AstType         `json:"ty"`
+	Value     json.RawMessage `json:"v"`
+	Position  int             `json:"pos"`
+	Length    int             `json:"len"`
+	FileId    int             `json:"file"`
+}
+
+// Implements json.Unmarshaler.
+// The value is decoded according to the type field `ty`.
+// Int and Uint are decoded as int64 and uint64 without the loss of precision.
+// The numbers in ListOfAny and Any are decoded as int64 if they are integers, otherwise float64.
+// Function cannot be decoded, and it is set to nil.
+func (ast *Ast) UnmarshalJSON(data []byte) error {
+	var w astJSON
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+
+	var value interface{}
+	var err error
+	hasValue := len(w.Value) != 0 && !bytes.Equal(w.Value, []byte("null"))
+
+	switch w.Type {
+	case AstType_Nil, AstType_Function:
+		// value is nil
+	case AstType_Rune:
+		var v rune
+		if hasValue {
+			err = json.Unmarshal(w.Value, &v)
+		}
+		value = v
+	case AstType_Int:
+		var v int64
+		if hasValue {
+			err = json.Unmarshal(w.Value, &v)
+		}
+		value = v
+	case AstType_Uint:
+		var v uint64
+		if hasValue {
+			err = json.Unmarshal(w.Value, &v)
+		}
+		value = v
+	case AstType_Float:
+		var v float64
+		if hasValue {
+			err = json.Unmarshal(w.Value, &v)
+		}
+		value = v
+	case AstType_Bool:
+		var v bool
+		if hasValue {
+			err = json.Unmarshal(w.Value, &v)
+		}
+		value = v
+	case AstType_String:
+		var v string
+		if hasValue {
+			err = json.Unmarshal(w.Value, &v)
+		}
+		value = v
+	case AstType_AstCons:
+		var v AstCons
+		if hasValue {
+			err = json.Unmarshal(w.Value, &v)
+		}
+		value = v
+	case AstType_ListOfAst:
+		var v AstSlice
+		if hasValue {
+			err = json.Unmarshal(w.Value, &v)
+		}
+		value = v
+	case AstType_ListOfAny:
+		var v []interface{}
+		if hasValue {
+			err = unmarshalJSONWithNumber(w.Value, &v)
+		}
+		value = v
+	case AstType_Any:
+		if hasValue {
+			err = unmarshalJSONWithNumber(w.Value, &value)
+		}
+	default:
+		return errors.New("Ast.UnmarshalJSON: Unknown AST type: " + w.Type.String())
+	}
+	if err != nil {
+		return err
+	}
+
+	*ast = Ast{
+		OpCode:    w.OpCode,
+		ClassName: w.ClassName,
+		Type:      w.Type,
+		Value:     value,
+		SourcePosition: SourcePosition{
+			Position: w.Position,
+			Length:   w.Length,
+			FileId:   w.FileId,
+		},
+	}
+	return nil
+}
+
+// Decode JSON. The numbers are decoded as int64 if they are integers, otherwise float64.
+func unmarshalJSONWithNumber(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	switch w := v.(type) {
+	case *[]interface{}:
+		for i := range *w {
+			(*w)[i] = convertJSONNumber((*w)[i])
+		}
+	case *interface{}:
+		*w = convertJSONNumber(*w)
+	}
+	return nil
+}
+
+// Convert json.Number in the decoded value to int64 or float64.
+func convertJSONNumber(v interface{}) interface{} {
+	switch w := v.(type) {
+	case json.Number:
+		if n, err := w.Int64(); err == nil {
+			return n
+		}
+		f, _ := w.Float64()
+		return f
+	case []interface{}:
+		for i := range w {
+			w[i] = convertJSONNumber(w[i])
+		}
+		return w
+	case map[string]interface{}:
+		for k := range w {
+			w[k] = convertJSONNumber(w[k])
+		}
+		return w
+	default:
+		return v
+	}
+}
 
 // Mode of the outbound trip of the traverse.
 type WayThereMode int
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAstUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		ast  Ast
+	}{
+		{name: "Nil", ast: Ast{ClassName: "Nil", Type: AstType_Nil}},
+		{name: "Rune", ast: Ast{Type: AstType_Rune, Value: 'あ'}},
+		{name: "Int", ast: Ast{Type: AstType_Int, Value: int64(math.MinInt64)}},
+		{name: "Int zero", ast: Ast{Type: AstType_Int, Value: int64(0)}},
+		{name: "Uint", ast: Ast{Type: AstType_Uint, Value: uint64(math.MaxUint64)}},
+		{name: "Float", ast: Ast{Type: AstType_Float, Value: 1.5}},
+		{name: "Bool", ast: Ast{Type: AstType_Bool, Value: true}},
+		{name: "String", ast: Ast{
+			OpCode:         3,
+			ClassName:      "Word",
+			Type:           AstType_String,
+			Value:          "abc",
+			SourcePosition: SourcePosition{Position: 10, Length: 3, FileId: 2},
+		}},
+		{name: "AstCons", ast: Ast{Type: AstType_AstCons, Value: AstCons{
+			Car: Ast{ClassName: "Op", Type: AstType_String, Value: "+"},
+			Cdr: Ast{Type: AstType_ListOfAst, Value: AstSlice{
+				{Type: AstType_Int, Value: int64(1)},
+				{Type: AstType_AstCons, Value: AstCons{
+					Car: Ast{Type: AstType_Uint, Value: uint64(2)},
+					Cdr: Ast{Type: AstType_Nil},
+				}},
+			}},
+		}}},
+		{name: "ListOfAst", ast: Ast{Type: AstType_ListOfAst, Value: AstSlice{
+			{Type: AstType_ListOfAst, Value: AstSlice{{Type: AstType_Rune, Value: 'x'}}},
+			{Type: AstType_ListOfAst, Value: AstSlice{}},
+		}}},
+		{name: "ListOfAny", ast: Ast{Type: AstType_ListOfAny, Value: []interface{}{int64(1), 2.5, "a", true, nil}}},
+		{name: "Any", ast: Ast{Type: AstType_Any, Value: map[string]interface{}{"a": int64(1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.ast)
+			if err != nil {
+				t.Fatalf("json.Marshal() err = %v", err)
+			}
+			var got Ast
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("json.Unmarshal() err = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.ast) {
+				t.Errorf("json.Unmarshal(%s) = %#v, want %#v", data, got, tt.ast)
+			}
+		})
+	}
+}
+
+func TestAstSliceUnmarshalJSON(t *testing.T) {
+	var got AstSlice
+	err := json.Unmarshal([]byte(`[{"ty":2,"v":9007199254740993},{"ty":1,"v":97,"pos":1,"len":1}]`), &got)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() err = %v", err)
+	}
+	want := AstSlice{
+		{Type: AstType_Int, Value: int64(9007199254740993)},
+		{Type: AstType_Rune, Value: 'a', SourcePosition: SourcePosition{Position: 1, Length: 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("json.Unmarshal() = %#v, want %#v", got, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"ty":99}`), &Ast{}); err == nil {
+		t.Errorf("json.Unmarshal() with the unknown type should be an error")
+	}
+}
@@ -4,8 +4,11 @@ package parser
 func LightBaseParser(className string, fn LightParserImplFn) ParserFn {
 	parser := func(ctx ParserContext) (ParserContext, error) {
 		out, err := fn(ctx)
-		if err != nil && out.MatchStatus < MatchStatus_Error {
-			out.MatchStatus = MatchStatus_Error
+		if err != nil {
+			if out.MatchStatus < MatchStatus_Error {
+				out.MatchStatus = MatchStatus_Error
+			}
+			err = ToParseError(out.SourcePosition, className, err)
 		}
 		if out.MatchStatus == MatchStatus_Matched {
 			out.Quantity = 1
@@ -46,7 +49,7 @@ func BaseParser(
 		}
 	}
 
-	parser := func(ctx ParserContext) (ParserContext, error) {
+	impl := func(ctx ParserContext) (ParserContext, error) {
 		ctx.ClassName = className
 
 		out := ctx
@@ -165,6 +168,14 @@ func BaseParser(
 		return out, nil
 	}
 
+	parser := func(ctx ParserContext) (ParserContext, error) {
+		out, err := impl(ctx)
+		if err != nil {
+			err = ToParseError(out.SourcePosition, className, err)
+		}
+		return out, err
+	}
+
 	if traceEnabled {
 		traceParserTrackingNo++
 		return tracer(traceScope, parserTracer, traceParserTrackingNo, className, parser)
@@ -0,0 +1,785 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sort"
+)
+
+// Binary AST format
+//
+//	header: magic "TKNA" (4 bytes), version (1 byte), flags (1 byte)
+//	record: Ast | count (uvarint) Ast...
+//	Ast:    type (uvarint), opcode (uvarint), class name, [position, length, file ID (varint)], value
+//
+// The class name is a length prefixed string, or a reference to the interning table if interning is enabled.
+// The interning table is shared by all records of the stream.
+const (
+	astBinaryMagic   = "TKNA"
+	astBinaryVersion = 1
+
+	astBinaryFlag_Intern         = 0x01
+	astBinaryFlag_StripPositions = 0x02
+
+	// Upper limit of the lengths and counts to protect the decoder from broken data.
+	maxAstBinaryLength = 1 << 30
+)
+
+// Tags of the values of ListOfAny and Any.
+const (
+	anyTag_Nil byte = iota
+	anyTag_Bool
+	anyTag_Int
+	anyTag_Int8
+	anyTag_Int16
+	anyTag_Int32
+	anyTag_Int64
+	anyTag_Uint
+	anyTag_Uint8
+	anyTag_Uint16
+	anyTag_Uint32
+	anyTag_Uint64
+	anyTag_Float32
+	anyTag_Float64
+	anyTag_String
+	anyTag_Bytes
+	anyTag_List
+	anyTag_Map
+	anyTag_Ast
+	anyTag_AstSlice
+)
+
+const (
+	msgBadAstBinaryHeader  = "Bad AST binary format header"
+	msgBadAstBinaryVersion = "Unsupported AST binary format version"
+	msgBadAstBinaryData    = "Broken AST binary data"
+	msgUnsupportedAnyValue = "Unsupported value type in the AST binary format"
+	msgAstValueMismatch    = "AST value does not match the AST type"
+)
+
+// Make the error of the AST whose value is not of the AST type.
+func astValueMismatchError(ast Ast) error {
+	return errors.New(msgAstValueMismatch + ": " + ast.Type.String())
+}
+
+// Options of AstEncoder.
+type AstEncoderOptions struct {
+	// Store each class name only once in the stream.
+	Intern bool
+	// Don't store the source positions.
+	StripPositions bool
+}
+
+// Streaming encoder of the binary AST format.
+type AstEncoder struct {
+	w             io.Writer
+	opts          AstEncoderOptions
+	headerWritten bool
+	interned      map[string]uint64
+	buf           []byte
+}
+
+// Constructor
+func NewAstEncoder(w io.Writer, opts AstEncoderOptions) *AstEncoder {
+	return &AstEncoder{
+		w:        w,
+		opts:     opts,
+		interned: make(map[string]uint64),
+		buf:      make([]byte, 0, 1024),
+	}
+}
+
+// Write the header if it is not written yet.
+func (e *AstEncoder) writeHeader() {
+	if e.headerWritten {
+		return
+	}
+	var flags byte
+	if e.opts.Intern {
+		flags |= astBinaryFlag_Intern
+	}
+	if e.opts.StripPositions {
+		flags |= astBinaryFlag_StripPositions
+	}
+	e.buf = append(e.buf, astBinaryMagic...)
+	e.buf = append(e.buf, astBinaryVersion, flags)
+	e.headerWritten = true
+}
+
+// Write the buffer to the writer.
+func (e *AstEncoder) flush() error {
+	_, err := e.w.Write(e.buf)
+	e.buf = e.buf[:0]
+	return err
+}
+
+// Encode a record. If it fails, nothing of the record is written.
+func (e *AstEncoder) encodeRecord(fn func() error) error {
+	e.writeHeader()
+	start := len(e.buf)
+	numInterned := uint64(len(e.interned))
+
+	if err := fn(); err != nil {
+		e.buf = e.buf[:start]
+		for k, n := range e.interned {
+			if numInterned < n {
+				delete(e.interned, k)
+			}
+		}
+		return err
+	}
+	return e.flush()
+}
+
+// Encode an AST as a record.
+func (e *AstEncoder) Encode(ast Ast) error {
+	return e.encodeRecord(func() error {
+		return e.encodeAst(ast)
+	})
+}
+
+// Encode an AST slice as a record. It should be decoded by AstDecoder.DecodeSlice().
+func (e *AstEncoder) EncodeSlice(asts AstSlice) error {
+	return e.encodeRecord(func() error {
+		return e.encodeAstSlice(asts)
+	})
+}
+
+func (e *AstEncoder) putUvarint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	e.buf = append(e.buf, tmp[:n]...)
+}
+
+func (e *AstEncoder) putVarint(v int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	e.buf = append(e.buf, tmp[:n]...)
+}
+
+func (e *AstEncoder) putUint32(v uint32) {
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], v)
+	e.buf = append(e.buf, tmp[:]...)
+}
+
+func (e *AstEncoder) putUint64(v uint64) {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], v)
+	e.buf = append(e.buf, tmp[:]...)
+}
+
+func (e *AstEncoder) putString(s string) {
+	e.putUvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// Write the class name. 0 is followed by a new string, n is the (n-1)-th interned string.
+func (e *AstEncoder) putClassName(s string) {
+	if !e.opts.Intern {
+		e.putString(s)
+		return
+	}
+	if n, ok := e.interned[s]; ok {
+		e.putUvarint(n)
+		return
+	}
+	e.putUvarint(0)
+	e.putString(s)
+	e.interned[s] = uint64(len(e.interned) + 1)
+}
+
+func (e *AstEncoder) encodeAstSlice(asts AstSlice) error {
+	e.putUvarint(uint64(len(asts)))
+	for i := range asts {
+		if err := e.encodeAst(asts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *AstEncoder) encodeAst(ast Ast) error {
+	e.putUvarint(uint64(ast.Type))
+	e.putUvarint(uint64(ast.OpCode))
+	e.putClassName(ast.ClassName)
+	if !e.opts.StripPositions {
+		e.putVarint(int64(ast.Position))
+		e.putVarint(int64(ast.Length))
+		e.putVarint(int64(ast.FileId))
+	}
+
+	switch ast.Type {
+	case AstType_Nil, AstType_Function:
+		// The function is not stored.
+	case AstType_Rune:
+		v, ok := ast.Value.(rune)
+		if !ok {
+			return astValueMismatchError(ast)
+		}
+		e.putVarint(int64(v))
+	case AstType_Int:
+		v, ok := ast.Value.(int64)
+		if !ok {
+			return astValueMismatchError(ast)
+		}
+		e.putVarint(v)
+	case AstType_Uint:
+		v, ok := ast.Value.(uint64)
+		if !ok {
+			return astValueMismatchError(ast)
+		}
+		e.putUvarint(v)
+	case AstType_Float:
+		v, ok := ast.Value.(float64)
+		if !ok {
+			return astValueMismatchError(ast)
+		}
+		e.putUint64(math.Float64bits(v))
+	case AstType_Bool:
+		v, ok := ast.Value.(bool)
+		if !ok {
+			return astValueMismatchError(ast)
+		}
+		if v {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+	case AstType_String:
+		v, ok := ast.Value.(string)
+		if !ok {
+			return astValueMismatchError(ast)
+		}
+		e.putString(v)
+	case AstType_AstCons:
+		cons, ok := ast.Value.(AstCons)
+		if !ok {
+			return astValueMismatchError(ast)
+		}
+		if err := e.encodeAst(cons.Car); err != nil {
+			return err
+		}
+		return e.encodeAst(cons.Cdr)
+	case AstType_ListOfAst:
+		v, ok := ast.Value.(AstSlice)
+		if !ok {
+			return astValueMismatchError(ast)
+		}
+		return e.encodeAstSlice(v)
+	case AstType_ListOfAny:
+		// The SliceLike values (e.g. the results of ToSlice) are decoded as []interface{}.
+		switch list := ast.Value.(type) {
+		case []interface{}:
+			e.putUvarint(uint64(len(list)))
+			for _, v := range list {
+				if err := e.encodeAny(v); err != nil {
+					return err
+				}
+			}
+		case SliceLike:
+			length := list.Len()
+			e.putUvarint(uint64(length))
+			for i := 0; i < length; i++ {
+				if err := e.encodeAny(list.Get(i)); err != nil {
+					return err
+				}
+			}
+		default:
+			return astValueMismatchError(ast)
+		}
+	case AstType_Any:
+		return e.encodeAny(ast.Value)
+	default:
+		return errors.New(msgUnsupportedAnyValue + ": " + ast.Type.String())
+	}
+	return nil
+}
+
+func (e *AstEncoder) encodeAny(v interface{}) error {
+	switch w := v.(type) {
+	case nil:
+		e.buf = append(e.buf, anyTag_Nil)
+	case bool:
+		e.buf = append(e.buf, anyTag_Bool)
+		if w {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+	case int:
+		e.buf = append(e.buf, anyTag_Int)
+		e.putVarint(int64(w))
+	case int8:
+		e.buf = append(e.buf, anyTag_Int8)
+		e.putVarint(int64(w))
+	case int16:
+		e.buf = append(e.buf, anyTag_Int16)
+		e.putVarint(int64(w))
+	case int32:
+		e.buf = append(e.buf, anyTag_Int32)
+		e.putVarint(int64(w))
+	case int64:
+		e.buf = append(e.buf, anyTag_Int64)
+		e.putVarint(w)
+	case uint:
+		e.buf = append(e.buf, anyTag_Uint)
+		e.putUvarint(uint64(w))
+	case uint8:
+		e.buf = append(e.buf, anyTag_Uint8)
+		e.putUvarint(uint64(w))
+	case uint16:
+		e.buf = append(e.buf, anyTag_Uint16)
+		e.putUvarint(uint64(w))
+	case uint32:
+		e.buf = append(e.buf, anyTag_Uint32)
+		e.putUvarint(uint64(w))
+	case uint64:
+		e.buf = append(e.buf, anyTag_Uint64)
+		e.putUvarint(w)
+	case float32:
+		e.buf = append(e.buf, anyTag_Float32)
+		e.putUint32(math.Float32bits(w))
+	case float64:
+		e.buf = append(e.buf, anyTag_Float64)
+		e.putUint64(math.Float64bits(w))
+	case string:
+		e.buf = append(e.buf, anyTag_String)
+		e.putString(w)
+	case []byte:
+		e.buf = append(e.buf, anyTag_Bytes)
+		e.putUvarint(uint64(len(w)))
+		e.buf = append(e.buf, w...)
+	case []interface{}:
+		e.buf = append(e.buf, anyTag_List)
+		e.putUvarint(uint64(len(w)))
+		for _, x := range w {
+			if err := e.encodeAny(x); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		e.buf = append(e.buf, anyTag_Map)
+		e.putUvarint(uint64(len(w)))
+		keys := make([]string, 0, len(w))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e.putString(k)
+			if err := e.encodeAny(w[k]); err != nil {
+				return err
+			}
+		}
+	case Ast:
+		e.buf = append(e.buf, anyTag_Ast)
+		return e.encodeAst(w)
+	case AstSlice:
+		e.buf = append(e.buf, anyTag_AstSlice)
+		return e.encodeAstSlice(w)
+	default:
+		return errors.New(msgUnsupportedAnyValue)
+	}
+	return nil
+}
+
+// Streaming decoder of the binary AST format.
+type AstDecoder struct {
+	r          *bufio.Reader
+	headerRead bool
+	flags      byte
+	interned   []string
+}
+
+// Constructor
+func NewAstDecoder(r io.Reader) *AstDecoder {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &AstDecoder{
+		r: br,
+	}
+}
+
+// Read the header if it is not read yet.
+// It returns io.EOF if the stream is empty.
+func (d *AstDecoder) readHeader() error {
+	if d.headerRead {
+		return nil
+	}
+	var header [len(astBinaryMagic) + 2]byte
+	if _, err := io.ReadFull(d.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return errors.New(msgBadAstBinaryHeader)
+		}
+		return err
+	}
+	if string(header[:len(astBinaryMagic)]) != astBinaryMagic {
+		return errors.New(msgBadAstBinaryHeader)
+	}
+	if header[len(astBinaryMagic)] != astBinaryVersion {
+		return errors.New(msgBadAstBinaryVersion)
+	}
+	d.flags = header[len(astBinaryMagic)+1]
+	d.headerRead = true
+	return nil
+}
+
+// Test whether the next record exists. It returns io.EOF at the end of the stream.
+func (d *AstDecoder) beginRecord() error {
+	if err := d.readHeader(); err != nil {
+		return err
+	}
+	if _, err := d.r.Peek(1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Decode a record encoded by AstEncoder.Encode().
+// It returns io.EOF at the end of the stream.
+func (d *AstDecoder) Decode() (Ast, error) {
+	if err := d.beginRecord(); err != nil {
+		return Ast{}, err
+	}
+	return d.decodeAst()
+}
+
+// Decode a record encoded by AstEncoder.EncodeSlice().
+// It returns io.EOF at the end of the stream.
+func (d *AstDecoder) DecodeSlice() (AstSlice, error) {
+	if err := d.beginRecord(); err != nil {
+		return nil, err
+	}
+	return d.decodeAstSlice()
+}
+
+// Convert the error at the middle of a record.
+func brokenAstBinaryError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.New(msgBadAstBinaryData)
+	}
+	return err
+}
+
+func (d *AstDecoder) readUvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(d.r)
+	return v, brokenAstBinaryError(err)
+}
+
+func (d *AstDecoder) readVarint() (int64, error) {
+	v, err := binary.ReadVarint(d.r)
+	return v, brokenAstBinaryError(err)
+}
+
+func (d *AstDecoder) readLength() (int, error) {
+	n, err := d.readUvarint()
+	if err != nil {
+		return 0, err
+	}
+	if maxAstBinaryLength < n {
+		return 0, errors.New(msgBadAstBinaryData)
+	}
+	return int(n), nil
+}
+
+func (d *AstDecoder) readBytes() ([]byte, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	// The length is not trusted. The buffer grows as the data arrives.
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(n)); err != nil {
+		return nil, brokenAstBinaryError(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *AstDecoder) readString() (string, error) {
+	buf, err := d.readBytes()
+	return string(buf), err
+}
+
+func (d *AstDecoder) readByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	return b, brokenAstBinaryError(err)
+}
+
+func (d *AstDecoder) readFixed(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[:size]); err != nil {
+		return 0, brokenAstBinaryError(err)
+	}
+	if size == 4 {
+		return uint64(binary.LittleEndian.Uint32(buf[:])), nil
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+func (d *AstDecoder) readClassName() (string, error) {
+	if d.flags&astBinaryFlag_Intern == 0 {
+		return d.readString()
+	}
+	n, err := d.readUvarint()
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		s, err := d.readString()
+		if err != nil {
+			return "", err
+		}
+		d.interned = append(d.interned, s)
+		return s, nil
+	}
+	if uint64(len(d.interned)) < n {
+		return "", errors.New(msgBadAstBinaryData)
+	}
+	return d.interned[n-1], nil
+}
+
+func (d *AstDecoder) decodeAstSlice() (AstSlice, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	capacity := n
+	if 1024 < capacity {
+		capacity = 1024
+	}
+	asts := make(AstSlice, 0, capacity)
+	for i := 0; i < n; i++ {
+		ast, err := d.decodeAst()
+		if err != nil {
+			return nil, err
+		}
+		asts = append(asts, ast)
+	}
+	return asts, nil
+}
+
+func (d *AstDecoder) decodeAst() (Ast, error) {
+	var ast Ast
+
+	ty, err := d.readUvarint()
+	if err != nil {
+		return ast, err
+	}
+	ast.Type = AstType(ty)
+
+	opCode, err := d.readUvarint()
+	if err != nil {
+		return ast, err
+	}
+	ast.OpCode = AstOpCodeType(opCode)
+
+	if ast.ClassName, err = d.readClassName(); err != nil {
+		return ast, err
+	}
+
+	if d.flags&astBinaryFlag_StripPositions == 0 {
+		var v [3]int64
+		for i := range v {
+			if v[i], err = d.readVarint(); err != nil {
+				return ast, err
+			}
+		}
+		ast.SourcePosition = SourcePosition{
+			Position: int(v[0]),
+			Length:   int(v[1]),
+			FileId:   int(v[2]),
+		}
+	}
+
+	switch ast.Type {
+	case AstType_Nil, AstType_Function:
+		// value is nil
+	case AstType_Rune:
+		v, err := d.readVarint()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = rune(v)
+	case AstType_Int:
+		v, err := d.readVarint()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = v
+	case AstType_Uint:
+		v, err := d.readUvarint()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = v
+	case AstType_Float:
+		v, err := d.readFixed(8)
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = math.Float64frombits(v)
+	case AstType_Bool:
+		v, err := d.readByte()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = v != 0
+	case AstType_String:
+		v, err := d.readString()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = v
+	case AstType_AstCons:
+		car, err := d.decodeAst()
+		if err != nil {
+			return ast, err
+		}
+		cdr, err := d.decodeAst()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = AstCons{Car: car, Cdr: cdr}
+	case AstType_ListOfAst:
+		v, err := d.decodeAstSlice()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = v
+	case AstType_ListOfAny:
+		v, err := d.decodeList()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = v
+	case AstType_Any:
+		v, err := d.decodeAny()
+		if err != nil {
+			return ast, err
+		}
+		ast.Value = v
+	default:
+		return ast, errors.New(msgBadAstBinaryData)
+	}
+	return ast, nil
+}
+
+func (d *AstDecoder) decodeList() ([]interface{}, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	capacity := n
+	if 1024 < capacity {
+		capacity = 1024
+	}
+	list := make([]interface{}, 0, capacity)
+	for i := 0; i < n; i++ {
+		v, err := d.decodeAny()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func (d *AstDecoder) decodeAny() (interface{}, error) {
+	tag, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case anyTag_Nil:
+		return nil, nil
+	case anyTag_Bool:
+		v, err := d.readByte()
+		return v != 0, err
+	case anyTag_Int, anyTag_Int8, anyTag_Int16, anyTag_Int32, anyTag_Int64:
+		v, err := d.readVarint()
+		switch tag {
+		case anyTag_Int:
+			return int(v), err
+		case anyTag_Int8:
+			return int8(v), err
+		case anyTag_Int16:
+			return int16(v), err
+		case anyTag_Int32:
+			return int32(v), err
+		default:
+			return v, err
+		}
+	case anyTag_Uint, anyTag_Uint8, anyTag_Uint16, anyTag_Uint32, anyTag_Uint64:
+		v, err := d.readUvarint()
+		switch tag {
+		case anyTag_Uint:
+			return uint(v), err
+		case anyTag_Uint8:
+			return uint8(v), err
+		case anyTag_Uint16:
+			return uint16(v), err
+		case anyTag_Uint32:
+			return uint32(v), err
+		default:
+			return v, err
+		}
+	case anyTag_Float32:
+		v, err := d.readFixed(4)
+		return math.Float32frombits(uint32(v)), err
+	case anyTag_Float64:
+		v, err := d.readFixed(8)
+		return math.Float64frombits(v), err
+	case anyTag_String:
+		return d.readString()
+	case anyTag_Bytes:
+		return d.readBytes()
+	case anyTag_List:
+		return d.decodeList()
+	case anyTag_Map:
+		n, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]interface{})
+		for i := 0; i < n; i++ {
+			k, err := d.readString()
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.decodeAny()
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	case anyTag_Ast:
+		return d.decodeAst()
+	case anyTag_AstSlice:
+		return d.decodeAstSlice()
+	default:
+		return nil, errors.New(msgBadAstBinaryData)
+	}
+}
+
+// Encode the AST slice to the binary AST format.
+func MarshalAstBinary(asts AstSlice, opts AstEncoderOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewAstEncoder(&buf, opts).EncodeSlice(asts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode the AST slice from the binary AST format.
+func UnmarshalAstBinary(data []byte) (AstSlice, error) {
+	return NewAstDecoder(bytes.NewReader(data)).DecodeSlice()
+}
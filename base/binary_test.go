@@ -0,0 +1,222 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func binaryTestAsts() AstSlice {
+	return AstSlice{
+		{Type: AstType_Nil, ClassName: "Nil"},
+		{Type: AstType_Rune, Value: 'あ', SourcePosition: SourcePosition{Position: 1, Length: 3, FileId: 2}},
+		{Type: AstType_Int, Value: int64(math.MinInt64), ClassName: "Number"},
+		{Type: AstType_Uint, Value: uint64(math.MaxUint64), ClassName: "Number"},
+		{Type: AstType_Float, Value: -1.25, ClassName: "Number"},
+		{Type: AstType_Bool, Value: true},
+		{Type: AstType_String, Value: "abc", OpCode: 42},
+		{Type: AstType_AstCons, ClassName: "Cons", Value: AstCons{
+			Car: Ast{Type: AstType_String, Value: "+", ClassName: "Op"},
+			Cdr: Ast{Type: AstType_ListOfAst, Value: AstSlice{
+				{Type: AstType_Int, Value: int64(1), ClassName: "Number"},
+				{Type: AstType_Int, Value: int64(2), ClassName: "Number"},
+			}},
+		}},
+		{Type: AstType_ListOfAst, Value: AstSlice{}},
+		{Type: AstType_ListOfAny, Value: []interface{}{
+			nil, false, 1, int8(-2), int16(3), int32(-4), int64(5),
+			uint(6), uint8(7), uint16(8), uint32(9), uint64(10),
+			float32(1.5), 2.5, "s", []byte{1, 2},
+			[]interface{}{"x"}, map[string]interface{}{"b": 1, "a": "2"},
+			Ast{Type: AstType_Int, Value: int64(3)},
+			AstSlice{{Type: AstType_Bool, Value: false}},
+		}},
+		{Type: AstType_Any, Value: []byte{0xff}},
+	}
+}
+
+func TestAstBinaryRoundTrip(t *testing.T) {
+	asts := binaryTestAsts()
+
+	for _, opts := range []AstEncoderOptions{{}, {Intern: true}} {
+		data, err := MarshalAstBinary(asts, opts)
+		if err != nil {
+			t.Fatalf("MarshalAstBinary(%+v) err = %v", opts, err)
+		}
+		got, err := UnmarshalAstBinary(data)
+		if err != nil {
+			t.Fatalf("UnmarshalAstBinary(%+v) err = %v", opts, err)
+		}
+		if !reflect.DeepEqual(got, asts) {
+			t.Errorf("UnmarshalAstBinary(%+v) = %#v, want %#v", opts, got, asts)
+		}
+	}
+}
+
+func TestAstBinarySize(t *testing.T) {
+	asts := make(AstSlice, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		asts = append(asts, Ast{
+			ClassName:      ":string:Number",
+			Type:           AstType_Int,
+			Value:          int64(i),
+			SourcePosition: SourcePosition{Position: i * 4, Length: 3},
+		})
+	}
+
+	plain, _ := MarshalAstBinary(asts, AstEncoderOptions{})
+	interned, _ := MarshalAstBinary(asts, AstEncoderOptions{Intern: true})
+	stripped, _ := MarshalAstBinary(asts, AstEncoderOptions{Intern: true, StripPositions: true})
+	js, _ := json.Marshal(asts)
+
+	if !(len(stripped) < len(interned) && len(interned) < len(plain) && len(plain) < len(js)) {
+		t.Errorf("sizes: stripped %d, interned %d, plain %d, json %d", len(stripped), len(interned), len(plain), len(js))
+	}
+
+	got, err := UnmarshalAstBinary(stripped)
+	if err != nil {
+		t.Fatalf("UnmarshalAstBinary() err = %v", err)
+	}
+	if got[10].Position != 0 || got[10].Value != int64(10) || got[10].ClassName != ":string:Number" {
+		t.Errorf("UnmarshalAstBinary() = %#v", got[10])
+	}
+}
+
+func TestAstBinaryStream(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewAstEncoder(&buf, AstEncoderOptions{Intern: true})
+
+	if err := enc.Encode(Ast{Type: AstType_Function, Value: func() {}}); err != nil {
+		t.Fatalf("Encode() err = %v", err)
+	}
+	if err := enc.Encode(Ast{Type: AstType_Any, ClassName: "Bad", Value: struct{}{}}); err == nil {
+		t.Errorf("Encode() of the unsupported value should be an error")
+	}
+	if err := enc.Encode(Ast{Type: AstType_String, ClassName: "Bad", Value: "ok"}); err != nil {
+		t.Fatalf("Encode() err = %v", err)
+	}
+	if err := enc.EncodeSlice(AstSlice{{Type: AstType_Int, ClassName: "Bad", Value: int64(1)}}); err != nil {
+		t.Fatalf("EncodeSlice() err = %v", err)
+	}
+
+	dec := NewAstDecoder(&buf)
+	ast, err := dec.Decode()
+	if err != nil || ast.Type != AstType_Function || ast.Value != nil {
+		t.Errorf("Decode() = %#v, %v", ast, err)
+	}
+	ast, err = dec.Decode()
+	if err != nil || ast.ClassName != "Bad" || ast.Value != "ok" {
+		t.Errorf("Decode() = %#v, %v", ast, err)
+	}
+	asts, err := dec.DecodeSlice()
+	if err != nil || len(asts) != 1 || asts[0].ClassName != "Bad" {
+		t.Errorf("DecodeSlice() = %#v, %v", asts, err)
+	}
+	if _, err = dec.Decode(); err != io.EOF {
+		t.Errorf("Decode() at the end err = %v, want io.EOF", err)
+	}
+}
+
+// Record of an AST with the class name of 1 GiB length and no data.
+var binaryTestHugeLength = []byte("TKNA\x01\x00\x01\x05\x00\x80\x80\x80\x80\x04")
+
+func TestAstBinaryHugeLength(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := UnmarshalAstBinary(binaryTestHugeLength); err == nil {
+		t.Fatalf("UnmarshalAstBinary() err = nil")
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; 1<<20 < n {
+		t.Errorf("UnmarshalAstBinary() allocated %d bytes", n)
+	}
+}
+
+func TestAstBinaryBadData(t *testing.T) {
+	data, _ := MarshalAstBinary(binaryTestAsts(), AstEncoderOptions{})
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "magic", data: append([]byte("XXXX"), data[4:]...), wantErr: msgBadAstBinaryHeader},
+		{name: "version", data: append(append([]byte("TKNA"), 99), data[5:]...), wantErr: msgBadAstBinaryVersion},
+		{name: "short header", data: data[:3], wantErr: msgBadAstBinaryHeader},
+		{name: "truncated", data: data[:len(data)-1], wantErr: msgBadAstBinaryData},
+		{name: "huge length", data: binaryTestHugeLength, wantErr: msgBadAstBinaryData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalAstBinary(tt.data)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("UnmarshalAstBinary() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// SliceLike of int for the tests.
+type binaryTestIntSlice []int
+
+func (s binaryTestIntSlice) Len() int                         { return len(s) }
+func (s binaryTestIntSlice) Get(i int) interface{}            { return s[i] }
+func (s binaryTestIntSlice) Set(i int, v interface{})         { s[i] = v.(int) }
+func (s binaryTestIntSlice) Reslice(start, end int) SliceLike { return s[start:end] }
+func (s binaryTestIntSlice) Copy(start, end int) SliceLike {
+	return append(binaryTestIntSlice{}, s[start:end]...)
+}
+func (s binaryTestIntSlice) Make(len, cap int) SliceLike      { return make(binaryTestIntSlice, len, cap) }
+func (s binaryTestIntSlice) ItemEquals(a, b interface{}) bool { return a == b }
+
+func TestAstBinarySliceLike(t *testing.T) {
+	asts := AstSlice{{Type: AstType_ListOfAny, Value: binaryTestIntSlice{1, 2, 3}}}
+
+	data, err := MarshalAstBinary(asts, AstEncoderOptions{})
+	if err != nil {
+		t.Fatalf("MarshalAstBinary() err = %v", err)
+	}
+	got, err := UnmarshalAstBinary(data)
+	if err != nil {
+		t.Fatalf("UnmarshalAstBinary() err = %v", err)
+	}
+	want := []interface{}{1, 2, 3}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].Value, want) {
+		t.Errorf("UnmarshalAstBinary() = %v, want %v", got, want)
+	}
+}
+
+func TestAstBinaryValueMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		ast  Ast
+	}{
+		{name: "rune", ast: Ast{Type: AstType_Rune, Value: "a"}},
+		{name: "int", ast: Ast{Type: AstType_Int, Value: 1}},
+		{name: "uint", ast: Ast{Type: AstType_Uint, Value: uint(1)}},
+		{name: "float", ast: Ast{Type: AstType_Float, Value: float32(1)}},
+		{name: "bool", ast: Ast{Type: AstType_Bool, Value: 1}},
+		{name: "string", ast: Ast{Type: AstType_String, Value: []byte("a")}},
+		{name: "cons", ast: Ast{Type: AstType_AstCons, Value: nil}},
+		{name: "list of ast", ast: Ast{Type: AstType_ListOfAst, Value: []Ast{}}},
+		{name: "list of any", ast: Ast{Type: AstType_ListOfAny, Value: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := NewAstEncoder(&buf, AstEncoderOptions{}).Encode(tt.ast)
+			if err == nil || err.Error() != msgAstValueMismatch+": "+tt.ast.Type.String() {
+				t.Errorf("Encode() err = %v", err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("Encode() wrote %d bytes", buf.Len())
+			}
+		})
+	}
+}
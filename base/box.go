@@ -2,7 +2,7 @@ package parser
 
 import (
 	"reflect"
-	// "unsafe"
+	"unsafe"
 )
 
 // An interface that abstracts memory address.
@@ -22,7 +22,10 @@ type Box interface {
 	// Get value as []byte
 	GetBytes() []byte
 	// Get value as unsafe.Pointer
-	// GetPointer() unsafe.Pointer
+	//
+	// NOTE: The result is valid only while the referent is reachable from the Go values.
+	// The rules of the package unsafe are applied, and the reflection boxes do not support it on TinyGo.
+	GetPointer() unsafe.Pointer
 
 	// Set any
 	SetAny(v interface{})
@@ -39,7 +42,9 @@ type Box interface {
 	// Set []byte value
 	SetBytes(v []byte)
 	// Set unsafe.Pointer
-	// SetPointer(v unsafe.Pointer)
+	//
+	// NOTE: The destination should be unsafe.Pointer (or interface{}), and the same caveats as GetPointer are applied.
+	SetPointer(v unsafe.Pointer)
 
 	// Get a specified element that is associated to the index
 	Index(i int) Box
@@ -89,11 +94,6 @@ func (s ReflectionBox) GetBytes() []byte {
 	return s.Val.Bytes()
 }
 
-//
-// func (s ReflectionBox) GetPointer() unsafe.Pointer {
-// 	return unsafe.Pointer(s.Val.Pointer())  // TinyGo does not implement this function.
-// }
-
 //
 func (s ReflectionBox) SetAny(v interface{}) {
 	s.Val.Set(reflect.ValueOf(v))
@@ -129,11 +129,6 @@ func (s ReflectionBox) SetBytes(v []byte) {
 	s.Val.SetBytes(v)
 }
 
-//
-// func (s ReflectionBox) SetPointer(v unsafe.Pointer) {
-// 	s.Val.SetPointer(v)  // TinyGo does not implement this function.
-// }
-
 //
 func (s ReflectionBox) Index(i int) Box {
 	return ReflectionBox{Val: s.Val.Index(i)}
@@ -190,11 +185,6 @@ func (s MapContainerReflectionBox) GetBytes() []byte {
 	return s.Container.MapIndex(s.Key).Bytes()
 }
 
-//
-// func (s MapContainerReflectionBox) GetPointer() unsafe.Pointer {
-// 	return unsafe.Pointer(s.Container.MapIndex(s.Key).Pointer())  // TinyGo does not implement this function.
-// }
-
 //
 func (s MapContainerReflectionBox) SetAny(v interface{}) {
 	s.Container.SetMapIndex(s.Key, reflect.ValueOf(v))
@@ -230,11 +220,6 @@ func (s MapContainerReflectionBox) SetBytes(v []byte) {
 	s.Container.SetMapIndex(s.Key, reflect.ValueOf(v))
 }
 
-//
-// func (s MapContainerReflectionBox) SetPointer(v unsafe.Pointer) {
-// 	s.Container.SetMapIndex(s.Key, reflect.ValueOf(v))  // TinyGo does not implement this function.
-// }
-
 //
 func (s MapContainerReflectionBox) Index(i int) Box {
 	return ReflectionBox{Val: reflect.ValueOf(nil)}
@@ -316,14 +301,6 @@ func (p *NotInitializedMapContainerReflectionBox) GetBytes() []byte {
 	return p.Container.MapIndex(p.Key).Bytes()
 }
 
-//
-// func (p *NotInitializedMapContainerReflectionBox) GetPointer() unsafe.Pointer {
-// 	if !p.Initialized {
-// 		panic(msgReferencedNonInitializedVariable)
-// 	}
-// 	return unsafe.Pointer(p.Container.MapIndex(p.Key).Pointer())  // TinyGo does not implement this function.
-// }
-
 //
 func (p *NotInitializedMapContainerReflectionBox) SetAny(v interface{}) {
 	p.Container.SetMapIndex(p.Key, reflect.ValueOf(v))
@@ -366,12 +343,6 @@ func (p *NotInitializedMapContainerReflectionBox) SetBytes(v []byte) {
 	p.Initialized = true
 }
 
-//
-// func (p *NotInitializedMapContainerReflectionBox) SetPointer(v unsafe.Pointer) {
-// 	p.Container.SetMapIndex(p.Key, reflect.ValueOf(v))  // TinyGo does not implement this function.
-// 	p.Initialized = true
-// }
-
 //
 func (p *NotInitializedMapContainerReflectionBox) Index(i int) Box {
 	if !p.Initialized {
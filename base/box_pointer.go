@@ -0,0 +1,39 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// The value should be a pointer, map, slice, func, chan or unsafe.Pointer.
+func (s ReflectionBox) GetPointer() unsafe.Pointer {
+	return unsafe.Pointer(s.Val.Pointer())
+}
+
+// The value should be settable unsafe.Pointer.
+func (s ReflectionBox) SetPointer(v unsafe.Pointer) {
+	s.Val.SetPointer(v)
+}
+
+func (s MapContainerReflectionBox) GetPointer() unsafe.Pointer {
+	return unsafe.Pointer(s.Container.MapIndex(s.Key).Pointer())
+}
+
+func (s MapContainerReflectionBox) SetPointer(v unsafe.Pointer) {
+	s.Container.SetMapIndex(s.Key, reflect.ValueOf(v))
+}
+
+func (p *NotInitializedMapContainerReflectionBox) GetPointer() unsafe.Pointer {
+	if !p.Initialized {
+		panic(msgReferencedNonInitializedVariable)
+	}
+	return unsafe.Pointer(p.Container.MapIndex(p.Key).Pointer())
+}
+
+func (p *NotInitializedMapContainerReflectionBox) SetPointer(v unsafe.Pointer) {
+	p.Container.SetMapIndex(p.Key, reflect.ValueOf(v))
+	p.Initialized = true
+}
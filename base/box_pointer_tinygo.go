@@ -0,0 +1,35 @@
+//go:build tinygo
+// +build tinygo
+
+package parser
+
+import (
+	"unsafe"
+)
+
+// TinyGo does not implement reflect.Value.Pointer() and reflect.Value.SetPointer().
+const msgPointerNotSupported = "Error: Pointer access of the reflection box is not supported on TinyGo."
+
+func (s ReflectionBox) GetPointer() unsafe.Pointer {
+	panic(msgPointerNotSupported)
+}
+
+func (s ReflectionBox) SetPointer(v unsafe.Pointer) {
+	panic(msgPointerNotSupported)
+}
+
+func (s MapContainerReflectionBox) GetPointer() unsafe.Pointer {
+	panic(msgPointerNotSupported)
+}
+
+func (s MapContainerReflectionBox) SetPointer(v unsafe.Pointer) {
+	panic(msgPointerNotSupported)
+}
+
+func (p *NotInitializedMapContainerReflectionBox) GetPointer() unsafe.Pointer {
+	panic(msgPointerNotSupported)
+}
+
+func (p *NotInitializedMapContainerReflectionBox) SetPointer(v unsafe.Pointer) {
+	panic(msgPointerNotSupported)
+}
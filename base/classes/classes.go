@@ -1,18 +1,25 @@
 package classes
 
 const (
-	Indirect    = ":base:Indirect"
-	Error       = ":base:Error"
-	Unmatched   = ":base:Unmatched"
-	Zero        = ":base:Zero"
-	Start       = ":base:Start"
-	FlatGroup   = ":base:FlatGroup"
-	Group       = ":base:Group"
-	First       = ":base:First"
-	LookAhead   = ":base:LookAhead"
-	LookAheadN  = ":base:LookAheadN"
-	LookBehind  = ":base:LookBehind"
-	LookBehindN = ":base:LookBehindN"
-	Repeat      = ":base:Repeat"
-	Trans       = ":base:Trans"
+	Indirect      = ":base:Indirect"
+	Error         = ":base:Error"
+	Unmatched     = ":base:Unmatched"
+	Zero          = ":base:Zero"
+	Start         = ":base:Start"
+	FlatGroup     = ":base:FlatGroup"
+	Group         = ":base:Group"
+	First         = ":base:First"
+	LookAhead     = ":base:LookAhead"
+	LookAheadN    = ":base:LookAheadN"
+	LookBehind    = ":base:LookBehind"
+	LookBehindN   = ":base:LookBehindN"
+	Repeat        = ":base:Repeat"
+	RepeatByCount = ":base:RepeatByCount"
+	Trans         = ":base:Trans"
+	SepBy         = ":base:SepBy"
+	SepBy1        = ":base:SepBy1"
+	SepEndBy      = ":base:SepEndBy"
+	Between       = ":base:Between"
+	Surrounded    = ":base:Surrounded"
+	Pratt         = ":base:Pratt"
 )
@@ -0,0 +1,289 @@
+package parser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity of the diagnostic.
+type SeverityType int
+
+const (
+	Severity_Error SeverityType = iota
+	Severity_Warning
+	Severity_Info
+)
+
+// Imprements Stringer.
+func (t SeverityType) String() string {
+	switch t {
+	case Severity_Error:
+		return "error"
+	case Severity_Warning:
+		return "warning"
+	case Severity_Info:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+// Labeled span of the diagnostic.
+type DiagnosticLabel struct {
+	SourcePosition
+	// Message shown under the span. It can be empty.
+	Message string
+}
+
+// Diagnostic report.
+type Diagnostic struct {
+	Severity SeverityType
+	// Error code. (e.g. "E0001") It can be empty.
+	Code    string
+	Message string
+	// The span marked with `^`.
+	Primary DiagnosticLabel
+	// The spans marked with `-`.
+	Secondary []DiagnosticLabel
+	Notes     []string
+	Help      string
+}
+
+// Options of RenderDiagnostic().
+type DiagnosticOptions struct {
+	// Source name shown in the location line. (e.g. file path)
+	Name    string
+	TabSize int
+	// Column mode of the location line.
+	ColumnMode ColumnMode
+	// Colorize the output with the ANSI escape sequences.
+	Color bool
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiBlue   = "\x1b[1;34m"
+	ansiCyan   = "\x1b[1;36m"
+)
+
+// Marked segment of a line.
+type diagnosticMark struct {
+	line    int
+	start   int
+	end     int
+	primary bool
+	// Message is shown on the last line of the span.
+	message string
+}
+
+// Diagnostic renderer
+type diagnosticRenderer struct {
+	src   string
+	index *LineIndex
+	opts  DiagnosticOptions
+	sb    strings.Builder
+	color string
+}
+
+// Write the text with the color.
+func (r *diagnosticRenderer) write(color, s string) {
+	if r.opts.Color && color != "" {
+		r.sb.WriteString(color)
+		r.sb.WriteString(s)
+		r.sb.WriteString(ansiReset)
+	} else {
+		r.sb.WriteString(s)
+	}
+}
+
+// Expand the tabs to the spaces.
+func (r *diagnosticRenderer) expand(s string) string {
+	return strings.ReplaceAll(s, "\t", strings.Repeat(" ", r.opts.TabSize))
+}
+
+// Split the label into the marks of each line.
+func (r *diagnosticRenderer) marks(label DiagnosticLabel, primary bool) []diagnosticMark {
+	start := label.Position
+	if start < 0 {
+		start = 0
+	} else if len(r.src) < start {
+		start = len(r.src)
+	}
+	end := start + label.Length
+	if len(r.src) < end {
+		end = len(r.src)
+	}
+
+	first := r.index.lineIndexOf(start)
+	last := first
+	if start < end {
+		last = r.index.lineIndexOf(end - 1)
+	}
+
+	marks := make([]diagnosticMark, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		m := diagnosticMark{
+			line:    i,
+			start:   r.index.lines[i].start,
+			end:     r.index.lines[i].end,
+			primary: primary,
+		}
+		if i == first {
+			m.start = start
+		}
+		if i == last {
+			if end < m.end {
+				m.end = end
+			}
+			m.message = label.Message
+		}
+		if m.end < m.start {
+			m.end = m.start
+		}
+		marks = append(marks, m)
+	}
+	return marks
+}
+
+// Render the diagnostic.
+func (r *diagnosticRenderer) render(d Diagnostic) string {
+	switch d.Severity {
+	case Severity_Error:
+		r.color = ansiRed
+	case Severity_Warning:
+		r.color = ansiYellow
+	default:
+		r.color = ansiCyan
+	}
+
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + d.Code + "]"
+	}
+	r.write(r.color, header)
+	r.write(ansiBold, ": "+d.Message)
+	r.sb.WriteString("\n")
+
+	marks := r.marks(d.Primary, true)
+	for _, label := range d.Secondary {
+		marks = append(marks, r.marks(label, false)...)
+	}
+	sort.SliceStable(marks, func(i, j int) bool {
+		return marks[i].line < marks[j].line
+	})
+
+	lastLine := marks[len(marks)-1].line
+	gutterWidth := len(strconv.Itoa(lastLine + 1))
+	blankGutter := strings.Repeat(" ", gutterWidth) + " |"
+
+	primaryLine := r.index.lines[marks[0].line]
+	for _, m := range marks {
+		if m.primary {
+			primaryLine = r.index.lines[m.line]
+			break
+		}
+	}
+	primaryStart := d.Primary.Position
+	if primaryStart < primaryLine.start {
+		primaryStart = primaryLine.start
+	} else if primaryLine.end < primaryStart {
+		primaryStart = primaryLine.end
+	}
+	col := measureColumns(r.src[primaryLine.start:primaryStart], r.opts.ColumnMode, r.opts.TabSize) + 1
+	location := strconv.Itoa(r.index.lineIndexOf(primaryStart)+1) + ":" + strconv.Itoa(col)
+	if r.opts.Name != "" {
+		location = r.opts.Name + ":" + location
+	}
+	r.write(ansiBlue, strings.Repeat(" ", gutterWidth)+"--> ")
+	r.sb.WriteString(location + "\n")
+	r.write(ansiBlue, blankGutter)
+	r.sb.WriteString("\n")
+
+	prevLine := -1
+	for i := 0; i < len(marks); {
+		line := marks[i].line
+		if 0 <= prevLine && prevLine+1 < line {
+			r.write(ansiBlue, "...")
+			r.sb.WriteString("\n")
+		}
+		prevLine = line
+
+		span := r.index.lines[line]
+		text := r.src[span.start:span.end]
+		lineNo := strconv.Itoa(line + 1)
+		r.write(ansiBlue, strings.Repeat(" ", gutterWidth-len(lineNo))+lineNo+" |")
+		if text != "" {
+			r.sb.WriteString(" " + r.expand(text))
+		}
+		r.sb.WriteString("\n")
+
+		for ; i < len(marks) && marks[i].line == line; i++ {
+			m := marks[i]
+			indent := StringWidth(r.src[span.start:m.start], r.opts.TabSize)
+			width := StringWidth(r.src[m.start:m.end], r.opts.TabSize)
+			if width == 0 {
+				width = 1
+			}
+
+			markChar, markColor := "-", ansiBlue
+			if m.primary {
+				markChar, markColor = "^", r.color
+			}
+			r.write(ansiBlue, blankGutter)
+			r.sb.WriteString(" " + strings.Repeat(" ", indent))
+			r.write(markColor, strings.Repeat(markChar, width))
+			if m.message != "" {
+				r.write(markColor, " "+m.message)
+			}
+			r.sb.WriteString("\n")
+		}
+	}
+
+	if len(d.Notes) != 0 || d.Help != "" {
+		r.write(ansiBlue, blankGutter)
+		r.sb.WriteString("\n")
+	}
+	for _, note := range d.Notes {
+		r.write(ansiBlue, strings.Repeat(" ", gutterWidth)+" =")
+		r.write(ansiBold, " note")
+		r.sb.WriteString(": " + note + "\n")
+	}
+	if d.Help != "" {
+		r.write(ansiBlue, strings.Repeat(" ", gutterWidth)+" =")
+		r.write(ansiBold, " help")
+		r.sb.WriteString(": " + d.Help + "\n")
+	}
+
+	return r.sb.String()
+}
+
+// Render the diagnostic like rustc and codespan.
+//
+//	error[E0001]: unexpected token
+//	 --> input.txt:2:5
+//	  |
+//	1 | let x =
+//	  |     - the binding starts here
+//	2 | foo bar
+//	  |     ^^^ expected an operator
+//	  |
+//	  = note: the expression is incomplete
+//	  = help: insert an operator
+//
+// The span is underlined by the display width of SourcePosition.Length.
+// The span over multiple lines is underlined on each line, and the message is shown on the last line.
+func RenderDiagnostic(src string, d Diagnostic, opts DiagnosticOptions) string {
+	if opts.TabSize <= 0 {
+		opts.TabSize = 4
+	}
+	r := &diagnosticRenderer{
+		src:   src,
+		index: NewLineIndex(src),
+		opts:  opts,
+	}
+	return r.render(d)
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderDiagnostic(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		d    Diagnostic
+		opts DiagnosticOptions
+		want string
+	}{{
+		name: "1",
+		src:  "let x =\nfoo bar\n",
+		d: Diagnostic{
+			Code:    "E0001",
+			Message: "unexpected token",
+			Primary: DiagnosticLabel{
+				SourcePosition: SourcePosition{Position: 12, Length: 3},
+				Message:        "expected an operator",
+			},
+			Secondary: []DiagnosticLabel{{
+				SourcePosition: SourcePosition{Position: 4, Length: 1},
+				Message:        "the binding starts here",
+			}},
+			Notes: []string{"the expression is incomplete"},
+			Help:  "insert an operator",
+		},
+		opts: DiagnosticOptions{Name: "input.txt"},
+		want: "error[E0001]: unexpected token\n" +
+			" --> input.txt:2:5\n" +
+			"  |\n" +
+			"1 | let x =\n" +
+			"  |     - the binding starts here\n" +
+			"2 | foo bar\n" +
+			"  |     ^^^ expected an operator\n" +
+			"  |\n" +
+			"  = note: the expression is incomplete\n" +
+			"  = help: insert an operator\n",
+	}, {
+		name: "wide characters and tabs",
+		src:  "\tあいう = 1",
+		d: Diagnostic{
+			Severity: Severity_Warning,
+			Message:  "unused variable",
+			Primary:  DiagnosticLabel{SourcePosition: SourcePosition{Position: 1, Length: 9}},
+		},
+		opts: DiagnosticOptions{TabSize: 2, ColumnMode: ColumnMode_Rune},
+		want: "warning: unused variable\n" +
+			" --> 1:2\n" +
+			"  |\n" +
+			"1 |   あいう = 1\n" +
+			"  |   ^^^^^^\n",
+	}, {
+		name: "multi-line span",
+		src:  "a\nfoo(\n  bar\n)\nb",
+		d: Diagnostic{
+			Message: "unclosed call",
+			Primary: DiagnosticLabel{SourcePosition: SourcePosition{Position: 2, Length: 13}, Message: "in this call"},
+		},
+		want: "error: unclosed call\n" +
+			" --> 2:1\n" +
+			"  |\n" +
+			"2 | foo(\n" +
+			"  | ^^^^\n" +
+			"3 |   bar\n" +
+			"  | ^^^^^\n" +
+			"4 | )\n" +
+			"  | ^ in this call\n",
+	}, {
+		name: "empty span and gap",
+		src:  "x\n\n\n\ny",
+		d: Diagnostic{
+			Message:   "missing semicolon",
+			Primary:   DiagnosticLabel{SourcePosition: SourcePosition{Position: 6, Length: 0}},
+			Secondary: []DiagnosticLabel{{SourcePosition: SourcePosition{Position: 0, Length: 1}}},
+		},
+		want: "error: missing semicolon\n" +
+			" --> 5:2\n" +
+			"  |\n" +
+			"1 | x\n" +
+			"  | -\n" +
+			"...\n" +
+			"5 | y\n" +
+			"  |  ^\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderDiagnostic(tt.src, tt.d, tt.opts)
+			if got != tt.want {
+				t.Errorf("RenderDiagnostic() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDiagnosticColor(t *testing.T) {
+	got := RenderDiagnostic("abc", Diagnostic{
+		Message: "bad",
+		Primary: DiagnosticLabel{SourcePosition: SourcePosition{Position: 1, Length: 1}},
+	}, DiagnosticOptions{Color: true})
+
+	if !strings.Contains(got, ansiRed+"^"+ansiReset) {
+		t.Errorf("RenderDiagnostic() = %q, the mark is not colored", got)
+	}
+}
@@ -0,0 +1,391 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Edit operation type of the AST diff.
+type DiffOpType int
+
+const (
+	// The subtree is inserted at NewPath.
+	DiffOp_Insert DiffOpType = iota
+	// The subtree at OldPath is deleted.
+	DiffOp_Delete
+	// The attributes (class name, type, opcode, source position or scalar value) of the node are changed.
+	// The children are compared separately, unless the type is changed. (e.g. from a list to a scalar)
+	DiffOp_Update
+	// The subtree at OldPath is moved to NewPath.
+	DiffOp_Move
+)
+
+// Imprements Stringer.
+func (t DiffOpType) String() string {
+	switch t {
+	case DiffOp_Insert:
+		return "insert"
+	case DiffOp_Delete:
+		return "delete"
+	case DiffOp_Update:
+		return "update"
+	case DiffOp_Move:
+		return "move"
+	default:
+		return "unknown"
+	}
+}
+
+// Path from the root to the AST.
+// Each item is the index of ListOfAst, or 0 (car) / 1 (cdr) of AstCons.
+type AstPath []int
+
+// Format the path as `/0/2/1`. The root is `/`.
+func (p AstPath) String() string {
+	if len(p) == 0 {
+		return "/"
+	}
+	var sb strings.Builder
+	for _, i := range p {
+		sb.WriteString("/")
+		sb.WriteString(strconv.Itoa(i))
+	}
+	return sb.String()
+}
+
+// Append the index to the copy of the path.
+func (p AstPath) child(i int) AstPath {
+	w := make(AstPath, len(p), len(p)+1)
+	copy(w, p)
+	return append(w, i)
+}
+
+// Edit operation of the AST diff.
+// OldPath is the path in the old tree, and NewPath is the path in the new tree.
+// (They are not the paths in the intermediate trees.)
+type DiffOp struct {
+	Op DiffOpType
+	// Path in the old tree. It is nil for Insert.
+	OldPath AstPath
+	// Path in the new tree. It is nil for Delete.
+	NewPath AstPath
+	// Node in the old tree. It is the zero value for Insert.
+	Old Ast
+	// Node in the new tree. It is the zero value for Delete.
+	New Ast
+}
+
+// Format the operation as a line of FormatDiff().
+func (d DiffOp) String() string {
+	switch d.Op {
+	case DiffOp_Insert:
+		return "+ " + d.NewPath.String() + " " + astSummary(d.New)
+	case DiffOp_Delete:
+		return "- " + d.OldPath.String() + " " + astSummary(d.Old)
+	case DiffOp_Update:
+		return "~ " + d.OldPath.String() + " " + astSummary(d.Old) + " -> " + astSummary(d.New)
+	case DiffOp_Move:
+		return "> " + d.OldPath.String() + " -> " + d.NewPath.String() + " " + astSummary(d.Old)
+	default:
+		return "? " + d.OldPath.String()
+	}
+}
+
+// Options of DiffAst().
+type DiffOptions struct {
+	IgnorePosition bool
+	IgnoreOpCode   bool
+}
+
+// Test whether the value of ListOfAst or AstCons matches the AST type.
+func hasChildren(ast Ast) bool {
+	var ok bool
+	switch ast.Type {
+	case AstType_ListOfAst:
+		_, ok = ast.Value.(AstSlice)
+		ok = ok || ast.Value == nil
+	case AstType_AstCons:
+		_, ok = ast.Value.(AstCons)
+	}
+	return ok
+}
+
+// Short description of the AST node. (class name, type and scalar value, or the number of the children)
+func astSummary(ast Ast) string {
+	var sb strings.Builder
+	if ast.ClassName != "" {
+		sb.WriteString(ast.ClassName)
+		sb.WriteString(" ")
+	}
+	sb.WriteString(ast.Type.String())
+	switch ast.Type {
+	case AstType_Nil, AstType_Function:
+	case AstType_ListOfAst, AstType_AstCons:
+		if hasChildren(ast) {
+			sb.WriteString("[" + strconv.Itoa(len(astChildren(ast))) + "]")
+		} else {
+			sb.WriteString(fmt.Sprintf("(%v)", ast.Value))
+		}
+	case AstType_String:
+		if v, ok := ast.Value.(string); ok {
+			sb.WriteString("(" + strconv.Quote(v) + ")")
+		} else {
+			sb.WriteString(fmt.Sprintf("(%v)", ast.Value))
+		}
+	case AstType_Rune:
+		if v, ok := ast.Value.(rune); ok {
+			sb.WriteString("(" + strconv.QuoteRune(v) + ")")
+		} else {
+			sb.WriteString(fmt.Sprintf("(%v)", ast.Value))
+		}
+	default:
+		sb.WriteString(fmt.Sprintf("(%v)", ast.Value))
+	}
+	if ast.OpCode != 0 {
+		sb.WriteString(" op=" + strconv.FormatUint(uint64(ast.OpCode), 10))
+	}
+	if ast.SourcePosition != (SourcePosition{}) {
+		sb.WriteString(" @" + strconv.Itoa(ast.Position) + "+" + strconv.Itoa(ast.Length))
+	}
+	return sb.String()
+}
+
+// Get the pointer of the function value. It returns false if the value is nil or not a pointer-like kind.
+func funcPointer(v interface{}) (uintptr, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return 0, false
+	}
+	switch rv.Kind() {
+	case reflect.Func, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.UnsafePointer:
+		return rv.Pointer(), true
+	}
+	return 0, false
+}
+
+// Diff state
+type differ struct {
+	opts DiffOptions
+	ops  []DiffOp
+}
+
+// Test whether the attributes of the nodes are equal. The children are not compared.
+func (d *differ) sameNode(a, b Ast) bool {
+	if a.ClassName != b.ClassName || a.Type != b.Type {
+		return false
+	}
+	if !d.opts.IgnoreOpCode && a.OpCode != b.OpCode {
+		return false
+	}
+	if !d.opts.IgnorePosition && a.SourcePosition != b.SourcePosition {
+		return false
+	}
+	switch a.Type {
+	case AstType_ListOfAst, AstType_AstCons:
+		x, y := hasChildren(a), hasChildren(b)
+		if !x || !y {
+			return x == y && reflect.DeepEqual(a.Value, b.Value)
+		}
+		return true
+	case AstType_Function:
+		x, okX := funcPointer(a.Value)
+		y, okY := funcPointer(b.Value)
+		if okX && okY {
+			return x == y
+		}
+		if okX || okY {
+			return false
+		}
+		return reflect.DeepEqual(a.Value, b.Value)
+	default:
+		return reflect.DeepEqual(a.Value, b.Value)
+	}
+}
+
+// Test whether the subtrees are equal.
+func (d *differ) equal(a, b Ast) bool {
+	if !d.sameNode(a, b) {
+		return false
+	}
+	x, y := astChildren(a), astChildren(b)
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !d.equal(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Test whether the nodes can be compared recursively. (the same class name and the same kind of type)
+func similar(a, b Ast) bool {
+	return a.ClassName == b.ClassName && a.Type == b.Type
+}
+
+// Compare the nodes at the paths.
+func (d *differ) node(a, b Ast, pa, pb AstPath) {
+	if !d.sameNode(a, b) {
+		d.ops = append(d.ops, DiffOp{Op: DiffOp_Update, OldPath: pa, NewPath: pb, Old: a, New: b})
+	}
+	if a.Type != b.Type {
+		// The subtree is replaced.
+		return
+	}
+
+	switch a.Type {
+	case AstType_ListOfAst:
+		d.list(astChildren(a), astChildren(b), pa, pb)
+	case AstType_AstCons:
+		x, y := astChildren(a), astChildren(b)
+		if len(x) != 2 || len(y) != 2 {
+			// The value does not match the AST type. It is reported by the update above.
+			return
+		}
+		d.node(x[0], y[0], pa.child(0), pb.child(0))
+		d.node(x[1], y[1], pa.child(1), pb.child(1))
+	}
+}
+
+// Longest common subsequence of the index pairs.
+func lcs(n, m int, eq func(i, j int) bool) [][2]int {
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, m+1)
+	}
+	for i := n - 1; 0 <= i; i-- {
+		for j := m - 1; 0 <= j; j-- {
+			switch {
+			case eq(i, j):
+				dp[i][j] = dp[i+1][j+1] + 1
+			case dp[i+1][j] >= dp[i][j+1]:
+				dp[i][j] = dp[i+1][j]
+			default:
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+
+	pairs := make([][2]int, 0, dp[0][0])
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case eq(i, j):
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// Compare the children.
+// The equal subtrees are kept, and the nodes between them are compared recursively.
+// The similar nodes are paired first, and the surplus nodes are deleted or inserted.
+func (d *differ) list(a, b AstSlice, pa, pb AstPath) {
+	anchors := lcs(len(a), len(b), func(i, j int) bool {
+		return d.equal(a[i], b[j])
+	})
+	anchors = append(anchors, [2]int{len(a), len(b)})
+
+	i0, j0 := 0, 0
+	for _, anchor := range anchors {
+		i1, j1 := anchor[0], anchor[1]
+		ga, gb := a[i0:i1], b[j0:j1]
+
+		pairs := lcs(len(ga), len(gb), func(i, j int) bool {
+			return similar(ga[i], gb[j])
+		})
+		pairs = append(pairs, [2]int{len(ga), len(gb)})
+
+		i, j := 0, 0
+		for _, pair := range pairs {
+			// The remaining nodes are replaced one by one.
+			for ; i < pair[0] && j < pair[1]; i, j = i+1, j+1 {
+				d.node(ga[i], gb[j], pa.child(i0+i), pb.child(j0+j))
+			}
+			for ; i < pair[0]; i++ {
+				d.ops = append(d.ops, DiffOp{Op: DiffOp_Delete, OldPath: pa.child(i0 + i), Old: ga[i]})
+			}
+			for ; j < pair[1]; j++ {
+				d.ops = append(d.ops, DiffOp{Op: DiffOp_Insert, NewPath: pb.child(j0 + j), New: gb[j]})
+			}
+			if i < len(ga) && j < len(gb) {
+				d.node(ga[i], gb[j], pa.child(i0+i), pb.child(j0+j))
+				i++
+				j++
+			}
+		}
+		i0, j0 = i1+1, j1+1
+	}
+}
+
+// Convert the pairs of the deleted and inserted equal subtrees into the moves.
+func (d *differ) detectMoves() {
+	moved := make([]bool, len(d.ops))
+	out := make([]DiffOp, 0, len(d.ops))
+
+	for i := range d.ops {
+		if moved[i] {
+			continue
+		}
+		op := d.ops[i]
+		if op.Op == DiffOp_Delete || op.Op == DiffOp_Insert {
+			for j := i + 1; j < len(d.ops); j++ {
+				other := d.ops[j]
+				if moved[j] || other.Op == op.Op || other.Op != DiffOp_Delete && other.Op != DiffOp_Insert {
+					continue
+				}
+				del, ins := op, other
+				if op.Op == DiffOp_Insert {
+					del, ins = other, op
+				}
+				if d.equal(del.Old, ins.New) {
+					moved[j] = true
+					op = DiffOp{Op: DiffOp_Move, OldPath: del.OldPath, NewPath: ins.NewPath, Old: del.Old, New: ins.New}
+					break
+				}
+			}
+		}
+		out = append(out, op)
+	}
+	d.ops = out
+}
+
+// Compare the AST trees and get the edit script.
+// The operations are in the document order, and the paths are the ones of the old and new trees.
+// It returns an empty slice if the trees are equal.
+func DiffAst(a, b Ast, opts DiffOptions) []DiffOp {
+	d := &differ{opts: opts, ops: make([]DiffOp, 0)}
+	d.node(a, b, AstPath{}, AstPath{})
+	d.detectMoves()
+	return d.ops
+}
+
+// Compare the AST slices and get the edit script. The first item of the paths is the index of the slice.
+func DiffAstSlice(a, b AstSlice, opts DiffOptions) []DiffOp {
+	d := &differ{opts: opts, ops: make([]DiffOp, 0)}
+	d.list(a, b, AstPath{}, AstPath{})
+	d.detectMoves()
+	return d.ops
+}
+
+// Format the edit script. One operation per line;
+// `+ NewPath node` (insert), `- OldPath node` (delete),
+// `~ OldPath old -> new` (update) and `> OldPath -> NewPath node` (move).
+func FormatDiff(ops []DiffOp) string {
+	var sb strings.Builder
+	for i, op := range ops {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(op.String())
+	}
+	return sb.String()
+}
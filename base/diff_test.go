@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"testing"
+)
+
+func diffTestList(className string, children ...Ast) Ast {
+	return Ast{ClassName: className, Type: AstType_ListOfAst, Value: AstSlice(children)}
+}
+
+func diffTestInt(className string, v int64) Ast {
+	return Ast{ClassName: className, Type: AstType_Int, Value: v}
+}
+
+func TestDiffAst(t *testing.T) {
+	l, n := diffTestList, diffTestInt
+	at := func(ast Ast, pos int, op AstOpCodeType) Ast {
+		ast.SourcePosition = SourcePosition{Position: pos, Length: 1}
+		ast.OpCode = op
+		return ast
+	}
+
+	tests := []struct {
+		name string
+		a    Ast
+		b    Ast
+		opts DiffOptions
+		want string
+	}{{
+		name: "equal",
+		a:    l("Root", n("A", 1), l("B", n("C", 2))),
+		b:    l("Root", n("A", 1), l("B", n("C", 2))),
+		want: "",
+	}, {
+		name: "update value",
+		a:    l("Root", n("A", 1), l("B", n("C", 2), n("D", 3))),
+		b:    l("Root", n("A", 1), l("B", n("C", 2), n("D", 4))),
+		want: "~ /1/1 D Int(3) -> D Int(4)",
+	}, {
+		name: "update class",
+		a:    l("Root", l("A", n("X", 1))),
+		b:    l("Root", l("B", n("X", 2))),
+		want: "~ /0 A ListOfAst[1] -> B ListOfAst[1]\n~ /0/0 X Int(1) -> X Int(2)",
+	}, {
+		name: "update type",
+		a:    l("Root", l("A", n("X", 1))),
+		b:    l("Root", n("A", 1)),
+		want: "~ /0 A ListOfAst[1] -> A Int(1)",
+	}, {
+		name: "insert and delete",
+		a:    l("Root", n("A", 1), n("B", 2), n("C", 3)),
+		b:    l("Root", n("A", 1), n("X", 9), n("C", 3), n("D", 4)),
+		want: "~ /1 B Int(2) -> X Int(9)\n+ /3 D Int(4)",
+	}, {
+		name: "delete",
+		a:    l("Root", n("A", 1), n("B", 2), n("C", 3)),
+		b:    l("Root", n("C", 3)),
+		want: "- /0 A Int(1)\n- /1 B Int(2)",
+	}, {
+		name: "move",
+		a:    l("Root", l("A", n("X", 1)), n("B", 2), n("C", 3)),
+		b:    l("Root", n("B", 2), n("C", 3), l("A", n("X", 1))),
+		want: "> /0 -> /2 A ListOfAst[1]",
+	}, {
+		name: "cons",
+		a:    Ast{Type: AstType_AstCons, Value: AstCons{Car: n("K", 1), Cdr: n("V", 2)}},
+		b:    Ast{Type: AstType_AstCons, Value: AstCons{Car: n("K", 1), Cdr: n("V", 3)}},
+		want: "~ /1 V Int(2) -> V Int(3)",
+	}, {
+		name: "position and opcode",
+		a:    l("Root", at(n("A", 1), 0, 1), at(n("B", 2), 2, 0)),
+		b:    l("Root", at(n("A", 1), 0, 2), at(n("B", 2), 4, 0)),
+		want: "~ /0 A Int(1) op=1 @0+1 -> A Int(1) op=2 @0+1\n~ /1 B Int(2) @2+1 -> B Int(2) @4+1",
+	}, {
+		name: "ignore position",
+		a:    l("Root", at(n("A", 1), 0, 1), at(n("B", 2), 2, 0)),
+		b:    l("Root", at(n("A", 1), 0, 2), at(n("B", 2), 4, 0)),
+		opts: DiffOptions{IgnorePosition: true},
+		want: "~ /0 A Int(1) op=1 @0+1 -> A Int(1) op=2 @0+1",
+	}, {
+		name: "ignore position and opcode",
+		a:    l("Root", at(n("A", 1), 0, 1), at(n("B", 2), 2, 0)),
+		b:    l("Root", at(n("A", 1), 0, 2), at(n("B", 2), 4, 0)),
+		opts: DiffOptions{IgnorePosition: true, IgnoreOpCode: true},
+		want: "",
+	}, {
+		name: "same function",
+		a:    l("Root", Ast{ClassName: "F", Type: AstType_Function, Value: diffTestInt}),
+		b:    l("Root", Ast{ClassName: "F", Type: AstType_Function, Value: diffTestInt}),
+		want: "",
+	}, {
+		name: "other function",
+		a:    l("Root", Ast{ClassName: "F", Type: AstType_Function, Value: diffTestInt}),
+		b:    l("Root", Ast{ClassName: "F", Type: AstType_Function, Value: diffTestList}),
+		want: "~ /0 F Function -> F Function",
+	}, {
+		name: "nil function",
+		a:    l("Root", Ast{ClassName: "F", Type: AstType_Function}),
+		b:    l("Root", Ast{ClassName: "F", Type: AstType_Function}),
+		want: "",
+	}, {
+		name: "mismatched string",
+		a:    l("Root", Ast{ClassName: "S", Type: AstType_String, Value: 1}),
+		b:    l("Root", Ast{ClassName: "S", Type: AstType_String, Value: "x"}),
+		want: "~ /0 S String(1) -> S String(\"x\")",
+	}, {
+		name: "mismatched cons",
+		a:    l("Root", Ast{ClassName: "P", Type: AstType_AstCons, Value: "x"}),
+		b:    l("Root", Ast{ClassName: "P", Type: AstType_AstCons, Value: AstCons{Car: n("K", 1), Cdr: n("V", 2)}}),
+		want: "~ /0 P AstCons(x) -> P AstCons[2]",
+	}, {
+		name: "same mismatched cons",
+		a:    l("Root", Ast{ClassName: "P", Type: AstType_AstCons, Value: "x"}),
+		b:    l("Root", Ast{ClassName: "P", Type: AstType_AstCons, Value: "x"}),
+		want: "",
+	}, {
+		name: "nil and non-pointer function",
+		a:    l("Root", Ast{ClassName: "F", Type: AstType_Function}),
+		b:    l("Root", Ast{ClassName: "F", Type: AstType_Function, Value: "f"}),
+		want: "~ /0 F Function -> F Function",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDiff(DiffAst(tt.a, tt.b, tt.opts)); got != tt.want {
+				t.Errorf("DiffAst() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffAstSlice(t *testing.T) {
+	l, n := diffTestList, diffTestInt
+	a := AstSlice{n("A", 1), l("B", n("S", 1)), n("C", 3)}
+	b := AstSlice{l("B", n("S", 2)), n("C", 3), n("A", 1)}
+
+	ops := DiffAstSlice(a, b, DiffOptions{})
+	want := "> /0 -> /2 A Int(1)\n~ /1/0 S Int(1) -> S Int(2)"
+	if got := FormatDiff(ops); got != want {
+		t.Errorf("DiffAstSlice() =\n%v\nwant\n%v", got, want)
+	}
+	if len(ops) != 2 || ops[0].Op != DiffOp_Move || ops[1].NewPath.String() != "/0/0" {
+		t.Errorf("DiffAstSlice() = %+v", ops)
+	}
+}
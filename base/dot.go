@@ -0,0 +1,260 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options of the Graphviz DOT emitters.
+type DotOptions struct {
+	// Name of the graph. Default is "ast" or "parser".
+	Name string
+	// Maximum length (in runes) of the values in the labels. Default is 32. If it is negative, the values are not truncated.
+	MaxValueLength int
+}
+
+// Quote the DOT string.
+func dotQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteString("\"")
+	for _, c := range s {
+		switch c {
+		case '"', '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(c)
+		case '\n':
+			sb.WriteString("\\n")
+		case '\r':
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteString("\"")
+	return sb.String()
+}
+
+// Truncate the string to n runes.
+func dotTruncate(s string, n int) string {
+	if n < 0 {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+// Emitter of the AST graph
+type astDotEmitter struct {
+	opts DotOptions
+	sb   strings.Builder
+	seq  int
+}
+
+// Label of the node. (class name, type, value and span)
+func (e *astDotEmitter) label(ast Ast) string {
+	lines := make([]string, 0, 3)
+	if ast.ClassName != "" {
+		lines = append(lines, ast.ClassName)
+	}
+
+	var value string
+	switch ast.Type {
+	case AstType_Nil, AstType_Function:
+	case AstType_AstCons, AstType_ListOfAst:
+		if !hasChildren(ast) {
+			value = fmt.Sprint(ast.Value)
+		}
+	case AstType_String:
+		if v, ok := ast.Value.(string); ok {
+			value = strconv.Quote(v)
+		} else {
+			value = fmt.Sprint(ast.Value)
+		}
+	case AstType_Rune:
+		if v, ok := ast.Value.(rune); ok {
+			value = strconv.QuoteRune(v)
+		} else {
+			value = fmt.Sprint(ast.Value)
+		}
+	default:
+		value = fmt.Sprint(ast.Value)
+	}
+	if value != "" {
+		lines = append(lines, ast.Type.String()+" "+dotTruncate(value, e.opts.MaxValueLength))
+	} else {
+		lines = append(lines, ast.Type.String())
+	}
+
+	lines = append(lines, "@"+strconv.Itoa(ast.Position)+"+"+strconv.Itoa(ast.Length))
+	return strings.Join(lines, "\n")
+}
+
+// Emit the node and its children, and get the node ID.
+// The value that does not match the AST type is shown in the label, and has no edges.
+func (e *astDotEmitter) node(ast Ast) string {
+	id := "n" + strconv.Itoa(e.seq)
+	e.seq++
+
+	e.sb.WriteString("\t" + id + " [label=" + dotQuote(e.label(ast)) + "];\n")
+
+	switch ast.Type {
+	case AstType_ListOfAst:
+		children, _ := ast.Value.(AstSlice)
+		for i, child := range children {
+			childId := e.node(child)
+			e.sb.WriteString("\t" + id + " -> " + childId + " [label=\"" + strconv.Itoa(i) + "\"];\n")
+		}
+	case AstType_AstCons:
+		cons, ok := ast.Value.(AstCons)
+		if !ok {
+			break
+		}
+		carId := e.node(cons.Car)
+		e.sb.WriteString("\t" + id + " -> " + carId + " [label=\"car\", style=dashed, color=blue];\n")
+		cdrId := e.node(cons.Cdr)
+		e.sb.WriteString("\t" + id + " -> " + cdrId + " [label=\"cdr\", style=dashed, color=red];\n")
+	}
+	return id
+}
+
+// Emit the AST trees as a Graphviz DOT digraph.
+// Each node shows the class name, type, value and span (`@position+length`).
+// The edges of ListOfAst are labeled with the indexes, and the ones of AstCons are labeled with car and cdr.
+func AstSliceToDot(asts AstSlice, opts DotOptions) string {
+	if opts.Name == "" {
+		opts.Name = "ast"
+	}
+	if opts.MaxValueLength == 0 {
+		opts.MaxValueLength = 32
+	}
+
+	e := &astDotEmitter{opts: opts}
+	e.sb.WriteString("digraph " + dotQuote(opts.Name) + " {\n")
+	e.sb.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	for _, ast := range asts {
+		e.node(ast)
+	}
+	e.sb.WriteString("}\n")
+	return e.sb.String()
+}
+
+// Emit the AST tree as a Graphviz DOT digraph.
+func AstToDot(ast Ast, opts DotOptions) string {
+	return AstSliceToDot(AstSlice{ast}, opts)
+}
+
+// Node of the parser graph
+type parserGraphNode struct {
+	scope     string
+	className string
+	calls     int
+	matched   int
+}
+
+// ParserTracer that records the combinator graph of the parser.
+// The nodes are the traced parsers, and the edges are the calls from the parent parsers.
+// Only the parsers called while recording appear in the graph.
+// NOTE: It's not thread safe.
+//
+//	graph := NewParserGraph()
+//	parser := DebugTrace("expr", graph)(expr())
+//	parser(*NewStringParserContext("1+2"))
+//	dot := graph.Dot(DotOptions{})
+type ParserGraph struct {
+	nodes map[int]*parserGraphNode
+	edges map[[2]int]int
+	stack []int
+}
+
+// Constructor
+func NewParserGraph() *ParserGraph {
+	return &ParserGraph{
+		nodes: make(map[int]*parserGraphNode),
+		edges: make(map[[2]int]int),
+		stack: make([]int, 0),
+	}
+}
+
+// before event
+func (g *ParserGraph) Before(scope string, trNo int, className string, ctx *ParserContext) {
+	node, ok := g.nodes[trNo]
+	if !ok {
+		node = &parserGraphNode{scope: scope, className: className}
+		g.nodes[trNo] = node
+	}
+	node.calls++
+
+	if n := len(g.stack); n != 0 {
+		g.edges[[2]int{g.stack[n-1], trNo}]++
+	}
+	g.stack = append(g.stack, trNo)
+}
+
+// after event
+func (g *ParserGraph) After(scope string, trNo int, className string, ctx *ParserContext, err error) {
+	if err == nil && ctx.MatchStatus == MatchStatus_Matched {
+		g.nodes[trNo].matched++
+	}
+	g.pop()
+}
+
+// error event
+func (g *ParserGraph) Panic(scope string, trNo int, className string, ctx *ParserContext, r interface{}) {
+	g.pop()
+}
+
+func (g *ParserGraph) pop() {
+	if n := len(g.stack); n != 0 {
+		g.stack = g.stack[:n-1]
+	}
+}
+
+// Emit the recorded combinator graph as a Graphviz DOT digraph.
+// Each node shows the class name, scope, tracking number and the counts of the calls and matches.
+// The edges are labeled with the counts of the calls.
+func (g *ParserGraph) Dot(opts DotOptions) string {
+	if opts.Name == "" {
+		opts.Name = "parser"
+	}
+
+	ids := make([]int, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	edges := make([][2]int, 0, len(g.edges))
+	for edge := range g.edges {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] != edges[j][0] {
+			return edges[i][0] < edges[j][0]
+		}
+		return edges[i][1] < edges[j][1]
+	})
+
+	var sb strings.Builder
+	sb.WriteString("digraph " + dotQuote(opts.Name) + " {\n")
+	sb.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	for _, id := range ids {
+		node := g.nodes[id]
+		className := node.className
+		if className == "" {
+			className = "(anonymous)"
+		}
+		label := className + "\n" + node.scope + " #" + strconv.Itoa(id) + "\n" +
+			"calls " + strconv.Itoa(node.calls) + ", matched " + strconv.Itoa(node.matched)
+		sb.WriteString("\tp" + strconv.Itoa(id) + " [label=" + dotQuote(label) + "];\n")
+	}
+	for _, edge := range edges {
+		sb.WriteString("\tp" + strconv.Itoa(edge[0]) + " -> p" + strconv.Itoa(edge[1]) +
+			" [label=\"" + strconv.Itoa(g.edges[edge]) + "\"];\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+package parser_test
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/string"
+)
+
+func TestAstToDot(t *testing.T) {
+	ast := Ast{ClassName: "Call", Type: AstType_ListOfAst, SourcePosition: SourcePosition{Position: 0, Length: 9}, Value: AstSlice{
+		{ClassName: "Name", Type: AstType_String, Value: "say \"hi\"", SourcePosition: SourcePosition{Position: 0, Length: 8}},
+		{Type: AstType_AstCons, Value: AstCons{
+			Car: Ast{Type: AstType_Int, Value: int64(1)},
+			Cdr: Ast{Type: AstType_Nil},
+		}},
+	}}
+
+	got := AstToDot(ast, DotOptions{MaxValueLength: 5})
+	want := `digraph "ast" {
+	node [shape=box, fontname="monospace"];
+	n0 [label="Call\nListOfAst\n@0+9"];
+	n1 [label="Name\nString \"say ...\n@0+8"];
+	n0 -> n1 [label="0"];
+	n2 [label="AstCons\n@0+0"];
+	n3 [label="Int 1\n@0+0"];
+	n2 -> n3 [label="car", style=dashed, color=blue];
+	n4 [label="Nil\n@0+0"];
+	n2 -> n4 [label="cdr", style=dashed, color=red];
+	n0 -> n2 [label="1"];
+}
+`
+	if got != want {
+		t.Errorf("AstToDot() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestAstToDotMismatchedValue(t *testing.T) {
+	ast := Ast{ClassName: "Root", Type: AstType_ListOfAst, Value: AstSlice{
+		{Type: AstType_String, Value: 1},
+		{Type: AstType_AstCons, Value: "x"},
+		{Type: AstType_ListOfAst, Value: 2},
+	}}
+
+	got := AstToDot(ast, DotOptions{})
+	want := `digraph "ast" {
+	node [shape=box, fontname="monospace"];
+	n0 [label="Root\nListOfAst\n@0+0"];
+	n1 [label="String 1\n@0+0"];
+	n0 -> n1 [label="0"];
+	n2 [label="AstCons x\n@0+0"];
+	n0 -> n2 [label="1"];
+	n3 [label="ListOfAst 2\n@0+0"];
+	n0 -> n3 [label="2"];
+}
+`
+	if got != want {
+		t.Errorf("AstToDot() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestParserGraph(t *testing.T) {
+	graph := NewParserGraph()
+	parser := DebugTrace("num", graph)(
+		FlatGroup(
+			Start(),
+			OneOrMoreTimes(Number()),
+			End(),
+		),
+	)
+
+	for _, s := range []string{"123", "12a"} {
+		if _, err := parser(*NewStringParserContext(s)); err != nil {
+			t.Fatalf("parser() error = %v", err)
+		}
+	}
+
+	// The tracking numbers are global, so they are renumbered from 1.
+	got := graph.Dot(DotOptions{Name: "num"})
+	if m := regexp.MustCompile(`p(\d+) \[`).FindStringSubmatch(got); m != nil {
+		offset, _ := strconv.Atoi(m[1])
+		got = regexp.MustCompile(`(p|#)(\d+)`).ReplaceAllStringFunc(got, func(s string) string {
+			n, _ := strconv.Atoi(s[1:])
+			return s[:1] + strconv.Itoa(n-offset+1)
+		})
+	}
+	want := `digraph "num" {
+	node [shape=box, fontname="monospace"];
+	p1 [label=":base:Start\n/num #1\ncalls 2, matched 2"];
+	p2 [label=":string:Number\n/num #2\ncalls 7, matched 5"];
+	p3 [label=":base:Repeat\n/num #3\ncalls 2, matched 2"];
+	p4 [label=":string:End\n/num #4\ncalls 2, matched 1"];
+	p5 [label=":base:FlatGroup\n/num #5\ncalls 2, matched 1"];
+	p6 [label=":Base:DebugTrace\n/num #6\ncalls 2, matched 1"];
+	p3 -> p2 [label="7"];
+	p5 -> p1 [label="2"];
+	p5 -> p3 [label="2"];
+	p5 -> p4 [label="2"];
+	p6 -> p5 [label="2"];
+}
+`
+	if got != want {
+		t.Errorf("Dot() =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Structured error returned by the parsers and transformers.
+// Use errors.As() to get it from the returned error.
+type ParseError struct {
+	// Source position where the error occurred.
+	SourcePosition
+	// Line and column. They are 0 until Locate() is called.
+	Line int
+	Col  int
+	// Source name. It is set by SourceSet.Locate().
+	FileName string
+	// Source lines and guide. It is empty until Locate() is called.
+	ErrSource string
+	// Class name of the innermost parser (or transformer owner) that failed.
+	ClassName string
+	// Class names of the parsers that the error passed through, from the innermost to the outermost.
+	RuleStack []string
+	// Wrapped error.
+	Cause error
+}
+
+// Imprements error.
+func (e *ParseError) Error() string {
+	msg := "Parse error"
+	if e.Cause != nil {
+		msg = e.Cause.Error()
+	}
+	if 0 < e.Line {
+		if e.FileName != "" {
+			msg += " at " + e.FileName + ":" + strconv.Itoa(e.Line) + ":" + strconv.Itoa(e.Col)
+		} else {
+			msg += " at Line " + strconv.Itoa(e.Line) + ", Col " + strconv.Itoa(e.Col)
+		}
+		if e.ErrSource != "" {
+			msg += "\n" + e.ErrSource
+		}
+	}
+	return msg
+}
+
+// Imprements errors.Unwrap().
+func (e *ParseError) Unwrap() error {
+	return e.Cause
+}
+
+// Set the line, column and source lines from the source string.
+func (e *ParseError) Locate(src string, tabSize int) *ParseError {
+	pos := GetLineAndColPosition(src, e.SourcePosition, tabSize)
+	e.Line = pos.Line
+	e.Col = pos.Col
+	e.ErrSource = pos.ErrSource
+	return e
+}
+
+// Make the ParseError.
+func NewParseError(pos SourcePosition, className string, msg string) *ParseError {
+	return &ParseError{
+		SourcePosition: pos,
+		ClassName:      className,
+		Cause:          errors.New(msg),
+	}
+}
+
+// Convert the error to the ParseError.
+// If err is already a ParseError, its copy with the class name pushed to the rule stack is returned.
+// err itself is not changed, so it can be a shared error.
+// Otherwise err is wrapped with the source position.
+func ToParseError(pos SourcePosition, className string, err error) *ParseError {
+	if pe, ok := err.(*ParseError); ok {
+		w := *pe
+		if w.ClassName == "" {
+			w.ClassName = className
+		}
+		if className != "" {
+			w.RuleStack = make([]string, len(pe.RuleStack), len(pe.RuleStack)+1)
+			copy(w.RuleStack, pe.RuleStack)
+			w.RuleStack = append(w.RuleStack, className)
+		}
+		return &w
+	}
+
+	pe := &ParseError{
+		SourcePosition: pos,
+		ClassName:      className,
+		Cause:          err,
+	}
+	if className != "" {
+		pe.RuleStack = []string{className}
+	}
+	return pe
+}
+
+// Make the ParseError of the transformer.
+// The source position is the span of the ASTs. If there is no AST, the position of the context is used.
+func NewTransformError(ctx ParserContext, asts AstSlice, cause error) *ParseError {
+	return &ParseError{
+		SourcePosition: astsSpan(ctx, asts),
+		ClassName:      ctx.ClassName,
+		Cause:          cause,
+	}
+}
+
+// Get the source span of the ASTs.
+func astsSpan(ctx ParserContext, asts AstSlice) SourcePosition {
+	if len(asts) == 0 {
+		return ctx.SourcePosition
+	}
+	start := asts[0].Position
+	end := start + asts[0].Length
+	for _, ast := range asts[1:] {
+		if ast.Position < start {
+			start = ast.Position
+		}
+		if end < ast.Position+ast.Length {
+			end = ast.Position + ast.Length
+		}
+	}
+	return SourcePosition{
+		Position: start,
+		Length:   end - start,
+		FileId:   asts[0].FileId,
+	}
+}
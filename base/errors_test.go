@@ -0,0 +1,124 @@
+package parser_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/string"
+)
+
+func TestParseErrorRuleStack(t *testing.T) {
+	parser := FlatGroup(Seq("a"), Trans(Seq("b"), TransformError("bad b")))
+
+	_, err := parser(*NewStringParserContext("ab"))
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("err is not a ParseError: %v", err)
+	}
+	if perr.Position != 1 || perr.Length != 1 {
+		t.Errorf("SourcePosition = %v, want {1 1}", perr.SourcePosition)
+	}
+	if perr.ClassName != ":base:Trans" {
+		t.Errorf("ClassName = %v, want :base:Trans", perr.ClassName)
+	}
+	if want := []string{":base:Trans", ":base:FlatGroup"}; !reflect.DeepEqual(perr.RuleStack, want) {
+		t.Errorf("RuleStack = %v, want %v", perr.RuleStack, want)
+	}
+	if err.Error() != "bad b" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	perr.Locate("ab", 4)
+	if perr.Line != 1 || perr.Col != 2 {
+		t.Errorf("Line, Col = %d, %d, want 1, 2", perr.Line, perr.Col)
+	}
+	if want := "bad b at Line 1, Col 2\n > | ab\n   |  ^^^^"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestParseErrorErrorParser(t *testing.T) {
+	parser := FlatGroup(Seq("x"), Error("unexpected"))
+
+	_, err := parser(*NewStringParserContext("xy"))
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("err is not a ParseError: %v", err)
+	}
+	if perr.Position != 1 || perr.ClassName != ":base:Error" {
+		t.Errorf("ParseError = %+v", perr)
+	}
+	if want := []string{":base:Error", ":base:FlatGroup"}; !reflect.DeepEqual(perr.RuleStack, want) {
+		t.Errorf("RuleStack = %v, want %v", perr.RuleStack, want)
+	}
+}
+
+func TestParseErrorCause(t *testing.T) {
+	parser := Trans(OneOrMoreTimes(Number()), ParseInt)
+
+	_, err := parser(*NewStringParserContext("99999999999999999999"))
+
+	if !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("errors.Is(err, strconv.ErrRange) is false: %v", err)
+	}
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("err is not a ParseError: %v", err)
+	}
+	if perr.Position != 0 || perr.Length != 20 {
+		t.Errorf("SourcePosition = %v, want {0 20}", perr.SourcePosition)
+	}
+}
+
+func TestParseErrorUserError(t *testing.T) {
+	cause := errors.New("user error")
+	parser := FlatGroup(Seq("a"), func(ctx ParserContext) (ParserContext, error) {
+		ctx.MatchStatus = MatchStatus_Error
+		return ctx, cause
+	})
+
+	_, err := parser(*NewStringParserContext("ab"))
+
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(err, cause) is false: %v", err)
+	}
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("err is not a ParseError: %v", err)
+	}
+	if perr.Position != 1 || perr.ClassName != ":base:FlatGroup" {
+		t.Errorf("ParseError = %+v", perr)
+	}
+}
+
+func TestToParseErrorCopy(t *testing.T) {
+	shared := NewParseError(SourcePosition{Position: 1}, "", "shared")
+	shared.RuleStack = make([]string, 1, 8)
+	shared.RuleStack[0] = "A"
+
+	a := ToParseError(SourcePosition{}, "B", shared)
+	b := ToParseError(SourcePosition{}, "C", shared)
+	c := ToParseError(SourcePosition{}, "C", b).Locate("xy", 4)
+
+	if shared.ClassName != "" || !reflect.DeepEqual(shared.RuleStack, []string{"A"}) || shared.Line != 0 {
+		t.Errorf("shared error is changed: %+v", shared)
+	}
+	if a.ClassName != "B" || !reflect.DeepEqual(a.RuleStack, []string{"A", "B"}) {
+		t.Errorf("ToParseError() = %+v", a)
+	}
+	if b.ClassName != "C" || !reflect.DeepEqual(b.RuleStack, []string{"A", "C"}) || b.Line != 0 {
+		t.Errorf("ToParseError() = %+v", b)
+	}
+	// The consecutive same class names are not collapsed.
+	if !reflect.DeepEqual(c.RuleStack, []string{"A", "C", "C"}) || c.Line != 1 || c.Col != 2 {
+		t.Errorf("ToParseError() = %+v", c)
+	}
+	if !errors.Is(c, shared.Cause) {
+		t.Errorf("errors.Is(c, shared.Cause) is false")
+	}
+}
@@ -0,0 +1,196 @@
+package parser
+
+// Link to the parent of the visited node.
+type astVisitLink struct {
+	parent *astVisitLink
+	index  int
+}
+
+// Node visited by the iterators.
+type AstVisit struct {
+	Ast Ast
+	// Depth of the node. The root is 0.
+	Depth int
+	link  *astVisitLink
+}
+
+// Path from the root to the node.
+// It is built on demand, so that the deep trees are visited in the linear time.
+func (v AstVisit) Path() AstPath {
+	w := make(AstPath, v.Depth)
+	for p, i := v.link, v.Depth-1; p != nil; p, i = p.parent, i-1 {
+		w[i] = p.index
+	}
+	return w
+}
+
+// Path of the parent node. It is nil for the root.
+func (v AstVisit) ParentPath() AstPath {
+	if v.Depth == 0 {
+		return nil
+	}
+	w := v.Path()
+	return w[:len(w)-1]
+}
+
+// Index of the node in the parent. It is -1 for the root.
+func (v AstVisit) Index() int {
+	if v.link == nil {
+		return -1
+	}
+	return v.link.index
+}
+
+// Visits of the children.
+func (v AstVisit) children() []AstVisit {
+	children := astChildren(v.Ast)
+	w := make([]AstVisit, len(children))
+	for i, child := range children {
+		w[i] = AstVisit{Ast: child, Depth: v.Depth + 1, link: &astVisitLink{parent: v.link, index: i}}
+	}
+	return w
+}
+
+// Non-recursive iterator of the AST tree.
+// The children of ListOfAst and the car and cdr of AstCons are visited.
+//
+//	it := ast.PreOrder()
+//	for it.Next() {
+//	    v := it.Visit()
+//	}
+type AstIterator struct {
+	next    func() (AstVisit, bool)
+	current AstVisit
+	skip    bool
+}
+
+// Move to the next node. It returns false if there are no more nodes.
+func (it *AstIterator) Next() bool {
+	v, ok := it.next()
+	if ok {
+		it.current = v
+		it.skip = false
+	}
+	return ok
+}
+
+// Current node.
+func (it *AstIterator) Visit() AstVisit {
+	return it.current
+}
+
+// Do not visit the children of the current node.
+// It has no effect on the post-order iterator, because the children are already visited.
+func (it *AstIterator) SkipChildren() {
+	it.skip = true
+}
+
+// Iterator of the pre-order (depth-first) traversal.
+func (s Ast) PreOrder() *AstIterator {
+	it := &AstIterator{}
+	stack := []AstVisit{{Ast: s}}
+	started := false
+
+	it.next = func() (AstVisit, bool) {
+		if started && !it.skip {
+			children := it.current.children()
+			for i := len(children) - 1; 0 <= i; i-- {
+				stack = append(stack, children[i])
+			}
+		}
+		started = true
+		if len(stack) == 0 {
+			return AstVisit{}, false
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v, true
+	}
+	return it
+}
+
+// Iterator of the post-order (depth-first) traversal.
+// The children are visited before the parent.
+func (s Ast) PostOrder() *AstIterator {
+	type frame struct {
+		visit    AstVisit
+		children []AstVisit
+		index    int
+	}
+
+	it := &AstIterator{}
+	root := AstVisit{Ast: s}
+	stack := []*frame{{visit: root, children: root.children()}}
+
+	it.next = func() (AstVisit, bool) {
+		for len(stack) != 0 {
+			top := stack[len(stack)-1]
+			if top.index < len(top.children) {
+				child := top.children[top.index]
+				top.index++
+				stack = append(stack, &frame{visit: child, children: child.children()})
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			return top.visit, true
+		}
+		return AstVisit{}, false
+	}
+	return it
+}
+
+// Iterator of the breadth-first (level-order) traversal.
+func (s Ast) BreadthFirst() *AstIterator {
+	it := &AstIterator{}
+	queue := []AstVisit{{Ast: s}}
+	started := false
+
+	it.next = func() (AstVisit, bool) {
+		if started && !it.skip {
+			queue = append(queue, it.current.children()...)
+		}
+		started = true
+		if len(queue) == 0 {
+			return AstVisit{}, false
+		}
+		v := queue[0]
+		queue[0] = AstVisit{}
+		queue = queue[1:]
+		return v, true
+	}
+	return it
+}
+
+// Mode of the next step of Walk.
+type WalkMode int
+
+const (
+	// Visit the children.
+	WalkMode_Continue WalkMode = iota
+	// Do not visit the children of the node.
+	WalkMode_SkipChildren
+	// Stop the walk.
+	WalkMode_Stop
+)
+
+// Callback handler for Walk.
+type FnWalk func(v AstVisit) (WalkMode, error)
+
+// Walk the tree in the pre-order without recursion.
+// It stops at the first error returned by fn.
+func (s Ast) Walk(fn FnWalk) error {
+	it := s.PreOrder()
+	for it.Next() {
+		mode, err := fn(it.Visit())
+		if err != nil {
+			return err
+		}
+		switch mode {
+		case WalkMode_SkipChildren:
+			it.SkipChildren()
+		case WalkMode_Stop:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build go1.23
+// +build go1.23
+
+package parser
+
+import (
+	"iter"
+)
+
+// Make the sequence from the iterator.
+func astSeq(it *AstIterator) iter.Seq[AstVisit] {
+	return func(yield func(AstVisit) bool) {
+		for it.Next() {
+			if !yield(it.Visit()) {
+				return
+			}
+		}
+	}
+}
+
+// Sequence of the pre-order (depth-first) traversal.
+func (s Ast) PreOrderSeq() iter.Seq[AstVisit] {
+	return func(yield func(AstVisit) bool) {
+		astSeq(s.PreOrder())(yield)
+	}
+}
+
+// Sequence of the post-order (depth-first) traversal.
+func (s Ast) PostOrderSeq() iter.Seq[AstVisit] {
+	return func(yield func(AstVisit) bool) {
+		astSeq(s.PostOrder())(yield)
+	}
+}
+
+// Sequence of the breadth-first (level-order) traversal.
+func (s Ast) BreadthFirstSeq() iter.Seq[AstVisit] {
+	return func(yield func(AstVisit) bool) {
+		astSeq(s.BreadthFirst())(yield)
+	}
+}
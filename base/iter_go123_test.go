@@ -0,0 +1,43 @@
+//go:build go1.23
+// +build go1.23
+
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAstSeq(t *testing.T) {
+	tree := iterTestTree()
+
+	w := make([]string, 0)
+	for v := range tree.PreOrderSeq() {
+		if v.Ast.ClassName == "B" {
+			break
+		}
+		w = append(w, v.Ast.ClassName)
+	}
+	if got, want := strings.Join(w, " "), "Root A A1 A2"; got != want {
+		t.Errorf("PreOrderSeq() = %v, want %v", got, want)
+	}
+
+	seq := tree.PostOrderSeq()
+	for i := 0; i < 2; i++ {
+		w = w[:0]
+		for v := range seq {
+			w = append(w, v.Ast.ClassName)
+		}
+		if got, want := strings.Join(w, " "), "A1 A2 A B1 B2 B C Root"; got != want {
+			t.Errorf("PostOrderSeq() = %v, want %v", got, want)
+		}
+	}
+
+	w = w[:0]
+	for v := range tree.BreadthFirstSeq() {
+		w = append(w, v.Path().String())
+	}
+	if got, want := strings.Join(w, " "), "/ /0 /1 /2 /0/0 /0/1 /1/0 /1/1"; got != want {
+		t.Errorf("BreadthFirstSeq() = %v, want %v", got, want)
+	}
+}
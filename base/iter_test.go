@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func iterTestTree() Ast {
+	l, n := diffTestList, diffTestInt
+	return l("Root",
+		l("A", n("A1", 1), n("A2", 2)),
+		Ast{ClassName: "B", Type: AstType_AstCons, Value: AstCons{Car: n("B1", 3), Cdr: n("B2", 4)}},
+		n("C", 5),
+	)
+}
+
+func iterTestFormat(it *AstIterator) string {
+	w := make([]string, 0)
+	for it.Next() {
+		v := it.Visit()
+		w = append(w, v.Ast.ClassName+v.Path().String())
+	}
+	return strings.Join(w, " ")
+}
+
+func TestAstIterator(t *testing.T) {
+	tree := iterTestTree()
+	tests := []struct {
+		name string
+		it   *AstIterator
+		want string
+	}{
+		{"pre-order", tree.PreOrder(), "Root/ A/0 A1/0/0 A2/0/1 B/1 B1/1/0 B2/1/1 C/2"},
+		{"post-order", tree.PostOrder(), "A1/0/0 A2/0/1 A/0 B1/1/0 B2/1/1 B/1 C/2 Root/"},
+		{"breadth-first", tree.BreadthFirst(), "Root/ A/0 B/1 C/2 A1/0/0 A2/0/1 B1/1/0 B2/1/1"},
+		{"scalar", diffTestInt("X", 1).PreOrder(), "X/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := iterTestFormat(tt.it); got != tt.want {
+				t.Errorf("iterator = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAstIteratorSkipChildren(t *testing.T) {
+	tree := iterTestTree()
+	for _, it := range []*AstIterator{tree.PreOrder(), tree.BreadthFirst()} {
+		w := make([]string, 0)
+		for it.Next() {
+			v := it.Visit()
+			w = append(w, v.Ast.ClassName)
+			if v.Ast.ClassName == "A" {
+				it.SkipChildren()
+			}
+			if v.Depth != len(v.Path()) || v.Depth != 0 && v.ParentPath().String() != v.Path()[:v.Depth-1].String() {
+				t.Errorf("Visit() = %+v", v)
+			}
+		}
+		if got := strings.Join(w, " "); strings.Contains(got, "A1") || !strings.Contains(got, "B2") {
+			t.Errorf("SkipChildren() = %v", got)
+		}
+	}
+}
+
+func TestAstWalk(t *testing.T) {
+	tree := iterTestTree()
+
+	w := make([]string, 0)
+	err := tree.Walk(func(v AstVisit) (WalkMode, error) {
+		w = append(w, v.Ast.ClassName)
+		switch v.Ast.ClassName {
+		case "B":
+			return WalkMode_SkipChildren, nil
+		case "C":
+			return WalkMode_Stop, nil
+		}
+		return WalkMode_Continue, nil
+	})
+	if got, want := strings.Join(w, " "), "Root A A1 A2 B C"; err != nil || got != want {
+		t.Errorf("Walk() = %v, %v, want %v", got, err, want)
+	}
+
+	errFound := errors.New("Found")
+	err = tree.Walk(func(v AstVisit) (WalkMode, error) {
+		if v.Ast.ClassName == "A2" {
+			return WalkMode_Continue, errFound
+		}
+		return WalkMode_Continue, nil
+	})
+	if err != errFound {
+		t.Errorf("Walk() error = %v", err)
+	}
+}
+
+func TestAstIteratorDeepTree(t *testing.T) {
+	const depth = 100000
+	tree := diffTestInt("Leaf", 1)
+	for i := 0; i < depth; i++ {
+		tree = diffTestList("Node", tree)
+	}
+
+	for _, it := range []*AstIterator{tree.PreOrder(), tree.PostOrder(), tree.BreadthFirst()} {
+		count, maxDepth := 0, 0
+		for it.Next() {
+			count++
+			if d := it.Visit().Depth; maxDepth < d {
+				maxDepth = d
+			}
+		}
+		if count != depth+1 || maxDepth != depth {
+			t.Errorf("iterator count = %v, depth = %v", count, maxDepth)
+		}
+	}
+}
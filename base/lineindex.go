@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Span of a line. (excluding the line break)
+type lineSpan struct {
+	start int
+	end   int
+}
+
+// Precomputed index of the line start positions.
+// It is built once per source, and converts between byte offsets and line/column positions by binary search.
+//
+// The line breaks are CR LF, CR, LF, VT, FF and NEL, the same as LineBreak() of the string package.
+type LineIndex struct {
+	src   string
+	lines []lineSpan
+}
+
+// Build the line index of the source.
+func NewLineIndex(src string) *LineIndex {
+	lines := make([]lineSpan, 0, 64)
+	start := 0
+	for i := 0; i < len(src); {
+		n := lineBreakLength(src, i)
+		if n == 0 {
+			i++
+			continue
+		}
+		lines = append(lines, lineSpan{start, i})
+		i += n
+		start = i
+	}
+	lines = append(lines, lineSpan{start, len(src)})
+
+	return &LineIndex{
+		src:   src,
+		lines: lines,
+	}
+}
+
+// Number of lines.
+func (x *LineIndex) LineCount() int {
+	return len(x.lines)
+}
+
+// Get the index (0-based) of the line containing the offset.
+func (x *LineIndex) lineIndexOf(offset int) int {
+	i := sort.Search(len(x.lines), func(i int) bool {
+		return offset < x.lines[i].start
+	})
+	if i == 0 {
+		return 0
+	}
+	return i - 1
+}
+
+// Clamp the line number (1-based) and convert to the index.
+func (x *LineIndex) clampLine(line int) int {
+	if line < 1 {
+		return 0
+	}
+	if len(x.lines) < line {
+		return len(x.lines) - 1
+	}
+	return line - 1
+}
+
+// Get the start offset of the line. (1-based)
+func (x *LineIndex) LineStart(line int) int {
+	return x.lines[x.clampLine(line)].start
+}
+
+// Get the end offset of the line, excluding the line break. (1-based)
+func (x *LineIndex) LineEnd(line int) int {
+	return x.lines[x.clampLine(line)].end
+}
+
+// Get the text of the line, excluding the line break. (1-based)
+func (x *LineIndex) LineText(line int) string {
+	span := x.lines[x.clampLine(line)]
+	return x.src[span.start:span.end]
+}
+
+// Get the line number (1-based) of the offset.
+func (x *LineIndex) Line(offset int) int {
+	return x.lineIndexOf(offset) + 1
+}
+
+// Convert the byte offset to the line and column. (both are 1-based)
+func (x *LineIndex) LineAndCol(offset int, mode ColumnMode, tabSize int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	} else if len(x.src) < offset {
+		offset = len(x.src)
+	}
+	i := x.lineIndexOf(offset)
+	return i + 1, measureColumns(x.src[x.lines[i].start:offset], mode, tabSize) + 1
+}
+
+// Convert the line and column (both are 1-based) to the byte offset.
+// If the column is beyond the end of the line, the end of the line is returned.
+func (x *LineIndex) Offset(line, col int, mode ColumnMode, tabSize int) int {
+	span := x.lines[x.clampLine(line)]
+	if mode == ColumnMode_Byte {
+		offset := span.start + col - 1
+		if offset < span.start {
+			return span.start
+		}
+		if span.end < offset {
+			return span.end
+		}
+		return offset
+	}
+
+	i := span.start
+	for c := 1; i < span.end && c < col; {
+		var n, w int
+		switch mode {
+		case ColumnMode_Grapheme:
+			n, w = graphemeLength(x.src[i:span.end]), 1
+		case ColumnMode_DisplayWidth:
+			n = graphemeLength(x.src[i:span.end])
+			w = graphemeWidth(x.src[i:i+n], tabSize)
+		default:
+			var r rune
+			r, n = utf8.DecodeRuneInString(x.src[i:span.end])
+			w = 1
+			if mode == ColumnMode_Utf16 && 0x10000 <= r {
+				w = 2
+			}
+		}
+		if col < c+w {
+			// The column is in the middle of the wide character.
+			break
+		}
+		c += w
+		i += n
+	}
+	return i
+}
+
+// Get the line and column position of the source position.
+// It is the same as GetLineAndColPositionWithMode().
+func (x *LineIndex) LineAndColPosition(pos SourcePosition, tabSize int, mode ColumnMode) LineAndColPosition {
+	position := pos.Position
+	if position < 0 {
+		position = 0
+	} else if len(x.src) < position {
+		position = len(x.src)
+	}
+	endPosition := position + pos.Length
+	if len(x.src) < endPosition {
+		endPosition = len(x.src)
+	}
+
+	line, col := x.LineAndCol(position, mode, tabSize)
+	endLine, endCol := x.LineAndCol(endPosition, mode, tabSize)
+	span := x.lines[line-1]
+
+	prevLine, lineBreak := "", ""
+	if 1 < line {
+		prev := x.lines[line-2]
+		prevLine = x.src[prev.start:prev.end]
+		lineBreak = x.src[prev.end:span.start]
+	}
+	errSource := formatErrSource(prevLine, lineBreak, x.src[span.start:span.end], x.src[span.start:position], tabSize)
+
+	return LineAndColPosition{
+		LineIndex:   span.start,
+		Line:        line,
+		Col:         col,
+		EndLine:     endLine,
+		EndCol:      endCol,
+		Position:    position,
+		EndPosition: endPosition,
+		ErrSource:   errSource,
+	}
+}
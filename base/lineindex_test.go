@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestLineIndexLineAndCol(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		offset int
+		mode   ColumnMode
+		line   int
+		col    int
+	}{
+		{name: "first", src: "abc\ndef", offset: 0, mode: ColumnMode_Byte, line: 1, col: 1},
+		{name: "lf", src: "abc\ndef", offset: 5, mode: ColumnMode_Byte, line: 2, col: 2},
+		{name: "crlf", src: "abc\r\ndef", offset: 6, mode: ColumnMode_Byte, line: 2, col: 2},
+		{name: "cr", src: "abc\rdef", offset: 5, mode: ColumnMode_Byte, line: 2, col: 2},
+		{name: "vt", src: "abc\vdef", offset: 5, mode: ColumnMode_Byte, line: 2, col: 2},
+		{name: "ff", src: "abc\fdef", offset: 5, mode: ColumnMode_Byte, line: 2, col: 2},
+		{name: "nel", src: "abc\u0085def", offset: 6, mode: ColumnMode_Byte, line: 2, col: 2},
+		{name: "blank lines", src: "a\n\n\nb", offset: 4, mode: ColumnMode_Byte, line: 4, col: 1},
+		{name: "end of source", src: "a\nb\n", offset: 4, mode: ColumnMode_Byte, line: 3, col: 1},
+		{name: "rune", src: "x\nあいう", offset: 8, mode: ColumnMode_Rune, line: 2, col: 3},
+		{name: "display width", src: "x\nあいう", offset: 8, mode: ColumnMode_DisplayWidth, line: 2, col: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := NewLineIndex(tt.src)
+			line, col := x.LineAndCol(tt.offset, tt.mode, 4)
+			if line != tt.line || col != tt.col {
+				t.Errorf("LineAndCol(%d) = %d:%d, want %d:%d", tt.offset, line, col, tt.line, tt.col)
+			}
+		})
+	}
+}
+
+func TestLineIndexOffset(t *testing.T) {
+	src := "abc\r\nあ𠮷éx\n\tz"
+	x := NewLineIndex(src)
+
+	if n := x.LineCount(); n != 3 {
+		t.Errorf("LineCount() = %d, want 3", n)
+	}
+	if s := x.LineText(2); s != "あ𠮷éx" {
+		t.Errorf("LineText(2) = %q", s)
+	}
+
+	modes := []ColumnMode{ColumnMode_Byte, ColumnMode_Rune, ColumnMode_Grapheme, ColumnMode_Utf16, ColumnMode_DisplayWidth}
+	starts := []int{5, 8, 12, 15}
+	for _, mode := range modes {
+		for _, offset := range starts {
+			line, col := x.LineAndCol(offset, mode, 4)
+			if got := x.Offset(line, col, mode, 4); got != offset {
+				t.Errorf("mode %d: Offset(LineAndCol(%d)) = %d", mode, offset, got)
+			}
+		}
+	}
+
+	if got := x.Offset(1, 100, ColumnMode_Rune, 4); got != 3 {
+		t.Errorf("Offset(1, 100) = %d, want 3", got)
+	}
+	if got := x.Offset(2, 2, ColumnMode_DisplayWidth, 4); got != 5 {
+		t.Errorf("Offset(2, 2) in the middle of the wide character = %d, want 5", got)
+	}
+	if got := x.Offset(3, 5, ColumnMode_DisplayWidth, 4); got != 18 {
+		t.Errorf("Offset(3, 5) after the tab = %d, want 18", got)
+	}
+}
+
+func TestLineIndexAgreesWithGetLineAndColPosition(t *testing.T) {
+	srcs := []string{
+		"foo\r\nbar\nあいう\tx\r\n\nbaz",
+		"a\vb\fc\u0085d\re\r\n",
+		"\u0085\u0085x\v\f",
+		"ab\rcd\r",
+	}
+
+	for _, src := range srcs {
+		x := NewLineIndex(src)
+		for offset := 0; offset <= len(src); offset++ {
+			pos := SourcePosition{Position: offset, Length: 3}
+			for _, mode := range []ColumnMode{ColumnMode_Byte, ColumnMode_Rune, ColumnMode_DisplayWidth} {
+				want := GetLineAndColPositionWithMode(src, pos, 4, mode)
+				got := x.LineAndColPosition(pos, 4, mode)
+				if got != want {
+					t.Errorf("%q offset %d, mode %d: got %+v, want %+v", src, offset, mode, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestGetLineAndColPositionLineBreaks(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		offset    int
+		line      int
+		col       int
+		errSource string
+	}{
+		{name: "vt", src: "abc\vdef", offset: 5, line: 2, col: 2, errSource: "   | abc\n > | def\n   |  ^^^^"},
+		{name: "ff", src: "abc\fdef", offset: 5, line: 2, col: 2, errSource: "   | abc\n > | def\n   |  ^^^^"},
+		{name: "nel", src: "abc\u0085def", offset: 6, line: 2, col: 2, errSource: "   | abc\n > | def\n   |  ^^^^"},
+		{name: "cr", src: "abc\rdef", offset: 5, line: 2, col: 2, errSource: "   | abc\r > | def\r   |  ^^^^"},
+		{name: "crlf", src: "abc\r\ndef", offset: 6, line: 2, col: 2, errSource: "   | abc\r\n > | def\n   |  ^^^^"},
+		{name: "in crlf", src: "abc\r\ndef", offset: 4, line: 1, col: 5, errSource: " > | abc\n   |    ^^^^"},
+		{name: "in nel", src: "abc\u0085def", offset: 4, line: 1, col: 5, errSource: " > | abc\n   |     ^^^^"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := SourcePosition{Position: tt.offset, Length: 1}
+			for _, got := range []LineAndColPosition{
+				GetLineAndColPosition(tt.src, pos, 4),
+				NewLineIndex(tt.src).LineAndColPosition(pos, 4, ColumnMode_Byte),
+			} {
+				if got.Line != tt.line || got.Col != tt.col || got.ErrSource != tt.errSource {
+					t.Errorf("got %d:%d %q, want %d:%d %q", got.Line, got.Col, got.ErrSource, tt.line, tt.col, tt.errSource)
+				}
+			}
+
+			perr := NewParseError(pos, "", "x").Locate(tt.src, 4)
+			if perr.Line != tt.line || perr.Col != tt.col {
+				t.Errorf("Locate() = %d:%d, want %d:%d", perr.Line, perr.Col, tt.line, tt.col)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"strconv"
 
 	clsz "github.com/shellyln/takenoco/base/classes"
 )
@@ -179,6 +180,84 @@ func Repeat(times Times, children ...ParserFn) ParserFn {
 
 // TODO: QtyShortest(qty, child ParserFn, subsequent ...ParserFn)
 
+// Run the prefix parser and read the count from the top of the resulting AST stack.
+// The AST of the count is removed from the stack.
+// The count AST should be AstType_Int or AstType_Uint.
+func ParseCountPrefix(prefix ParserFn, ctx ParserContext) (ParserContext, int, error) {
+	out, err := prefix(ctx)
+	if err != nil || out.MatchStatus != MatchStatus_Matched {
+		return out, 0, err
+	}
+	if len(out.AstStack) <= len(ctx.AstStack) {
+		out.MatchStatus = MatchStatus_Error
+		return out, 0, errors.New("Count prefix did not produce any AST")
+	}
+
+	top := out.AstStack[len(out.AstStack)-1]
+	var n int
+	switch top.Type {
+	case AstType_Int:
+		v, ok := top.Value.(int64)
+		if !ok {
+			out.MatchStatus = MatchStatus_Error
+			return out, 0, errors.New("Count prefix value does not match the AST type: " + top.Type.String())
+		}
+		if v < 0 || int64(int(v)) != v {
+			out.MatchStatus = MatchStatus_Error
+			return out, 0, errors.New("Count prefix is out of range: " + strconv.FormatInt(v, 10))
+		}
+		n = int(v)
+	case AstType_Uint:
+		v, ok := top.Value.(uint64)
+		if !ok {
+			out.MatchStatus = MatchStatus_Error
+			return out, 0, errors.New("Count prefix value does not match the AST type: " + top.Type.String())
+		}
+		if v > uint64(int(^uint(0)>>1)) {
+			out.MatchStatus = MatchStatus_Error
+			return out, 0, errors.New("Count prefix is out of range: " + strconv.FormatUint(v, 10))
+		}
+		n = int(v)
+	default:
+		out.MatchStatus = MatchStatus_Error
+		return out, 0, errors.New("Count prefix is not a number: " + top.Type.String())
+	}
+
+	out.AstStack = out.AstStack[:len(out.AstStack)-1]
+	return out, n, nil
+}
+
+// Repetitive assertion. {n,n}
+// n is read from the AST produced by the prefix parser.
+func RepeatByCount(prefix ParserFn, children ...ParserFn) ParserFn {
+	const ClassName = clsz.RepeatByCount
+	body := FlatGroup(children...)
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		out, n, err := ParseCountPrefix(prefix, ctx)
+		if err != nil {
+			return out, err
+		}
+		if out.MatchStatus != MatchStatus_Matched {
+			ctx.MatchStatus = MatchStatus_Unmatched
+			return ctx, nil
+		}
+
+		for i := 0; i < n; i++ {
+			out, err = body(out)
+			if err != nil {
+				return out, err
+			}
+			if out.MatchStatus != MatchStatus_Matched {
+				ctx.MatchStatus = MatchStatus_Unmatched
+				return ctx, nil
+			}
+		}
+
+		out.Length = out.Position - ctx.Position
+		return out, nil
+	})
+}
+
 // Repetitive assertion. {1,1}
 func Once(children ...ParserFn) ParserFn {
 	return Repeat(qtyOnce, children...)
@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"errors"
+
+	clsz "github.com/shellyln/takenoco/base/classes"
+)
+
+// Type of the operator in the operator table.
+type OperatorType int
+
+const (
+	// Prefix operator. (e.g. `-x`)
+	OperatorType_Prefix OperatorType = iota
+	// Infix operator. (e.g. `x + y`)
+	OperatorType_Infix
+	// Postfix operator. (e.g. `x!`)
+	OperatorType_Postfix
+)
+
+// Associativity of the infix operator.
+type Associativity int
+
+const (
+	// `a op b op c` is `(a op b) op c`
+	Associativity_Left Associativity = iota
+	// `a op b op c` is `a op (b op c)`
+	Associativity_Right
+	// `a op b op c` is an error.
+	Associativity_None
+)
+
+// Operator definition of the operator table.
+type Operator struct {
+	// Type of the operator.
+	Type OperatorType
+	// Parser that matches the operator token.
+	Parser ParserFn
+	// Binding power. The operator with the larger value binds tighter.
+	Power int
+	// Associativity. It is used only by the infix operators.
+	Assoc Associativity
+	// Node builder of this operator. If it is nil, the default builder is used.
+	Build TransformerFn
+}
+
+// Operator table for Pratt().
+type OperatorTable []Operator
+
+const (
+	msgOperandRequired     = "An operand is required after the operator"
+	msgNonAssociative      = "The non-associative operator cannot be chained"
+	msgOperatorNodeBuilder = "The operator node builder should return exactly one AST"
+)
+
+// Pratt parser state
+type prattParser struct {
+	operand ParserFn
+	prefix  []Operator
+	infix   []Operator
+	postfix []Operator
+	build   TransformerFn
+}
+
+// Replace the ASTs above the bottom of the stack with the node made by the builder.
+func (p *prattParser) reduce(op *Operator, start, bottom int, ctx ParserContext) (ParserContext, error) {
+	build := op.Build
+	if build == nil {
+		build = p.build
+	}
+
+	asts := make(AstSlice, 0, len(ctx.AstStack)-bottom)
+	asts = append(asts, ctx.AstStack[bottom:]...)
+
+	asts, err := build(ctx, asts)
+	if err != nil {
+		ctx.MatchStatus = MatchStatus_Error
+		return ctx, err
+	}
+	if len(asts) != 1 {
+		ctx.MatchStatus = MatchStatus_Error
+		return ctx, errors.New(msgOperatorNodeBuilder)
+	}
+
+	node := asts[0]
+	if node.Position == 0 && node.Length == 0 {
+		node.SourcePosition = SourcePosition{
+			Position: start,
+			Length:   ctx.Position - start,
+			FileId:   ctx.FileId,
+		}
+	}
+	ctx.AstStack = append(ctx.AstStack[:bottom], node)
+	return ctx, nil
+}
+
+// Parse the operand and the operators that bind tighter than minPower.
+func (p *prattParser) parse(ctx ParserContext, minPower int) (ParserContext, error) {
+	start := ctx.Position
+	bottom := len(ctx.AstStack)
+
+	var out ParserContext
+	var err error
+	matched := false
+
+	for i := range p.prefix {
+		op := &p.prefix[i]
+		out, err = op.Parser(ctx)
+		if err != nil {
+			return out, err
+		}
+		if out.MatchStatus != MatchStatus_Matched {
+			continue
+		}
+
+		opEnd := out
+		out, err = p.parse(out, op.Power*2+1)
+		if err != nil {
+			return out, err
+		}
+		if out.MatchStatus != MatchStatus_Matched {
+			opEnd.MatchStatus = MatchStatus_Error
+			return opEnd, errors.New(msgOperandRequired)
+		}
+
+		out, err = p.reduce(op, start, bottom, out)
+		if err != nil {
+			return out, err
+		}
+		matched = true
+		break
+	}
+
+	if !matched {
+		out, err = p.operand(ctx)
+		if err != nil {
+			return out, err
+		}
+		if out.MatchStatus != MatchStatus_Matched {
+			ctx.MatchStatus = MatchStatus_Unmatched
+			return ctx, nil
+		}
+		if len(out.AstStack)-bottom != 1 {
+			asts, _ := GroupingTransform(out, out.AstStack[bottom:])
+			out.AstStack = append(out.AstStack[:bottom], asts...)
+		}
+	}
+
+	lastNonAssocPower := -1
+
+OPERATORS:
+	for {
+		for i := range p.postfix {
+			op := &p.postfix[i]
+			if op.Power*2 < minPower {
+				continue
+			}
+
+			next, err := op.Parser(out)
+			if err != nil {
+				return next, err
+			}
+			if next.MatchStatus != MatchStatus_Matched {
+				continue
+			}
+
+			out, err = p.reduce(op, start, bottom, next)
+			if err != nil {
+				return out, err
+			}
+			lastNonAssocPower = -1
+			continue OPERATORS
+		}
+
+		for i := range p.infix {
+			op := &p.infix[i]
+			leftPower := op.Power * 2
+			rightPower := leftPower + 1
+			if op.Assoc == Associativity_Right {
+				rightPower = leftPower
+			}
+			if leftPower < minPower {
+				continue
+			}
+
+			next, err := op.Parser(out)
+			if err != nil {
+				return next, err
+			}
+			if next.MatchStatus != MatchStatus_Matched {
+				continue
+			}
+
+			if op.Assoc == Associativity_None && lastNonAssocPower == op.Power {
+				out.Length = next.Position - out.Position
+				out.MatchStatus = MatchStatus_Error
+				return out, errors.New(msgNonAssociative)
+			}
+
+			opEnd := next
+			next, err = p.parse(next, rightPower)
+			if err != nil {
+				return next, err
+			}
+			if next.MatchStatus != MatchStatus_Matched {
+				opEnd.MatchStatus = MatchStatus_Error
+				return opEnd, errors.New(msgOperandRequired)
+			}
+
+			out, err = p.reduce(op, start, bottom, next)
+			if err != nil {
+				return out, err
+			}
+			if op.Assoc == Associativity_None {
+				lastNonAssocPower = op.Power
+			} else {
+				lastNonAssocPower = -1
+			}
+			continue OPERATORS
+		}
+
+		break
+	}
+
+	out.Length = out.Position - start
+	out.MatchStatus = MatchStatus_Matched
+	return out, nil
+}
+
+// Operator precedence assertion by the Pratt parsing (precedence climbing).
+// The source is parsed in a single pass, and one AST is returned.
+//
+// The operand parser should return one AST. If it returns multiple ASTs, they are grouped by AstSlice.
+// The node builder receives the ASTs of the operator and operands in source order,
+// i.e. `[op, x]` for prefix, `[x, op, y]` for infix, and `[x, op]` for postfix operators.
+// It should return exactly one AST.
+func Pratt(operand ParserFn, table OperatorTable, build TransformerFn) ParserFn {
+	const ClassName = clsz.Pratt
+
+	p := &prattParser{
+		operand: operand,
+		build:   build,
+	}
+	for _, op := range table {
+		switch op.Type {
+		case OperatorType_Prefix:
+			p.prefix = append(p.prefix, op)
+		case OperatorType_Infix:
+			p.infix = append(p.infix, op)
+		case OperatorType_Postfix:
+			p.postfix = append(p.postfix, op)
+		}
+	}
+
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		return p.parse(ctx, 0)
+	})
+}
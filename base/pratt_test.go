@@ -0,0 +1,105 @@
+package parser_test
+
+import (
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/string"
+)
+
+// Node builder that renders the tree as a parenthesized string.
+func prattTestBuilder(_ ParserContext, asts AstSlice) (AstSlice, error) {
+	s := "("
+	for i, w := range asts {
+		if i != 0 {
+			s += " "
+		}
+		s += w.Value.(string)
+	}
+	s += ")"
+	return AstSlice{{
+		Type:  AstType_String,
+		Value: s,
+	}}, nil
+}
+
+func prattTestParser() ParserFn {
+	var expr ParserFn
+	operand := First(
+		Trans(OneOrMoreTimes(Alpha()), Concat),
+		Between(Seq("("), Seq(")"), Indirect(func() ParserFn { return expr })),
+	)
+	expr = Pratt(operand, OperatorTable{{
+		Type:   OperatorType_Prefix,
+		Parser: Seq("-"),
+		Power:  3,
+	}, {
+		Type:   OperatorType_Postfix,
+		Parser: Seq("!"),
+		Power:  5,
+	}, {
+		Type:   OperatorType_Infix,
+		Parser: Seq("^"),
+		Power:  4,
+		Assoc:  Associativity_Right,
+	}, {
+		Type:   OperatorType_Infix,
+		Parser: CharClass("*", "/"),
+		Power:  2,
+		Assoc:  Associativity_Left,
+	}, {
+		Type:   OperatorType_Infix,
+		Parser: CharClass("+", "-"),
+		Power:  1,
+		Assoc:  Associativity_Left,
+	}, {
+		Type:   OperatorType_Infix,
+		Parser: Seq("<"),
+		Power:  0,
+		Assoc:  Associativity_None,
+	}}, prattTestBuilder)
+	return FlatGroup(Start(), expr, End())
+}
+
+func TestPratt(t *testing.T) {
+	parser := prattTestParser()
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "operand", text: "a", want: "a"},
+		{name: "left", text: "a-b-c", want: "((a - b) - c)"},
+		{name: "right", text: "a^b^c", want: "(a ^ (b ^ c))"},
+		{name: "precedence", text: "a+b*c-d", want: "((a + (b * c)) - d)"},
+		{name: "prefix", text: "-a*b", want: "((- a) * b)"},
+		{name: "prefix 2", text: "--a^b", want: "(- (- (a ^ b)))"},
+		{name: "postfix", text: "-a!", want: "(- (a !))"},
+		{name: "grouped", text: "(a+b)*c", want: "((a + b) * c)"},
+		{name: "non-associative", text: "a<b", want: "(a < b)"},
+		{name: "non-associative chained", text: "a<b<c", wantErr: true},
+		{name: "dangling operator", text: "a+", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser(*NewStringParserContext(tt.text))
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Pratt() err is %v, want %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.MatchStatus != MatchStatus_Matched {
+				t.Errorf("Pratt() got.MatchStatus is %v", got.MatchStatus)
+				return
+			}
+			if len(got.AstStack) != 1 || got.AstStack[0].Value != tt.want {
+				t.Errorf("Pratt() got.AstStack = %v, want %v", got.AstStack, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package parser
 
-import "errors"
+import (
+	"strconv"
+	"strings"
+)
 
 // Production rules precedence
 type Precedence struct {
@@ -12,66 +15,255 @@ type Precedence struct {
 	Rtol bool
 }
 
-// Constructor for production rule
-func newContext(slice SliceLike, t interface{}) *ParserContext {
-	return &ParserContext{
-		Slice:    slice,
-		AstStack: make(AstSlice, 0, 1024),
-		SourcePosition: SourcePosition{
-			Position: 0,
-			Length:   0,
-		},
-		Tag: t,
+// AST slice with a gap at the editing position.
+// Replacing the ASTs near the gap does not move the rest of the slice.
+// Implements the interface SliceLike.
+type astGapBuffer struct {
+	buf      AstSlice
+	gapStart int
+	gapEnd   int
+}
+
+// Constructor
+func newAstGapBuffer(asts AstSlice) *astGapBuffer {
+	buf := make(AstSlice, len(asts), len(asts)+16)
+	copy(buf, asts)
+	buf = buf[:cap(buf)]
+	return &astGapBuffer{
+		buf:      buf,
+		gapStart: len(asts),
+		gapEnd:   len(buf),
+	}
+}
+
+// Convert the logical index to the index of the buffer.
+func (s *astGapBuffer) index(i int) int {
+	if i < s.gapStart {
+		return i
+	}
+	return i + s.gapEnd - s.gapStart
+}
+
+// Move the gap to the logical position.
+func (s *astGapBuffer) moveGap(pos int) {
+	for s.gapStart > pos {
+		s.gapStart--
+		s.gapEnd--
+		s.buf[s.gapEnd] = s.buf[s.gapStart]
+	}
+	for s.gapStart < pos {
+		s.buf[s.gapStart] = s.buf[s.gapEnd]
+		s.gapStart++
+		s.gapEnd++
+	}
+}
+
+// Replace the ASTs in the logical range [start, end) with repl.
+func (s *astGapBuffer) replace(start, end int, repl AstSlice) {
+	s.moveGap(end)
+	s.gapStart = start
+
+	if s.gapEnd-s.gapStart < len(repl) {
+		tail := len(s.buf) - s.gapEnd
+		buf := make(AstSlice, (len(s.buf)+len(repl))*2)
+		copy(buf, s.buf[:s.gapStart])
+		copy(buf[len(buf)-tail:], s.buf[s.gapEnd:])
+		s.buf = buf
+		s.gapEnd = len(buf) - tail
+	}
+
+	copy(s.buf[s.gapStart:], repl)
+	s.gapStart += len(repl)
+}
+
+// Imprements SliceLike.Len().
+func (s *astGapBuffer) Len() int {
+	return len(s.buf) - (s.gapEnd - s.gapStart)
+}
+
+// Imprements SliceLike.Get().
+func (s *astGapBuffer) Get(i int) interface{} {
+	return s.buf[s.index(i)]
+}
+
+// Imprements SliceLike.Set().
+func (s *astGapBuffer) Set(i int, v interface{}) {
+	s.buf[s.index(i)] = v.(Ast)
+}
+
+// Imprements SliceLike.Reslice().
+// It returns a copy, because the buffer is modified in place.
+func (s *astGapBuffer) Reslice(start, end int) SliceLike {
+	return s.Copy(start, end)
+}
+
+// Imprements SliceLike.Copy().
+func (s *astGapBuffer) Copy(start, end int) SliceLike {
+	w := make(AstSlice, 0, end-start)
+	for i := start; i < end; i++ {
+		w = append(w, s.buf[s.index(i)])
 	}
+	return w
+}
+
+// Imprements SliceLike.Make().
+func (s *astGapBuffer) Make(len, cap int) SliceLike {
+	return make(AstSlice, len, cap)
+}
+
+// Imprements SliceLike.ItemEquals().
+func (s *astGapBuffer) ItemEquals(a, b interface{}) bool {
+	return tempEmptyAstSlice.ItemEquals(a, b)
+}
+
+// State of ProductionRule().
+type productionState struct {
+	asts  *astGapBuffer
+	stack AstSlice
+	tag   interface{}
+	// For each precedence, the rules are known to be unmatched at the positions greater than or equal to the limit.
+	limits []int
+}
+
+// Update the limits after the logical range [start, end) is replaced with n ASTs.
+// The rules read the ASTs forward from the position,
+// so the results at the positions after the replaced range are not changed.
+func (p *productionState) invalidate(start, end, n int) {
+	for i, limit := range p.limits {
+		if end <= limit {
+			p.limits[i] = limit + n - (end - start)
+		} else {
+			p.limits[i] = start + n
+		}
+	}
+}
+
+// Try the rules at the position.
+// If one of them is matched, the matched part is replaced with the result.
+func (p *productionState) reduceAt(rules []ParserFn, pos int) (bool, error) {
+	for _, rule := range rules {
+		out, err := rule(ParserContext{
+			Slice:    p.asts,
+			AstStack: p.stack[:0],
+			SourcePosition: SourcePosition{
+				Position: pos,
+			},
+			Tag: p.tag,
+		})
+		if err != nil {
+			return false, err
+		}
+		if out.MatchStatus != MatchStatus_Matched {
+			continue
+		}
+
+		p.asts.replace(pos, out.Position, out.AstStack)
+		p.invalidate(pos, out.Position, len(out.AstStack))
+		p.stack = out.AstStack[:0]
+		return true, nil
+	}
+	return false, nil
+}
+
+// Apply the first matched rule.
+// The precedences are tried in order, and the positions are scanned in the direction of the precedence.
+// The positions that are known to be unmatched are skipped.
+func (p *productionState) reduce(precedences []Precedence) (bool, error) {
+	for k, precedence := range precedences {
+		if precedence.Rtol {
+			for i := p.limits[k] - 1; 0 <= i; i-- {
+				p.limits[k] = i + 1
+				ok, err := p.reduceAt(precedence.Rules, i)
+				if ok || err != nil {
+					return ok, err
+				}
+			}
+		} else {
+			for i := 0; i < p.limits[k]; i++ {
+				ok, err := p.reduceAt(precedence.Rules, i)
+				if ok || err != nil {
+					return ok, err
+				}
+			}
+		}
+		p.limits[k] = 0
+	}
+	return false, nil
+}
+
+// Make the error of the unreduced ASTs.
+// The source position of the error is the first unreduced AST.
+func productionRuleError(ctx ParserContext, asts SliceLike) error {
+	const maxItems = 16
+
+	var sb strings.Builder
+	sb.WriteString("Production rules are not matched. Unreduced ASTs: ")
+
+	length := asts.Len()
+	for i := 0; i < length && i < maxItems; i++ {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		ast := asts.Get(i).(Ast)
+		if ast.ClassName != "" {
+			sb.WriteString(ast.ClassName)
+		} else {
+			sb.WriteString("(" + ast.Type.String() + ")")
+		}
+		sb.WriteString(" at ")
+		sb.WriteString(strconv.Itoa(ast.Position))
+		sb.WriteString("+")
+		sb.WriteString(strconv.Itoa(ast.Length))
+	}
+	if maxItems < length {
+		sb.WriteString(", ... (" + strconv.Itoa(length) + " ASTs)")
+	}
+	pos := ctx.SourcePosition
+	if 0 < length {
+		pos = asts.Get(0).(Ast).SourcePosition
+	}
+	return NewParseError(pos, ctx.ClassName, sb.String())
 }
 
 // Transform the slices of AST according to the production rules.
+//
+// The first matched rule is applied, trying the precedences in order,
+// and it is repeated until the check parser is matched.
+// After a reduction, only the positions affected by it are tried again,
+// so the rules should not look behind the position.
+// If no rule is matched, an error is returned.
 func ProductionRule(precedences []Precedence, check ParserFn) TransformerFn {
 	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		p := &productionState{
+			asts:   newAstGapBuffer(asts),
+			stack:  make(AstSlice, 0, 16),
+			tag:    ctx.Tag,
+			limits: make([]int, len(precedences)),
+		}
+		for i := range p.limits {
+			p.limits[i] = len(asts) + 1
+		}
+
 		for {
-			matched := false
-		PRECEDENCE:
-			for _, precedence := range precedences {
-				astCtx := *newContext(asts, ctx.Tag)
-
-				for i := 0; i <= asts.Len(); i++ {
-					for _, rule := range precedence.Rules {
-						curCtx := astCtx
-						if precedence.Rtol {
-							curCtx.Position = asts.Len() - i
-						} else {
-							curCtx.Position = i
-						}
-
-						out, err := rule(curCtx)
-						if err != nil {
-							return nil, err
-						}
-						if out.MatchStatus >= MatchStatus_Unmatched {
-							continue
-						}
-
-						z := make(AstSlice, 0, asts.Len())
-						z = append(z, asts[0:curCtx.Position]...)
-						z = append(z, out.AstStack...)
-						z = append(z, asts[out.Position:]...)
-						asts = z
-
-						matched = true
-						break PRECEDENCE
-					}
-				}
+			reduced, err := p.reduce(precedences)
+			if err != nil {
+				return nil, err
 			}
 
-			if out, err := check(*newContext(asts, ctx.Tag)); err == nil && out.MatchStatus == MatchStatus_Matched {
+			out, err := check(ParserContext{
+				Slice:    p.asts,
+				AstStack: p.stack[:0],
+				Tag:      ctx.Tag,
+			})
+			if err == nil && out.MatchStatus == MatchStatus_Matched {
 				break
 			}
 
-			if !matched {
-				// TODO:
-				return nil, errors.New("Production rules are not matched.")
+			if !reduced {
+				return nil, productionRuleError(ctx, p.asts)
 			}
 		}
-		return asts, nil
+
+		return p.asts.Copy(0, p.asts.Len()).(AstSlice), nil
 	}
 }
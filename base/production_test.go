@@ -0,0 +1,193 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	objparser "github.com/shellyln/takenoco/object"
+)
+
+func isProductionTestOp(op string) ParserFn {
+	return Trans(
+		objparser.ObjClassFn(func(c interface{}) bool {
+			ast := c.(Ast)
+			return ast.ClassName == "Op" && ast.Value == op
+		}),
+		func(_ ParserContext, asts AstSlice) (AstSlice, error) {
+			return AstSlice{asts[0].Value.(Ast)}, nil
+		},
+	)
+}
+
+func anyProductionTestOperand() ParserFn {
+	return Trans(
+		objparser.ObjClassFn(func(c interface{}) bool {
+			return c.(Ast).ClassName != "Op"
+		}),
+		func(_ ParserContext, asts AstSlice) (AstSlice, error) {
+			return AstSlice{asts[0].Value.(Ast)}, nil
+		},
+	)
+}
+
+func productionTestBinaryOp(_ ParserContext, asts AstSlice) (AstSlice, error) {
+	return AstSlice{{
+		ClassName: "Expr",
+		Type:      AstType_String,
+		Value:     "(" + asts[0].Value.(string) + asts[1].Value.(string) + asts[2].Value.(string) + ")",
+	}}, nil
+}
+
+func productionTestRule() TransformerFn {
+	return ProductionRule(
+		[]Precedence{{
+			Rules: []ParserFn{
+				Trans(
+					FlatGroup(anyProductionTestOperand(), isProductionTestOp("^"), anyProductionTestOperand()),
+					productionTestBinaryOp,
+				),
+			},
+			Rtol: true,
+		}, {
+			Rules: []ParserFn{
+				Trans(
+					FlatGroup(anyProductionTestOperand(), isProductionTestOp("*"), anyProductionTestOperand()),
+					productionTestBinaryOp,
+				),
+			},
+		}, {
+			Rules: []ParserFn{
+				Trans(
+					FlatGroup(anyProductionTestOperand(), isProductionTestOp("+"), anyProductionTestOperand()),
+					productionTestBinaryOp,
+				),
+			},
+		}},
+		FlatGroup(Start(), objparser.Any(), objparser.End()),
+	)
+}
+
+func productionTestTokens(s string) AstSlice {
+	asts := make(AstSlice, 0, len(s))
+	for i, c := range s {
+		className := "Val"
+		if strings.ContainsRune("^*+-", c) {
+			className = "Op"
+		}
+		asts = append(asts, Ast{
+			ClassName:      className,
+			Type:           AstType_String,
+			Value:          string(c),
+			SourcePosition: SourcePosition{Position: i, Length: 1},
+		})
+	}
+	return asts
+}
+
+func TestProductionRule(t *testing.T) {
+	rule := productionTestRule()
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{name: "single", text: "a", want: "a"},
+		{name: "left", text: "a+b+c", want: "((a+b)+c)"},
+		{name: "right", text: "a^b^c", want: "(a^(b^c))"},
+		{name: "precedence", text: "a+b*c^d*e+f", want: "((a+((b*(c^d))*e))+f)"},
+		{name: "unmatched", text: "a+b+", wantErr: "Op at 3+1"},
+		{name: "unmatched 2", text: "ab", wantErr: "Val at 0+1, Val at 1+1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rule(ParserContext{}, productionTestTokens(tt.text))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ProductionRule() err is %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("ProductionRule() err is %v", err)
+				return
+			}
+			if len(got) != 1 || got[0].Value != tt.want {
+				t.Errorf("ProductionRule() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductionRuleOrder(t *testing.T) {
+	// The unary operator and the binary operator of the same precedence.
+	// After each reduction, the rules are retried from the first precedence.
+	rule := ProductionRule(
+		[]Precedence{{
+			Rules: []ParserFn{
+				Trans(
+					FlatGroup(anyProductionTestOperand(), isProductionTestOp("*"), anyProductionTestOperand()),
+					productionTestBinaryOp,
+				),
+				Trans(
+					FlatGroup(isProductionTestOp("-"), anyProductionTestOperand()),
+					func(_ ParserContext, asts AstSlice) (AstSlice, error) {
+						return AstSlice{{
+							ClassName: "Expr",
+							Type:      AstType_String,
+							Value:     "(" + asts[0].Value.(string) + asts[1].Value.(string) + ")",
+						}}, nil
+					},
+				),
+			},
+		}, {
+			Rules: []ParserFn{
+				Trans(
+					FlatGroup(anyProductionTestOperand(), isProductionTestOp("+"), anyProductionTestOperand()),
+					productionTestBinaryOp,
+				),
+			},
+		}},
+		FlatGroup(Start(), objparser.Any(), objparser.End()),
+	)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "1", text: "a*-b+c", want: "((a*(-b))+c)"},
+		{name: "2", text: "a+b*-c+d", want: "((a+(b*(-c)))+d)"},
+		{name: "3", text: "--a*b", want: "((-(-a))*b)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rule(ParserContext{}, productionTestTokens(tt.text))
+			if err != nil {
+				t.Errorf("ProductionRule() err is %v", err)
+				return
+			}
+			if len(got) != 1 || got[0].Value != tt.want {
+				t.Errorf("ProductionRule() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductionRuleLongInput(t *testing.T) {
+	rule := productionTestRule()
+	text := "a" + strings.Repeat("+a", 5000)
+
+	got, err := rule(ParserContext{}, productionTestTokens(text))
+	if err != nil {
+		t.Errorf("ProductionRule() err is %v", err)
+		return
+	}
+	if len(got) != 1 || len(got[0].Value.(string)) != len(text)+2*5000 {
+		t.Errorf("ProductionRule() got unexpected result")
+	}
+}
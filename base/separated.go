@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"errors"
+
+	clsz "github.com/shellyln/takenoco/base/classes"
+)
+
+const msgDanglingSeparator = "An item is required after the separator"
+
+// Common implementation for separated list parsers.
+func sepByBase(className string, minItems int, trailing bool, item, sep ParserFn, props []interface{}) ParserFn {
+	keepSep := false
+	grouping := false
+
+	for _, p := range props {
+		switch p.(type) {
+		case KeepSeparator:
+			keepSep = true
+		case GroupItems:
+			grouping = true
+		}
+	}
+
+	return LightBaseParser(className, func(ctx ParserContext) (ParserContext, error) {
+		out, err := item(ctx)
+		if err != nil {
+			return out, err
+		}
+
+		count := 0
+		if out.MatchStatus == MatchStatus_Matched {
+			count++
+			for {
+				beforeSep := out
+				afterSep, err := sep(out)
+				if err != nil {
+					return afterSep, err
+				}
+				if afterSep.MatchStatus != MatchStatus_Matched {
+					out = beforeSep
+					break
+				}
+				if !keepSep {
+					afterSep.AstStack = afterSep.AstStack[:len(beforeSep.AstStack)]
+				}
+
+				out, err = item(afterSep)
+				if err != nil {
+					return out, err
+				}
+				if out.MatchStatus != MatchStatus_Matched {
+					if trailing {
+						out = afterSep
+						break
+					}
+					// Points at the dangling separator.
+					out = beforeSep
+					out.Length = afterSep.Position - beforeSep.Position
+					out.MatchStatus = MatchStatus_Error
+					return out, errors.New(msgDanglingSeparator)
+				}
+				if out.Position == beforeSep.Position {
+					// Neither the separator nor the item consumed the source.
+					out = beforeSep
+					break
+				}
+				count++
+			}
+		} else {
+			out = ctx
+		}
+
+		if count < minItems {
+			ctx.MatchStatus = MatchStatus_Unmatched
+			return ctx, nil
+		}
+
+		if grouping {
+			asts, _ := GroupingTransform(ctx, out.AstStack[len(ctx.AstStack):])
+			asts[0].SourcePosition = SourcePosition{
+				Position: ctx.Position,
+				Length:   out.Position - ctx.Position,
+				FileId:   ctx.FileId,
+			}
+			out.AstStack = append(out.AstStack[:len(ctx.AstStack)], asts...)
+		}
+
+		out.Length = out.Position - ctx.Position
+		out.MatchStatus = MatchStatus_Matched
+		return out, nil
+	})
+}
+
+// Separated list assertion. `(item (sep item)*)?`
+// Separators are erased unless KeepSeparator{} is specified.
+// Items are grouped by AstSlice if GroupItems{} is specified.
+// It raises an error if no item follows a separator.
+func SepBy(item, sep ParserFn, props ...interface{}) ParserFn {
+	return sepByBase(clsz.SepBy, 0, false, item, sep, props)
+}
+
+// Separated list assertion. `item (sep item)*`
+// Separators are erased unless KeepSeparator{} is specified.
+// Items are grouped by AstSlice if GroupItems{} is specified.
+// It raises an error if no item follows a separator.
+func SepBy1(item, sep ParserFn, props ...interface{}) ParserFn {
+	return sepByBase(clsz.SepBy1, 1, false, item, sep, props)
+}
+
+// Separated list assertion with an optional trailing separator. `(item (sep item)* sep?)?`
+// Separators are erased unless KeepSeparator{} is specified.
+// Items are grouped by AstSlice if GroupItems{} is specified.
+func SepEndBy(item, sep ParserFn, props ...interface{}) ParserFn {
+	return sepByBase(clsz.SepEndBy, 0, true, item, sep, props)
+}
+
+// Delimited assertion. `open p close`
+// The ASTs of open and close are erased.
+func Between(open, close, p ParserFn) ParserFn {
+	const ClassName = clsz.Between
+	return BaseParser(ClassName, nil, nil, []ParserFn{
+		Trans(open, Erase),
+		p,
+		Trans(close, Erase),
+	}, nil)
+}
+
+// Delimited assertion. `delim p delim`
+// The ASTs of delim are erased.
+func Surrounded(delim, p ParserFn) ParserFn {
+	const ClassName = clsz.Surrounded
+	return BaseParser(ClassName, nil, nil, []ParserFn{
+		Trans(delim, Erase),
+		p,
+		Trans(delim, Erase),
+	}, nil)
+}
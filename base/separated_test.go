@@ -0,0 +1,145 @@
+package parser_test
+
+import (
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/string"
+)
+
+func TestSepBy(t *testing.T) {
+	item := Trans(OneOrMoreTimes(Number()), Concat)
+	sep := Seq(",")
+
+	type args struct {
+		parser ParserFn
+		text   string
+	}
+
+	tests := []struct {
+		name       string
+		args       args
+		wantErr    bool
+		wantStatus MatchStatusType
+		wantPos    int
+		want       []interface{}
+	}{{
+		name:       "SepBy empty",
+		args:       args{parser: SepBy(item, sep), text: ""},
+		wantStatus: MatchStatus_Matched,
+		wantPos:    0,
+		want:       []interface{}{},
+	}, {
+		name:       "SepBy 1",
+		args:       args{parser: SepBy(item, sep), text: "12,3,45;"},
+		wantStatus: MatchStatus_Matched,
+		wantPos:    7,
+		want:       []interface{}{"12", "3", "45"},
+	}, {
+		name:       "SepBy keep separators",
+		args:       args{parser: SepBy(item, sep, KeepSeparator{}), text: "12,3"},
+		wantStatus: MatchStatus_Matched,
+		wantPos:    4,
+		want:       []interface{}{"12", ",", "3"},
+	}, {
+		name:       "SepBy dangling separator",
+		args:       args{parser: SepBy(item, sep), text: "12,3,;"},
+		wantErr:    true,
+		wantStatus: MatchStatus_Error,
+		wantPos:    4,
+	}, {
+		name:       "SepBy1 empty",
+		args:       args{parser: SepBy1(item, sep), text: ""},
+		wantStatus: MatchStatus_Unmatched,
+		wantPos:    0,
+	}, {
+		name:       "SepBy1 1",
+		args:       args{parser: SepBy1(item, sep), text: "1"},
+		wantStatus: MatchStatus_Matched,
+		wantPos:    1,
+		want:       []interface{}{"1"},
+	}, {
+		name:       "SepEndBy trailing separator",
+		args:       args{parser: SepEndBy(item, sep), text: "1,2,"},
+		wantStatus: MatchStatus_Matched,
+		wantPos:    4,
+		want:       []interface{}{"1", "2"},
+	}, {
+		name:       "SepEndBy grouped",
+		args:       args{parser: SepEndBy(item, sep, GroupItems{}), text: "1,2"},
+		wantStatus: MatchStatus_Matched,
+		wantPos:    3,
+		want: []interface{}{AstSlice{{
+			ClassName: ":string:Number",
+			Type:      AstType_String,
+			Value:     "1",
+		}, {
+			ClassName: ":string:Number",
+			Type:      AstType_String,
+			Value:     "2",
+		}}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.args.parser(*NewStringParserContext(tt.args.text))
+			if tt.wantErr != (err != nil) {
+				t.Errorf("err is %v, want %v", err, tt.wantErr)
+				return
+			}
+			if got.MatchStatus != tt.wantStatus {
+				t.Errorf("got.MatchStatus is %v, want %v", got.MatchStatus, tt.wantStatus)
+				return
+			}
+			if got.Position != tt.wantPos {
+				t.Errorf("got.Position is %v, want %v", got.Position, tt.wantPos)
+				return
+			}
+			if tt.wantStatus != MatchStatus_Matched {
+				return
+			}
+			if len(got.AstStack) != len(tt.want) {
+				t.Errorf("got.AstStack = %v, want %v", got.AstStack, tt.want)
+				return
+			}
+			for i, w := range tt.want {
+				if g, ok := got.AstStack[i].Value.(AstSlice); ok {
+					if !g.ItemEquals(Ast{Type: AstType_ListOfAst, Value: g}, Ast{Type: AstType_ListOfAst, Value: w}) {
+						t.Errorf("got.AstStack[%v] = %v, want %v", i, g, w)
+					}
+				} else if got.AstStack[i].Value != w {
+					t.Errorf("got.AstStack[%v] = %v, want %v", i, got.AstStack[i].Value, w)
+				}
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	parser := Between(Seq("("), Seq(")"), Trans(OneOrMoreTimes(Alpha()), Concat))
+
+	got, err := parser(*NewStringParserContext("(abc)"))
+	if err != nil || got.MatchStatus != MatchStatus_Matched {
+		t.Errorf("Between() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+		return
+	}
+	if len(got.AstStack) != 1 || got.AstStack[0].Value != "abc" {
+		t.Errorf("Between() got.AstStack = %v", got.AstStack)
+	}
+
+	got, err = parser(*NewStringParserContext("(abc"))
+	if err != nil || got.MatchStatus != MatchStatus_Unmatched {
+		t.Errorf("Between() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+
+	parser = Surrounded(Seq("'"), Trans(OneOrMoreTimes(Alpha()), Concat))
+
+	got, err = parser(*NewStringParserContext("'abc'"))
+	if err != nil || got.MatchStatus != MatchStatus_Matched {
+		t.Errorf("Surrounded() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+		return
+	}
+	if len(got.AstStack) != 1 || got.AstStack[0].Value != "abc" {
+		t.Errorf("Surrounded() got.AstStack = %v", got.AstStack)
+	}
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"strconv"
+)
+
+// Source registered in the SourceSet.
+type SourceFile struct {
+	// File ID. It starts from 1.
+	Id int
+	// Name of the source. (e.g. file path)
+	Name string
+	// Source text.
+	Src   string
+	index *LineIndex
+}
+
+// Get the line index of the source. It is built on the first call.
+func (f *SourceFile) LineIndex() *LineIndex {
+	if f.index == nil {
+		f.index = NewLineIndex(f.Src)
+	}
+	return f.index
+}
+
+// Set of the named sources. (e.g. the main file and the included files)
+// Each source has a file ID, and the parsers copy it from ParserContext.FileId to SourcePosition.FileId of the ASTs.
+// NOTE: It's not thread safe.
+type SourceSet struct {
+	files  []*SourceFile
+	byName map[string]*SourceFile
+}
+
+// Constructor
+func NewSourceSet() *SourceSet {
+	return &SourceSet{
+		files:  make([]*SourceFile, 0, 8),
+		byName: make(map[string]*SourceFile),
+	}
+}
+
+// Register the source and return it.
+// If the source with the same name is already registered, it is returned as is.
+func (s *SourceSet) Add(name, src string) *SourceFile {
+	if f, ok := s.byName[name]; ok {
+		return f
+	}
+	f := &SourceFile{
+		Id:   len(s.files) + 1,
+		Name: name,
+		Src:  src,
+	}
+	s.files = append(s.files, f)
+	s.byName[name] = f
+	return f
+}
+
+// Get the source by the file ID. It returns nil if not found.
+func (s *SourceSet) File(id int) *SourceFile {
+	if id < 1 || len(s.files) < id {
+		return nil
+	}
+	return s.files[id-1]
+}
+
+// Get the source by the name. It returns nil if not found.
+func (s *SourceSet) FileByName(name string) *SourceFile {
+	return s.byName[name]
+}
+
+// Registered sources in order of the file IDs.
+func (s *SourceSet) Files() []*SourceFile {
+	return s.files
+}
+
+// Get the line and column position of the source position in the source of pos.FileId.
+// If the file is not found, the zero value is returned.
+func (s *SourceSet) GetLineAndColPosition(pos SourcePosition, tabSize int, mode ColumnMode) LineAndColPosition {
+	f := s.File(pos.FileId)
+	if f == nil {
+		return LineAndColPosition{}
+	}
+	lc := f.LineIndex().LineAndColPosition(pos, tabSize, mode)
+	lc.FileName = f.Name
+	return lc
+}
+
+// Format the source position as `path:line:col`.
+// If the file is not found, `?:pos` is returned.
+func (s *SourceSet) FormatPosition(pos SourcePosition, tabSize int, mode ColumnMode) string {
+	f := s.File(pos.FileId)
+	if f == nil {
+		return "?:" + strconv.Itoa(pos.Position)
+	}
+	line, col := f.LineIndex().LineAndCol(pos.Position, mode, tabSize)
+	return f.Name + ":" + strconv.Itoa(line) + ":" + strconv.Itoa(col)
+}
+
+// Set the file name, line, column and source lines of the error from the source of its FileId.
+func (s *SourceSet) Locate(e *ParseError, tabSize int) *ParseError {
+	f := s.File(e.FileId)
+	if f == nil {
+		return e
+	}
+	lc := f.LineIndex().LineAndColPosition(e.SourcePosition, tabSize, ColumnMode_Byte)
+	e.FileName = f.Name
+	e.Line = lc.Line
+	e.Col = lc.Col
+	e.ErrSource = lc.ErrSource
+	return e
+}
+
+// Render the diagnostic of the source of d.Primary.FileId.
+// The secondary labels in the other sources are not shown.
+func (s *SourceSet) RenderDiagnostic(d Diagnostic, opts DiagnosticOptions) string {
+	f := s.File(d.Primary.FileId)
+	if f == nil {
+		return RenderDiagnostic("", d, opts)
+	}
+	secondary := make([]DiagnosticLabel, 0, len(d.Secondary))
+	for _, label := range d.Secondary {
+		if label.FileId == f.Id {
+			secondary = append(secondary, label)
+		}
+	}
+	d.Secondary = secondary
+	opts.Name = f.Name
+	return RenderDiagnostic(f.Src, d, opts)
+}
+
+// Format the position as `path:line:col`, or `line:col` if FileName is empty.
+func (p LineAndColPosition) String() string {
+	s := strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Col)
+	if p.FileName != "" {
+		s = p.FileName + ":" + s
+	}
+	return s
+}
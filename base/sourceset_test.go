@@ -0,0 +1,84 @@
+package parser_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/string"
+)
+
+func TestSourceSet(t *testing.T) {
+	ss := NewSourceSet()
+	main := ss.Add("main.txt", "include lib.txt\n")
+	lib := ss.Add("lib.txt", "x = 1\ny = 22\n")
+
+	if main.Id != 1 || lib.Id != 2 {
+		t.Fatalf("file IDs = %d, %d, want 1, 2", main.Id, lib.Id)
+	}
+	if f := ss.Add("lib.txt", "other"); f != lib {
+		t.Errorf("Add() registered the same name twice")
+	}
+	if ss.File(3) != nil || ss.File(0) != nil {
+		t.Errorf("File() returned the unregistered source")
+	}
+	if ss.FileByName("main.txt") != main {
+		t.Errorf("FileByName() = %v", ss.FileByName("main.txt"))
+	}
+
+	number := Trans(OneOrMoreTimes(Number()), Concat)
+	parser := FlatGroup(
+		ZeroOrMoreTimes(First(number, Trans(OneOrMoreTimes(First(Alpha(), Whitespace(), Seq("="))), Erase))),
+		End(),
+	)
+
+	out, err := parser(*NewSourceFileParserContext(lib))
+	if err != nil || out.MatchStatus != MatchStatus_Matched {
+		t.Fatalf("parse failed: %v %v", out.MatchStatus, err)
+	}
+	if len(out.AstStack) != 2 {
+		t.Fatalf("AstStack = %v", out.AstStack)
+	}
+
+	pos := out.AstStack[1].SourcePosition
+	if pos.FileId != lib.Id {
+		t.Errorf("FileId = %d, want %d", pos.FileId, lib.Id)
+	}
+	if s := ss.FormatPosition(pos, 4, ColumnMode_Byte); s != "lib.txt:2:5" {
+		t.Errorf("FormatPosition() = %q", s)
+	}
+	tabbed := ss.Add("tab.txt", "\tz")
+	if s := ss.FormatPosition(SourcePosition{Position: 1, FileId: tabbed.Id}, 8, ColumnMode_DisplayWidth); s != "tab.txt:1:9" {
+		t.Errorf("FormatPosition() = %q", s)
+	}
+	if s := ss.GetLineAndColPosition(pos, 4, ColumnMode_Byte).String(); s != "lib.txt:2:5" {
+		t.Errorf("GetLineAndColPosition().String() = %q", s)
+	}
+
+	errParser := FlatGroup(Seq("include "), Error("cannot include"))
+	_, err = errParser(*NewSourceFileParserContext(main))
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("err is not a ParseError: %v", err)
+	}
+	ss.Locate(perr, 4)
+	if want := "cannot include at main.txt:1:9\n > | include lib.txt\n   |         ^^^^"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	got := ss.RenderDiagnostic(Diagnostic{
+		Message: "duplicated",
+		Primary: DiagnosticLabel{SourcePosition: pos},
+		Secondary: []DiagnosticLabel{
+			{SourcePosition: SourcePosition{Position: 8, Length: 7, FileId: main.Id}},
+		},
+	}, DiagnosticOptions{})
+	want := "error: duplicated\n" +
+		" --> lib.txt:2:5\n" +
+		"  |\n" +
+		"2 | y = 22\n" +
+		"  |     ^\n"
+	if got != want {
+		t.Errorf("RenderDiagnostic() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -15,8 +15,8 @@ func Erase(_ ParserContext, asts AstSlice) (AstSlice, error) {
 // Transform the result AST array.
 // Raise an error.
 func TransformError(s string) TransformerFn {
-	return func(_ ParserContext, asts AstSlice) (AstSlice, error) {
-		return asts, errors.New(s)
+	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		return asts, NewTransformError(ctx, asts, errors.New(s))
 	}
 }
 
@@ -147,11 +147,15 @@ func Pop(_ ParserContext, asts AstSlice) (AstSlice, error) {
 
 // Transform the result AST array.
 // Exchange the top with one below it.
+//
+// e.g.)
+// asts bottom |0  |...|n-2|n-1| top
+//             |0  |...|n-1|n-2|
 func Exchange(_ ParserContext, asts AstSlice) (AstSlice, error) {
 	length := len(asts)
 	if 2 <= length {
 		w := make(AstSlice, 0, length)
-		w = append(w, asts[:length-2]...)
+		w = append(w, asts...)
 		w[length-2] = asts[length-1]
 		w[length-1] = asts[length-2]
 		return w, nil
@@ -175,19 +179,106 @@ func Exchange(_ ParserContext, asts AstSlice) (AstSlice, error) {
 func Roll(n int) TransformerFn {
 	return func(_ ParserContext, asts AstSlice) (AstSlice, error) {
 		length := len(asts)
-		if 0 <= n {
-			if n < length {
-				return asts, nil
-			} else {
-				return asts, nil
-			}
+		if length == 0 {
+			return asts, nil
+		}
+		m := n % length
+		if m < 0 {
+			m += length
+		}
+		if m == 0 {
+			return asts, nil
+		}
+		w := make(AstSlice, 0, length)
+		w = append(w, asts[m:]...)
+		w = append(w, asts[:m]...)
+		return w, nil
+	}
+}
+
+// Transform the result AST array.
+// Duplicate the top.
+//
+// e.g.)
+// asts bottom |0  |...|n-1| top
+//             |0  |...|n-1|n-1|
+func Dup(_ ParserContext, asts AstSlice) (AstSlice, error) {
+	length := len(asts)
+	if 1 <= length {
+		w := make(AstSlice, 0, length+1)
+		w = append(w, asts...)
+		w = append(w, asts[length-1])
+		return w, nil
+	} else {
+		return asts, nil
+	}
+}
+
+// Transform the result AST array.
+// Remove n elements from the top.
+// If n is greater than the length, all elements are removed.
+func Drop(n int) TransformerFn {
+	return func(_ ParserContext, asts AstSlice) (AstSlice, error) {
+		length := len(asts)
+		if n <= 0 {
+			return asts, nil
+		} else if n < length {
+			return asts[:length-n], nil
+		} else {
+			return asts[:0], nil
+		}
+	}
+}
+
+// Transform the result AST array.
+// Copy the n-th element from the top to the top. `Pick(0)` is the same as `Dup`.
+//
+// e.g.) n==2
+// asts bottom |0  |...|n-3|n-2|n-1| top
+//             |0  |...|n-3|n-2|n-1|n-3|
+func Pick(n int) TransformerFn {
+	return func(_ ParserContext, asts AstSlice) (AstSlice, error) {
+		length := len(asts)
+		if 0 <= n && n < length {
+			w := make(AstSlice, 0, length+1)
+			w = append(w, asts...)
+			w = append(w, asts[length-1-n])
+			return w, nil
 		} else {
-			m := -n
-			if m < length {
-				return asts, nil
-			} else {
-				return asts, nil
-			}
+			return asts, nil
 		}
 	}
 }
+
+// Transform the result AST array.
+// Rotate the top three elements.
+//
+// e.g.)
+// asts bottom |0  |...|n-3|n-2|n-1| top
+//             |0  |...|n-2|n-1|n-3|
+func Rot(_ ParserContext, asts AstSlice) (AstSlice, error) {
+	length := len(asts)
+	if 3 <= length {
+		w := make(AstSlice, 0, length)
+		w = append(w, asts[:length-3]...)
+		w = append(w, asts[length-2], asts[length-1], asts[length-3])
+		return w, nil
+	} else {
+		return asts, nil
+	}
+}
+
+// Transform the result AST array.
+// Reverse the order of the elements.
+//
+// e.g.)
+// asts bottom |0  |1  |...|n-2|n-1| top
+//             |n-1|n-2|...|1  |0  |
+func Reverse(_ ParserContext, asts AstSlice) (AstSlice, error) {
+	length := len(asts)
+	w := make(AstSlice, length)
+	for i := 0; i < length; i++ {
+		w[i] = asts[length-1-i]
+	}
+	return w, nil
+}
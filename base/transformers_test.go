@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"testing"
+)
+
+func transformersTestAsts(v ...int64) AstSlice {
+	asts := make(AstSlice, 0, len(v))
+	for _, w := range v {
+		asts = append(asts, Ast{
+			Type:  AstType_Int,
+			Value: w,
+		})
+	}
+	return asts
+}
+
+func TestStackTransformers(t *testing.T) {
+	tests := []struct {
+		name  string
+		tr    TransformerFn
+		input AstSlice
+		want  AstSlice
+	}{
+		{name: "Exchange", tr: Exchange, input: transformersTestAsts(1, 2, 3), want: transformersTestAsts(1, 3, 2)},
+		{name: "Exchange short", tr: Exchange, input: transformersTestAsts(1), want: transformersTestAsts(1)},
+		{name: "Roll 2", tr: Roll(2), input: transformersTestAsts(1, 2, 3, 4, 5), want: transformersTestAsts(3, 4, 5, 1, 2)},
+		{name: "Roll -2", tr: Roll(-2), input: transformersTestAsts(1, 2, 3, 4, 5), want: transformersTestAsts(4, 5, 1, 2, 3)},
+		{name: "Roll 7", tr: Roll(7), input: transformersTestAsts(1, 2, 3, 4, 5), want: transformersTestAsts(3, 4, 5, 1, 2)},
+		{name: "Roll 0", tr: Roll(0), input: transformersTestAsts(1, 2, 3), want: transformersTestAsts(1, 2, 3)},
+		{name: "Roll empty", tr: Roll(3), input: transformersTestAsts(), want: transformersTestAsts()},
+		{name: "Dup", tr: Dup, input: transformersTestAsts(1, 2), want: transformersTestAsts(1, 2, 2)},
+		{name: "Dup empty", tr: Dup, input: transformersTestAsts(), want: transformersTestAsts()},
+		{name: "Drop 2", tr: Drop(2), input: transformersTestAsts(1, 2, 3), want: transformersTestAsts(1)},
+		{name: "Drop 5", tr: Drop(5), input: transformersTestAsts(1, 2, 3), want: transformersTestAsts()},
+		{name: "Pick 0", tr: Pick(0), input: transformersTestAsts(1, 2, 3), want: transformersTestAsts(1, 2, 3, 3)},
+		{name: "Pick 2", tr: Pick(2), input: transformersTestAsts(1, 2, 3), want: transformersTestAsts(1, 2, 3, 1)},
+		{name: "Pick 3", tr: Pick(3), input: transformersTestAsts(1, 2, 3), want: transformersTestAsts(1, 2, 3)},
+		{name: "Rot", tr: Rot, input: transformersTestAsts(1, 2, 3, 4), want: transformersTestAsts(1, 3, 4, 2)},
+		{name: "Rot short", tr: Rot, input: transformersTestAsts(1, 2), want: transformersTestAsts(1, 2)},
+		{name: "Reverse", tr: Reverse, input: transformersTestAsts(1, 2, 3), want: transformersTestAsts(3, 2, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := make(AstSlice, len(tt.input))
+			copy(input, tt.input)
+
+			got, err := tt.tr(ParserContext{}, input)
+			if err != nil {
+				t.Errorf("%v() err is %v", tt.name, err)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("%v() = %v, want %v", tt.name, got, tt.want)
+				return
+			}
+			for i := range got {
+				if !got.ItemEquals(got[i], tt.want[i]) {
+					t.Errorf("%v() = %v, want %v", tt.name, got, tt.want)
+					return
+				}
+			}
+			for i := range input {
+				if !input.ItemEquals(input[i], tt.input[i]) {
+					t.Errorf("%v() modified the input: %v", tt.name, input)
+					return
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,955 @@
+package parser
+
+import (
+	"unsafe"
+)
+
+// Typed boxes access the values through the typed pointers without reflect.Value.
+// The getters and setters of the other types convert the numbers, string and []byte each other,
+// and the unsupported operations panic.
+
+// Panic with the message of the unsupported operation.
+func unsupportedBoxOp(op, box string) {
+	panic("Error: " + op + " is not supported by " + box + ".")
+}
+
+// Convert the number to int64.
+func anyToInt64(v interface{}) (int64, bool) {
+	switch w := v.(type) {
+	case int64:
+		return w, true
+	case int:
+		return int64(w), true
+	case int32:
+		return int64(w), true
+	case int16:
+		return int64(w), true
+	case int8:
+		return int64(w), true
+	case uint64:
+		return int64(w), true
+	case uint:
+		return int64(w), true
+	case uint32:
+		return int64(w), true
+	case uint16:
+		return int64(w), true
+	case uint8:
+		return int64(w), true
+	case uintptr:
+		return int64(w), true
+	case float64:
+		return int64(w), true
+	case float32:
+		return int64(w), true
+	}
+	return 0, false
+}
+
+// Convert the number to uint64.
+func anyToUint64(v interface{}) (uint64, bool) {
+	switch w := v.(type) {
+	case uint64:
+		return w, true
+	case float64:
+		return uint64(w), true
+	case float32:
+		return uint64(w), true
+	}
+	w, ok := anyToInt64(v)
+	return uint64(w), ok
+}
+
+// Convert the number to float64.
+func anyToFloat64(v interface{}) (float64, bool) {
+	switch w := v.(type) {
+	case float64:
+		return w, true
+	case float32:
+		return float64(w), true
+	case uint64:
+		return float64(w), true
+	case uint:
+		return float64(w), true
+	case uintptr:
+		return float64(w), true
+	}
+	w, ok := anyToInt64(v)
+	return float64(w), ok
+}
+
+// Convert the string or []byte to string.
+func anyToString(v interface{}) (string, bool) {
+	switch w := v.(type) {
+	case string:
+		return w, true
+	case []byte:
+		return string(w), true
+	}
+	return "", false
+}
+
+// Convert the string or []byte to []byte.
+func anyToBytes(v interface{}) ([]byte, bool) {
+	switch w := v.(type) {
+	case []byte:
+		return w, true
+	case string:
+		return []byte(w), true
+	}
+	return nil, false
+}
+
+// Box of the dynamic value. It is the common implementation of AnyBox and MapEntryBox.
+type anyValueBox struct {
+	name string
+	get  func() interface{}
+	set  func(v interface{})
+}
+
+func (s anyValueBox) GetInt() int64 {
+	w, ok := anyToInt64(s.get())
+	if !ok {
+		unsupportedBoxOp("GetInt", s.name)
+	}
+	return w
+}
+
+func (s anyValueBox) GetUint() uint64 {
+	w, ok := anyToUint64(s.get())
+	if !ok {
+		unsupportedBoxOp("GetUint", s.name)
+	}
+	return w
+}
+
+func (s anyValueBox) GetFloat() float64 {
+	w, ok := anyToFloat64(s.get())
+	if !ok {
+		unsupportedBoxOp("GetFloat", s.name)
+	}
+	return w
+}
+
+func (s anyValueBox) GetBool() bool {
+	w, ok := s.get().(bool)
+	if !ok {
+		unsupportedBoxOp("GetBool", s.name)
+	}
+	return w
+}
+
+func (s anyValueBox) GetString() string {
+	w, ok := anyToString(s.get())
+	if !ok {
+		unsupportedBoxOp("GetString", s.name)
+	}
+	return w
+}
+
+func (s anyValueBox) GetBytes() []byte {
+	w, ok := anyToBytes(s.get())
+	if !ok {
+		unsupportedBoxOp("GetBytes", s.name)
+	}
+	return w
+}
+
+func (s anyValueBox) GetPointer() unsafe.Pointer {
+	w, ok := s.get().(unsafe.Pointer)
+	if !ok {
+		unsupportedBoxOp("GetPointer", s.name)
+	}
+	return w
+}
+
+// The value should be []interface{}.
+func (s anyValueBox) Index(i int) Box {
+	w, ok := s.get().([]interface{})
+	if !ok {
+		unsupportedBoxOp("Index", s.name)
+	}
+	return AnyBox{Ptr: &w[i]}
+}
+
+// The value should be map[string]interface{}. The nil value is initialized with the new map.
+func (s anyValueBox) MapIndex(k string) Box {
+	v := s.get()
+	w, ok := v.(map[string]interface{})
+	if !ok && v != nil {
+		unsupportedBoxOp("MapIndex", s.name)
+	}
+	if w == nil {
+		w = make(map[string]interface{})
+		s.set(w)
+	}
+	return MapEntryBox{Map: &w, Key: k}
+}
+
+// The key should be string.
+func (s anyValueBox) ComplexMapIndex(k interface{}) Box {
+	key, ok := k.(string)
+	if !ok {
+		unsupportedBoxOp("ComplexMapIndex", s.name)
+	}
+	return s.MapIndex(key)
+}
+
+// Implements the interface Box. Typed box of int64.
+type Int64Box struct {
+	Ptr *int64
+}
+
+func (s Int64Box) GetAny() interface{} {
+	return *s.Ptr
+}
+
+func (s Int64Box) GetInt() int64 {
+	return *s.Ptr
+}
+
+func (s Int64Box) GetUint() uint64 {
+	return uint64(*s.Ptr)
+}
+
+func (s Int64Box) GetFloat() float64 {
+	return float64(*s.Ptr)
+}
+
+func (s Int64Box) GetBool() bool {
+	unsupportedBoxOp("GetBool", "Int64Box")
+	return false
+}
+
+func (s Int64Box) GetString() string {
+	unsupportedBoxOp("GetString", "Int64Box")
+	return ""
+}
+
+func (s Int64Box) GetBytes() []byte {
+	unsupportedBoxOp("GetBytes", "Int64Box")
+	return nil
+}
+
+func (s Int64Box) GetPointer() unsafe.Pointer {
+	unsupportedBoxOp("GetPointer", "Int64Box")
+	return nil
+}
+
+func (s Int64Box) SetAny(v interface{}) {
+	w, ok := anyToInt64(v)
+	if !ok {
+		unsupportedBoxOp("SetAny", "Int64Box")
+	}
+	*s.Ptr = w
+}
+
+func (s Int64Box) SetInt(v int64) {
+	*s.Ptr = v
+}
+
+func (s Int64Box) SetUint(v uint64) {
+	*s.Ptr = int64(v)
+}
+
+func (s Int64Box) SetFloat(v float64) {
+	*s.Ptr = int64(v)
+}
+
+func (s Int64Box) SetBool(v bool) {
+	unsupportedBoxOp("SetBool", "Int64Box")
+}
+
+func (s Int64Box) SetString(v string) {
+	unsupportedBoxOp("SetString", "Int64Box")
+}
+
+func (s Int64Box) SetBytes(v []byte) {
+	unsupportedBoxOp("SetBytes", "Int64Box")
+}
+
+func (s Int64Box) SetPointer(v unsafe.Pointer) {
+	unsupportedBoxOp("SetPointer", "Int64Box")
+}
+
+func (s Int64Box) Index(i int) Box {
+	unsupportedBoxOp("Index", "Int64Box")
+	return nil
+}
+
+func (s Int64Box) MapIndex(k string) Box {
+	unsupportedBoxOp("MapIndex", "Int64Box")
+	return nil
+}
+
+func (s Int64Box) ComplexMapIndex(k interface{}) Box {
+	unsupportedBoxOp("ComplexMapIndex", "Int64Box")
+	return nil
+}
+
+// Implements the interface Box. Typed box of float64.
+type Float64Box struct {
+	Ptr *float64
+}
+
+func (s Float64Box) GetAny() interface{} {
+	return *s.Ptr
+}
+
+func (s Float64Box) GetInt() int64 {
+	return int64(*s.Ptr)
+}
+
+func (s Float64Box) GetUint() uint64 {
+	return uint64(*s.Ptr)
+}
+
+func (s Float64Box) GetFloat() float64 {
+	return *s.Ptr
+}
+
+func (s Float64Box) GetBool() bool {
+	unsupportedBoxOp("GetBool", "Float64Box")
+	return false
+}
+
+func (s Float64Box) GetString() string {
+	unsupportedBoxOp("GetString", "Float64Box")
+	return ""
+}
+
+func (s Float64Box) GetBytes() []byte {
+	unsupportedBoxOp("GetBytes", "Float64Box")
+	return nil
+}
+
+func (s Float64Box) GetPointer() unsafe.Pointer {
+	unsupportedBoxOp("GetPointer", "Float64Box")
+	return nil
+}
+
+func (s Float64Box) SetAny(v interface{}) {
+	w, ok := anyToFloat64(v)
+	if !ok {
+		unsupportedBoxOp("SetAny", "Float64Box")
+	}
+	*s.Ptr = w
+}
+
+func (s Float64Box) SetInt(v int64) {
+	*s.Ptr = float64(v)
+}
+
+func (s Float64Box) SetUint(v uint64) {
+	*s.Ptr = float64(v)
+}
+
+func (s Float64Box) SetFloat(v float64) {
+	*s.Ptr = v
+}
+
+func (s Float64Box) SetBool(v bool) {
+	unsupportedBoxOp("SetBool", "Float64Box")
+}
+
+func (s Float64Box) SetString(v string) {
+	unsupportedBoxOp("SetString", "Float64Box")
+}
+
+func (s Float64Box) SetBytes(v []byte) {
+	unsupportedBoxOp("SetBytes", "Float64Box")
+}
+
+func (s Float64Box) SetPointer(v unsafe.Pointer) {
+	unsupportedBoxOp("SetPointer", "Float64Box")
+}
+
+func (s Float64Box) Index(i int) Box {
+	unsupportedBoxOp("Index", "Float64Box")
+	return nil
+}
+
+func (s Float64Box) MapIndex(k string) Box {
+	unsupportedBoxOp("MapIndex", "Float64Box")
+	return nil
+}
+
+func (s Float64Box) ComplexMapIndex(k interface{}) Box {
+	unsupportedBoxOp("ComplexMapIndex", "Float64Box")
+	return nil
+}
+
+// Implements the interface Box. Typed box of string.
+type StringBox struct {
+	Ptr *string
+}
+
+func (s StringBox) GetAny() interface{} {
+	return *s.Ptr
+}
+
+func (s StringBox) GetInt() int64 {
+	unsupportedBoxOp("GetInt", "StringBox")
+	return 0
+}
+
+func (s StringBox) GetUint() uint64 {
+	unsupportedBoxOp("GetUint", "StringBox")
+	return 0
+}
+
+func (s StringBox) GetFloat() float64 {
+	unsupportedBoxOp("GetFloat", "StringBox")
+	return 0
+}
+
+func (s StringBox) GetBool() bool {
+	unsupportedBoxOp("GetBool", "StringBox")
+	return false
+}
+
+func (s StringBox) GetString() string {
+	return *s.Ptr
+}
+
+func (s StringBox) GetBytes() []byte {
+	return []byte(*s.Ptr)
+}
+
+func (s StringBox) GetPointer() unsafe.Pointer {
+	unsupportedBoxOp("GetPointer", "StringBox")
+	return nil
+}
+
+func (s StringBox) SetAny(v interface{}) {
+	w, ok := anyToString(v)
+	if !ok {
+		unsupportedBoxOp("SetAny", "StringBox")
+	}
+	*s.Ptr = w
+}
+
+func (s StringBox) SetInt(v int64) {
+	unsupportedBoxOp("SetInt", "StringBox")
+}
+
+func (s StringBox) SetUint(v uint64) {
+	unsupportedBoxOp("SetUint", "StringBox")
+}
+
+func (s StringBox) SetFloat(v float64) {
+	unsupportedBoxOp("SetFloat", "StringBox")
+}
+
+func (s StringBox) SetBool(v bool) {
+	unsupportedBoxOp("SetBool", "StringBox")
+}
+
+func (s StringBox) SetString(v string) {
+	*s.Ptr = v
+}
+
+func (s StringBox) SetBytes(v []byte) {
+	*s.Ptr = string(v)
+}
+
+func (s StringBox) SetPointer(v unsafe.Pointer) {
+	unsupportedBoxOp("SetPointer", "StringBox")
+}
+
+func (s StringBox) Index(i int) Box {
+	unsupportedBoxOp("Index", "StringBox")
+	return nil
+}
+
+func (s StringBox) MapIndex(k string) Box {
+	unsupportedBoxOp("MapIndex", "StringBox")
+	return nil
+}
+
+func (s StringBox) ComplexMapIndex(k interface{}) Box {
+	unsupportedBoxOp("ComplexMapIndex", "StringBox")
+	return nil
+}
+
+// Implements the interface Box. Typed box of []byte.
+type BytesBox struct {
+	Ptr *[]byte
+}
+
+func (s BytesBox) GetAny() interface{} {
+	return *s.Ptr
+}
+
+func (s BytesBox) GetInt() int64 {
+	unsupportedBoxOp("GetInt", "BytesBox")
+	return 0
+}
+
+func (s BytesBox) GetUint() uint64 {
+	unsupportedBoxOp("GetUint", "BytesBox")
+	return 0
+}
+
+func (s BytesBox) GetFloat() float64 {
+	unsupportedBoxOp("GetFloat", "BytesBox")
+	return 0
+}
+
+func (s BytesBox) GetBool() bool {
+	unsupportedBoxOp("GetBool", "BytesBox")
+	return false
+}
+
+func (s BytesBox) GetString() string {
+	return string(*s.Ptr)
+}
+
+func (s BytesBox) GetBytes() []byte {
+	return *s.Ptr
+}
+
+// Get the pointer to the first element. It is nil if the slice is nil.
+func (s BytesBox) GetPointer() unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(s.Ptr))
+}
+
+func (s BytesBox) SetAny(v interface{}) {
+	w, ok := anyToBytes(v)
+	if !ok {
+		unsupportedBoxOp("SetAny", "BytesBox")
+	}
+	*s.Ptr = w
+}
+
+func (s BytesBox) SetInt(v int64) {
+	unsupportedBoxOp("SetInt", "BytesBox")
+}
+
+func (s BytesBox) SetUint(v uint64) {
+	unsupportedBoxOp("SetUint", "BytesBox")
+}
+
+func (s BytesBox) SetFloat(v float64) {
+	unsupportedBoxOp("SetFloat", "BytesBox")
+}
+
+func (s BytesBox) SetBool(v bool) {
+	unsupportedBoxOp("SetBool", "BytesBox")
+}
+
+func (s BytesBox) SetString(v string) {
+	*s.Ptr = []byte(v)
+}
+
+func (s BytesBox) SetBytes(v []byte) {
+	*s.Ptr = v
+}
+
+func (s BytesBox) SetPointer(v unsafe.Pointer) {
+	unsupportedBoxOp("SetPointer", "BytesBox")
+}
+
+func (s BytesBox) Index(i int) Box {
+	unsupportedBoxOp("Index", "BytesBox")
+	return nil
+}
+
+func (s BytesBox) MapIndex(k string) Box {
+	unsupportedBoxOp("MapIndex", "BytesBox")
+	return nil
+}
+
+func (s BytesBox) ComplexMapIndex(k interface{}) Box {
+	unsupportedBoxOp("ComplexMapIndex", "BytesBox")
+	return nil
+}
+
+// Implements the interface Box. Box of interface{}. (e.g. the element of []interface{})
+// The getters convert the dynamic value without reflection.
+type AnyBox struct {
+	Ptr *interface{}
+}
+
+func (s AnyBox) box() anyValueBox {
+	return anyValueBox{
+		name: "AnyBox",
+		get:  func() interface{} { return *s.Ptr },
+		set:  func(v interface{}) { *s.Ptr = v },
+	}
+}
+
+func (s AnyBox) GetAny() interface{} {
+	return *s.Ptr
+}
+
+func (s AnyBox) GetInt() int64 {
+	return s.box().GetInt()
+}
+
+func (s AnyBox) GetUint() uint64 {
+	return s.box().GetUint()
+}
+
+func (s AnyBox) GetFloat() float64 {
+	return s.box().GetFloat()
+}
+
+func (s AnyBox) GetBool() bool {
+	return s.box().GetBool()
+}
+
+func (s AnyBox) GetString() string {
+	return s.box().GetString()
+}
+
+func (s AnyBox) GetBytes() []byte {
+	return s.box().GetBytes()
+}
+
+// The value should be unsafe.Pointer.
+func (s AnyBox) GetPointer() unsafe.Pointer {
+	return s.box().GetPointer()
+}
+
+func (s AnyBox) SetAny(v interface{}) {
+	*s.Ptr = v
+}
+
+func (s AnyBox) SetInt(v int64) {
+	*s.Ptr = v
+}
+
+func (s AnyBox) SetUint(v uint64) {
+	*s.Ptr = v
+}
+
+func (s AnyBox) SetFloat(v float64) {
+	*s.Ptr = v
+}
+
+func (s AnyBox) SetBool(v bool) {
+	*s.Ptr = v
+}
+
+func (s AnyBox) SetString(v string) {
+	*s.Ptr = v
+}
+
+func (s AnyBox) SetBytes(v []byte) {
+	*s.Ptr = v
+}
+
+func (s AnyBox) SetPointer(v unsafe.Pointer) {
+	*s.Ptr = v
+}
+
+// The value should be []interface{}.
+func (s AnyBox) Index(i int) Box {
+	return s.box().Index(i)
+}
+
+// The value should be map[string]interface{}. The nil value is initialized with the new map.
+func (s AnyBox) MapIndex(k string) Box {
+	return s.box().MapIndex(k)
+}
+
+// The value should be map[string]interface{}, and the key should be string.
+func (s AnyBox) ComplexMapIndex(k interface{}) Box {
+	return s.box().ComplexMapIndex(k)
+}
+
+// Implements the interface Box. Typed box of []interface{}.
+type SliceBox struct {
+	Ptr *[]interface{}
+}
+
+func (s SliceBox) GetAny() interface{} {
+	return *s.Ptr
+}
+
+func (s SliceBox) GetInt() int64 {
+	unsupportedBoxOp("GetInt", "SliceBox")
+	return 0
+}
+
+func (s SliceBox) GetUint() uint64 {
+	unsupportedBoxOp("GetUint", "SliceBox")
+	return 0
+}
+
+func (s SliceBox) GetFloat() float64 {
+	unsupportedBoxOp("GetFloat", "SliceBox")
+	return 0
+}
+
+func (s SliceBox) GetBool() bool {
+	unsupportedBoxOp("GetBool", "SliceBox")
+	return false
+}
+
+func (s SliceBox) GetString() string {
+	unsupportedBoxOp("GetString", "SliceBox")
+	return ""
+}
+
+func (s SliceBox) GetBytes() []byte {
+	unsupportedBoxOp("GetBytes", "SliceBox")
+	return nil
+}
+
+// Get the pointer to the first element. It is nil if the slice is nil.
+func (s SliceBox) GetPointer() unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(s.Ptr))
+}
+
+func (s SliceBox) SetAny(v interface{}) {
+	w, ok := v.([]interface{})
+	if !ok {
+		unsupportedBoxOp("SetAny", "SliceBox")
+	}
+	*s.Ptr = w
+}
+
+func (s SliceBox) SetInt(v int64) {
+	unsupportedBoxOp("SetInt", "SliceBox")
+}
+
+func (s SliceBox) SetUint(v uint64) {
+	unsupportedBoxOp("SetUint", "SliceBox")
+}
+
+func (s SliceBox) SetFloat(v float64) {
+	unsupportedBoxOp("SetFloat", "SliceBox")
+}
+
+func (s SliceBox) SetBool(v bool) {
+	unsupportedBoxOp("SetBool", "SliceBox")
+}
+
+func (s SliceBox) SetString(v string) {
+	unsupportedBoxOp("SetString", "SliceBox")
+}
+
+func (s SliceBox) SetBytes(v []byte) {
+	unsupportedBoxOp("SetBytes", "SliceBox")
+}
+
+func (s SliceBox) SetPointer(v unsafe.Pointer) {
+	unsupportedBoxOp("SetPointer", "SliceBox")
+}
+
+func (s SliceBox) Index(i int) Box {
+	return AnyBox{Ptr: &(*s.Ptr)[i]}
+}
+
+func (s SliceBox) MapIndex(k string) Box {
+	unsupportedBoxOp("MapIndex", "SliceBox")
+	return nil
+}
+
+func (s SliceBox) ComplexMapIndex(k interface{}) Box {
+	unsupportedBoxOp("ComplexMapIndex", "SliceBox")
+	return nil
+}
+
+// Implements the interface Box. Typed box of map[string]interface{}.
+type MapBox struct {
+	Ptr *map[string]interface{}
+}
+
+func (s MapBox) GetAny() interface{} {
+	return *s.Ptr
+}
+
+func (s MapBox) GetInt() int64 {
+	unsupportedBoxOp("GetInt", "MapBox")
+	return 0
+}
+
+func (s MapBox) GetUint() uint64 {
+	unsupportedBoxOp("GetUint", "MapBox")
+	return 0
+}
+
+func (s MapBox) GetFloat() float64 {
+	unsupportedBoxOp("GetFloat", "MapBox")
+	return 0
+}
+
+func (s MapBox) GetBool() bool {
+	unsupportedBoxOp("GetBool", "MapBox")
+	return false
+}
+
+func (s MapBox) GetString() string {
+	unsupportedBoxOp("GetString", "MapBox")
+	return ""
+}
+
+func (s MapBox) GetBytes() []byte {
+	unsupportedBoxOp("GetBytes", "MapBox")
+	return nil
+}
+
+// Get the pointer to the map header. It is nil if the map is nil.
+func (s MapBox) GetPointer() unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(s.Ptr))
+}
+
+func (s MapBox) SetAny(v interface{}) {
+	w, ok := v.(map[string]interface{})
+	if !ok {
+		unsupportedBoxOp("SetAny", "MapBox")
+	}
+	*s.Ptr = w
+}
+
+func (s MapBox) SetInt(v int64) {
+	unsupportedBoxOp("SetInt", "MapBox")
+}
+
+func (s MapBox) SetUint(v uint64) {
+	unsupportedBoxOp("SetUint", "MapBox")
+}
+
+func (s MapBox) SetFloat(v float64) {
+	unsupportedBoxOp("SetFloat", "MapBox")
+}
+
+func (s MapBox) SetBool(v bool) {
+	unsupportedBoxOp("SetBool", "MapBox")
+}
+
+func (s MapBox) SetString(v string) {
+	unsupportedBoxOp("SetString", "MapBox")
+}
+
+func (s MapBox) SetBytes(v []byte) {
+	unsupportedBoxOp("SetBytes", "MapBox")
+}
+
+func (s MapBox) SetPointer(v unsafe.Pointer) {
+	unsupportedBoxOp("SetPointer", "MapBox")
+}
+
+func (s MapBox) Index(i int) Box {
+	unsupportedBoxOp("Index", "MapBox")
+	return nil
+}
+
+func (s MapBox) MapIndex(k string) Box {
+	return MapEntryBox{Map: s.Ptr, Key: k}
+}
+
+// The key should be string.
+func (s MapBox) ComplexMapIndex(k interface{}) Box {
+	key, ok := k.(string)
+	if !ok {
+		unsupportedBoxOp("ComplexMapIndex", "MapBox")
+	}
+	return MapEntryBox{Map: s.Ptr, Key: key}
+}
+
+// Implements the interface Box. Entry of map[string]interface{}.
+// The nil map is initialized when the value is set.
+type MapEntryBox struct {
+	Map *map[string]interface{}
+	Key string
+}
+
+func (s MapEntryBox) box() anyValueBox {
+	return anyValueBox{
+		name: "MapEntryBox",
+		get:  func() interface{} { return (*s.Map)[s.Key] },
+		set:  s.set,
+	}
+}
+
+// Set the value. The nil map is initialized.
+func (s MapEntryBox) set(v interface{}) {
+	if *s.Map == nil {
+		*s.Map = make(map[string]interface{})
+	}
+	(*s.Map)[s.Key] = v
+}
+
+func (s MapEntryBox) GetAny() interface{} {
+	return (*s.Map)[s.Key]
+}
+
+func (s MapEntryBox) GetInt() int64 {
+	return s.box().GetInt()
+}
+
+func (s MapEntryBox) GetUint() uint64 {
+	return s.box().GetUint()
+}
+
+func (s MapEntryBox) GetFloat() float64 {
+	return s.box().GetFloat()
+}
+
+func (s MapEntryBox) GetBool() bool {
+	return s.box().GetBool()
+}
+
+func (s MapEntryBox) GetString() string {
+	return s.box().GetString()
+}
+
+func (s MapEntryBox) GetBytes() []byte {
+	return s.box().GetBytes()
+}
+
+// The value should be unsafe.Pointer.
+func (s MapEntryBox) GetPointer() unsafe.Pointer {
+	return s.box().GetPointer()
+}
+
+func (s MapEntryBox) SetAny(v interface{}) {
+	s.set(v)
+}
+
+func (s MapEntryBox) SetInt(v int64) {
+	s.set(v)
+}
+
+func (s MapEntryBox) SetUint(v uint64) {
+	s.set(v)
+}
+
+func (s MapEntryBox) SetFloat(v float64) {
+	s.set(v)
+}
+
+func (s MapEntryBox) SetBool(v bool) {
+	s.set(v)
+}
+
+func (s MapEntryBox) SetString(v string) {
+	s.set(v)
+}
+
+func (s MapEntryBox) SetBytes(v []byte) {
+	s.set(v)
+}
+
+func (s MapEntryBox) SetPointer(v unsafe.Pointer) {
+	s.set(v)
+}
+
+// The value should be []interface{}.
+func (s MapEntryBox) Index(i int) Box {
+	return s.box().Index(i)
+}
+
+// The value should be map[string]interface{}. The nil value is initialized with the new map.
+func (s MapEntryBox) MapIndex(k string) Box {
+	return s.box().MapIndex(k)
+}
+
+// The value should be map[string]interface{}, and the key should be string.
+func (s MapEntryBox) ComplexMapIndex(k interface{}) Box {
+	return s.box().ComplexMapIndex(k)
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+var (
+	_ Box = Int64Box{}
+	_ Box = Float64Box{}
+	_ Box = StringBox{}
+	_ Box = BytesBox{}
+	_ Box = AnyBox{}
+	_ Box = SliceBox{}
+	_ Box = MapBox{}
+	_ Box = MapEntryBox{}
+)
+
+func TestTypedBox(t *testing.T) {
+	i := int64(1)
+	f := 1.5
+	s := "a"
+	b := []byte("b")
+
+	Int64Box{Ptr: &i}.SetFloat(3.9)
+	Float64Box{Ptr: &f}.SetAny(2)
+	StringBox{Ptr: &s}.SetBytes([]byte("xyz"))
+	BytesBox{Ptr: &b}.SetString("uvw")
+
+	if i != 3 || f != 2 || s != "xyz" || string(b) != "uvw" {
+		t.Errorf("Set*() = %v, %v, %v, %v", i, f, s, b)
+	}
+	if (Int64Box{Ptr: &i}).GetFloat() != 3 || (Float64Box{Ptr: &f}).GetInt() != 2 ||
+		string((StringBox{Ptr: &s}).GetBytes()) != "xyz" || (BytesBox{Ptr: &b}).GetString() != "uvw" {
+		t.Errorf("Get*() failed")
+	}
+	if (BytesBox{Ptr: &b}).GetPointer() != unsafe.Pointer(&b[0]) {
+		t.Errorf("GetPointer() failed")
+	}
+}
+
+func TestTypedBoxContainer(t *testing.T) {
+	list := []interface{}{int32(1), "s", nil}
+	var m map[string]interface{}
+
+	lb := SliceBox{Ptr: &list}
+	if lb.Index(0).GetInt() != 1 || lb.Index(0).GetFloat() != 1 || lb.Index(1).GetString() != "s" {
+		t.Errorf("SliceBox.Index() failed")
+	}
+	lb.Index(1).SetBool(true)
+	lb.Index(2).MapIndex("k").SetInt(5)
+
+	mb := MapBox{Ptr: &m}
+	mb.MapIndex("list").SetAny(list)
+	mb.MapIndex("obj").MapIndex("x").SetString("y")
+	mb.ComplexMapIndex("list").Index(0).SetUint(7)
+
+	want := map[string]interface{}{
+		"list": []interface{}{uint64(7), true, map[string]interface{}{"k": int64(5)}},
+		"obj":  map[string]interface{}{"x": "y"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("MapBox = %#v, want %#v", m, want)
+	}
+	if mb.GetPointer() == nil || lb.GetPointer() != unsafe.Pointer(&list[0]) {
+		t.Errorf("GetPointer() failed")
+	}
+
+	var p interface{}
+	AnyBox{Ptr: &p}.SetPointer(unsafe.Pointer(&list))
+	if (AnyBox{Ptr: &p}).GetPointer() != unsafe.Pointer(&list) {
+		t.Errorf("AnyBox.GetPointer() failed")
+	}
+}
+
+func TestTypedBoxUnsupported(t *testing.T) {
+	i := int64(1)
+	list := []interface{}{"s"}
+	tests := []struct {
+		name string
+		fn   func()
+		msg  string
+	}{
+		{"scalar", func() { Int64Box{Ptr: &i}.GetString() }, "GetString is not supported by Int64Box"},
+		{"conversion", func() { SliceBox{Ptr: &list}.Index(0).GetInt() }, "GetInt is not supported by AnyBox"},
+		{"index", func() { SliceBox{Ptr: &list}.Index(0).MapIndex("k") }, "MapIndex is not supported by AnyBox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if msg, ok := r.(string); !ok || !strings.Contains(msg, tt.msg) {
+					t.Errorf("panic = %v, want %v", r, tt.msg)
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestReflectionBoxPointer(t *testing.T) {
+	x := 1
+	var p unsafe.Pointer
+	box := ReflectionBox{Val: reflect.ValueOf(&p).Elem()}
+	box.SetPointer(unsafe.Pointer(&x))
+	if p != unsafe.Pointer(&x) || (ReflectionBox{Val: reflect.ValueOf(&x)}).GetPointer() != unsafe.Pointer(&x) {
+		t.Errorf("ReflectionBox pointer access failed")
+	}
+
+	m := map[string]unsafe.Pointer{}
+	mbox := MapContainerReflectionBox{Container: reflect.ValueOf(m), Key: reflect.ValueOf("k")}
+	mbox.SetPointer(unsafe.Pointer(&x))
+	if mbox.GetPointer() != unsafe.Pointer(&x) {
+		t.Errorf("MapContainerReflectionBox pointer access failed")
+	}
+}
@@ -24,15 +24,22 @@ type SourcePosition struct {
 	Position int `json:"pos,omitempty"`
 	// Length of the token in the source string or slice.
 	Length int `json:"len,omitempty"`
+	// ID of the source in the SourceSet. It is 0 if the source is not registered.
+	FileId int `json:"file,omitempty"`
 }
 
 // Source position (for error reporting)
 type LineAndColPosition struct {
-	LineIndex int
-	Line      int
-	Col       int
-	Position  int
-	ErrSource string
+	LineIndex   int
+	Line        int
+	Col         int
+	EndLine     int
+	EndCol      int
+	Position    int
+	EndPosition int
+	ErrSource   string
+	// Source name. It is set by the SourceSet.
+	FileName string
 }
 
 // Match result status
@@ -67,6 +74,8 @@ type ParserContext struct {
 	Str string
 	// Source for object parser ([]T)
 	Slice SliceLike
+	// Source for binary parser ([]byte)
+	Bytes []byte
 	// Position is next source position. Length is matched source length.
 	SourcePosition
 	// Number of times the assertion is matched.
@@ -101,6 +110,16 @@ type ThereExists struct {
 type Rewind struct {
 }
 
+// Separator retention property of SepBy(), SepBy1() and SepEndBy(). This is a marker object.
+// If specified, the ASTs of the separators are kept in the result.
+type KeepSeparator struct {
+}
+
+// Grouping property of SepBy(), SepBy1() and SepEndBy(). This is a marker object.
+// If specified, the resulting ASTs are grouped by AstSlice.
+type GroupItems struct {
+}
+
 // Character code range property of BaseParser().
 type RuneRange struct {
 	// The smallest character code in the range.
@@ -0,0 +1,237 @@
+package parser
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Range of the characters.
+type runeSpan struct {
+	lo rune
+	hi rune
+}
+
+// East Asian Wide (W) and Fullwidth (F) characters, and emoji presentation characters.
+// https://www.unicode.org/reports/tr11/
+var wideRuneSpans = []runeSpan{
+	{0x1100, 0x115f}, {0x231a, 0x231b}, {0x2329, 0x232a}, {0x23e9, 0x23ec},
+	{0x23f0, 0x23f0}, {0x23f3, 0x23f3}, {0x25fd, 0x25fe}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267f, 0x267f}, {0x2693, 0x2693}, {0x26a1, 0x26a1},
+	{0x26aa, 0x26ab}, {0x26bd, 0x26be}, {0x26c4, 0x26c5}, {0x26ce, 0x26ce},
+	{0x26d4, 0x26d4}, {0x26ea, 0x26ea}, {0x26f2, 0x26f3}, {0x26f5, 0x26f5},
+	{0x26fa, 0x26fa}, {0x26fd, 0x26fd}, {0x2705, 0x2705}, {0x270a, 0x270b},
+	{0x2728, 0x2728}, {0x274c, 0x274c}, {0x274e, 0x274e}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27b0, 0x27b0}, {0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c}, {0x2b50, 0x2b50}, {0x2b55, 0x2b55}, {0x2e80, 0x303e},
+	{0x3041, 0x33ff}, {0x3400, 0x4dbf}, {0x4e00, 0x9fff}, {0xa000, 0xa4cf},
+	{0xa960, 0xa97f}, {0xac00, 0xd7a3}, {0xf900, 0xfaff}, {0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f}, {0xff00, 0xff60}, {0xffe0, 0xffe6}, {0x16fe0, 0x16fe4},
+	{0x17000, 0x18aff}, {0x1b000, 0x1b2ff}, {0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e}, {0x1f191, 0x1f19a}, {0x1f1e6, 0x1f1ff}, {0x1f200, 0x1f202},
+	{0x1f210, 0x1f23b}, {0x1f240, 0x1f248}, {0x1f250, 0x1f251}, {0x1f260, 0x1f265},
+	{0x1f300, 0x1f320}, {0x1f32d, 0x1f335}, {0x1f337, 0x1f37c}, {0x1f37e, 0x1f393},
+	{0x1f3a0, 0x1f3ca}, {0x1f3cf, 0x1f3d3}, {0x1f3e0, 0x1f3f0}, {0x1f3f4, 0x1f3f4},
+	{0x1f3f8, 0x1f43e}, {0x1f440, 0x1f440}, {0x1f442, 0x1f4fc}, {0x1f4ff, 0x1f53d},
+	{0x1f54b, 0x1f54e}, {0x1f550, 0x1f567}, {0x1f57a, 0x1f57a}, {0x1f595, 0x1f596},
+	{0x1f5a4, 0x1f5a4}, {0x1f5fb, 0x1f64f}, {0x1f680, 0x1f6c5}, {0x1f6cc, 0x1f6cc},
+	{0x1f6d0, 0x1f6d2}, {0x1f6d5, 0x1f6d7}, {0x1f6eb, 0x1f6ec}, {0x1f6f4, 0x1f6fc},
+	{0x1f7e0, 0x1f7eb}, {0x1f90c, 0x1f93a}, {0x1f93c, 0x1f945}, {0x1f947, 0x1f9ff},
+	{0x1fa70, 0x1faff}, {0x20000, 0x2fffd}, {0x30000, 0x3fffd},
+}
+
+// Test whether the character is wide (2 cells).
+func isWideRune(r rune) bool {
+	lo, hi := 0, len(wideRuneSpans)
+	for lo < hi {
+		m := (lo + hi) / 2
+		switch {
+		case r < wideRuneSpans[m].lo:
+			hi = m
+		case wideRuneSpans[m].hi < r:
+			lo = m + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Test whether the character extends the previous grapheme cluster.
+// (Grapheme_Extend, SpacingMark, ZWJ, variation selectors, emoji modifiers and tags)
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == 0x200c || r == 0x200d ||
+		0xfe00 <= r && r <= 0xfe0f ||
+		0x1f3fb <= r && r <= 0x1f3ff ||
+		0xe0020 <= r && r <= 0xe007f ||
+		0xe0100 <= r && r <= 0xe01ef
+}
+
+// Test whether the character is a regional indicator. (flag emoji)
+func isRegionalIndicator(r rune) bool {
+	return 0x1f1e6 <= r && r <= 0x1f1ff
+}
+
+// Hangul syllable type
+type hangulType int
+
+const (
+	hangulNone hangulType = iota
+	hangulL
+	hangulV
+	hangulT
+	hangulLV
+	hangulLVT
+)
+
+// Get the Hangul syllable type.
+func getHangulType(r rune) hangulType {
+	switch {
+	case 0x1100 <= r && r <= 0x115f, 0xa960 <= r && r <= 0xa97c:
+		return hangulL
+	case 0x1160 <= r && r <= 0x11a7, 0xd7b0 <= r && r <= 0xd7c6:
+		return hangulV
+	case 0x11a8 <= r && r <= 0x11ff, 0xd7cb <= r && r <= 0xd7fb:
+		return hangulT
+	case 0xac00 <= r && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return hangulLV
+		}
+		return hangulLVT
+	default:
+		return hangulNone
+	}
+}
+
+// Test whether two Hangul characters are in the same syllable.
+func isHangulContinued(prev, next hangulType) bool {
+	switch prev {
+	case hangulL:
+		return next == hangulL || next == hangulV || next == hangulLV || next == hangulLVT
+	case hangulV, hangulLV:
+		return next == hangulV || next == hangulT
+	case hangulT, hangulLVT:
+		return next == hangulT
+	default:
+		return false
+	}
+}
+
+// Get the byte length of the first grapheme cluster.
+// It is a simplified implementation of UAX #29 extended grapheme clusters.
+func graphemeLength(s string) int {
+	r, length := utf8.DecodeRuneInString(s)
+	if length == 0 {
+		return 0
+	}
+	if r == '\r' {
+		if 1 < len(s) && s[1] == '\n' {
+			return 2
+		}
+		return 1
+	}
+	if r < 0x20 || r == 0x7f {
+		return length
+	}
+
+	prev := r
+	riCount := 0
+	if isRegionalIndicator(r) {
+		riCount = 1
+	}
+
+	for length < len(s) {
+		next, n := utf8.DecodeRuneInString(s[length:])
+		switch {
+		case isGraphemeExtend(next):
+		case prev == 0x200d && !unicode.IsControl(next):
+			// ZWJ sequence
+		case riCount == 1 && isRegionalIndicator(next):
+			riCount = 2
+		case isHangulContinued(getHangulType(prev), getHangulType(next)):
+		default:
+			return length
+		}
+		prev = next
+		length += n
+	}
+	return length
+}
+
+// Get the display width of the grapheme cluster.
+func graphemeWidth(g string, tabSize int) int {
+	width := 0
+	for _, r := range g {
+		switch {
+		case r == '\t':
+			return tabSize
+		case r == 0xfe0f:
+			// Emoji presentation selector
+			width = 2
+		case isWideRune(r):
+			width = 2
+		case r < 0x20 || r == 0x7f || isGraphemeExtend(r) || unicode.Is(unicode.Cf, r):
+		default:
+			if width == 0 {
+				width = 1
+			}
+		}
+	}
+	return width
+}
+
+// Mode of the column counting.
+type ColumnMode int
+
+const (
+	// Count the columns by bytes (UTF-8 code units).
+	ColumnMode_Byte ColumnMode = iota
+	// Count the columns by characters (Unicode code points).
+	ColumnMode_Rune
+	// Count the columns by grapheme clusters.
+	ColumnMode_Grapheme
+	// Count the columns by UTF-16 code units. (LSP, JavaScript)
+	ColumnMode_Utf16
+	// Count the columns by the display width. (East Asian wide characters are 2 cells, and tabs are tabSize cells.)
+	ColumnMode_DisplayWidth
+)
+
+// Measure the width of the string.
+func measureColumns(s string, mode ColumnMode, tabSize int) int {
+	switch mode {
+	case ColumnMode_Rune:
+		return utf8.RuneCountInString(s)
+	case ColumnMode_Grapheme:
+		count := 0
+		for i := 0; i < len(s); count++ {
+			i += graphemeLength(s[i:])
+		}
+		return count
+	case ColumnMode_Utf16:
+		count := 0
+		for _, r := range s {
+			if 0x10000 <= r {
+				count += 2
+			} else {
+				count++
+			}
+		}
+		return count
+	case ColumnMode_DisplayWidth:
+		width := 0
+		for i := 0; i < len(s); {
+			n := graphemeLength(s[i:])
+			width += graphemeWidth(s[i:i+n], tabSize)
+			i += n
+		}
+		return width
+	default:
+		return len(s)
+	}
+}
+
+// Get the display width of the string.
+// East Asian wide characters are 2 cells, and tabs are tabSize cells.
+func StringWidth(s string, tabSize int) int {
+	return measureColumns(s, ColumnMode_DisplayWidth, tabSize)
+}
@@ -0,0 +1,553 @@
+package parser
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Options of UnmarshalAstWithOptions().
+type AstUnmarshalOptions struct {
+	// Concrete types of the interface values by the class name.
+	// The map values are the prototypes of the types. (e.g. `"FunctionCall": &FunctionCall{}`)
+	Types map[string]interface{}
+}
+
+var (
+	astReflectType            = reflect.TypeOf(Ast{})
+	astSliceReflectType       = reflect.TypeOf(AstSlice{})
+	astTypeReflectType        = reflect.TypeOf(AstType_Nil)
+	sourcePositionReflectType = reflect.TypeOf(SourcePosition{})
+)
+
+// The index of the `#n` selector is not an integer.
+var errBadAstTag = errors.New("Bad ast tag")
+
+// Unmarshaller state
+type astUnmarshaller struct {
+	types map[string]reflect.Type
+}
+
+// Get the child ASTs. The children of AstCons are the car and cdr.
+func astChildren(ast Ast) AstSlice {
+	switch ast.Type {
+	case AstType_ListOfAst:
+		children, _ := ast.Value.(AstSlice)
+		return children
+	case AstType_AstCons:
+		cons, ok := ast.Value.(AstCons)
+		if !ok {
+			return nil
+		}
+		return AstSlice{cons.Car, cons.Cdr}
+	default:
+		return nil
+	}
+}
+
+// Make the AST from the element of ListOfAny.
+func astFromAny(v interface{}, pos SourcePosition) Ast {
+	ast := Ast{Value: v, SourcePosition: pos}
+	switch w := v.(type) {
+	case nil:
+		ast.Type = AstType_Nil
+	case bool:
+		ast.Type = AstType_Bool
+	case int:
+		ast.Type, ast.Value = AstType_Int, int64(w)
+	case int8:
+		ast.Type, ast.Value = AstType_Int, int64(w)
+	case int16:
+		ast.Type, ast.Value = AstType_Int, int64(w)
+	case int32:
+		// The element has no AST type, so rune is also decoded as Int.
+		ast.Type, ast.Value = AstType_Int, int64(w)
+	case int64:
+		ast.Type = AstType_Int
+	case uint:
+		ast.Type, ast.Value = AstType_Uint, uint64(w)
+	case uint8:
+		ast.Type, ast.Value = AstType_Uint, uint64(w)
+	case uint16:
+		ast.Type, ast.Value = AstType_Uint, uint64(w)
+	case uint32:
+		ast.Type, ast.Value = AstType_Uint, uint64(w)
+	case uint64:
+		ast.Type = AstType_Uint
+	case float32:
+		ast.Type, ast.Value = AstType_Float, float64(w)
+	case float64:
+		ast.Type = AstType_Float
+	case string:
+		ast.Type = AstType_String
+	case AstSlice:
+		ast.Type = AstType_ListOfAst
+	case []interface{}, SliceLike:
+		ast.Type = AstType_ListOfAny
+	default:
+		ast.Type = AstType_Any
+	}
+	return ast
+}
+
+// Get the value of the AST as the Go value. The lists are converted to []interface{}.
+func astGenericValue(ast Ast) interface{} {
+	switch ast.Type {
+	case AstType_ListOfAst, AstType_AstCons:
+		children := astChildren(ast)
+		w := make([]interface{}, 0, len(children))
+		for _, child := range children {
+			w = append(w, astGenericValue(child))
+		}
+		return w
+	default:
+		return ast.Value
+	}
+}
+
+// Make the error at the source position of the AST.
+func (u *astUnmarshaller) error(path string, ast Ast, msg string) error {
+	return NewParseError(ast.SourcePosition, ast.ClassName, path+": "+msg)
+}
+
+// Make the type mismatch error.
+func (u *astUnmarshaller) mismatch(path string, ast Ast, rv reflect.Value) error {
+	return u.error(path, ast, "Cannot unmarshal "+ast.Type.String()+" into Go value of type "+rv.Type().String())
+}
+
+// Decode the AST into the value.
+func (u *astUnmarshaller) decode(path string, ast Ast, rv reflect.Value) error {
+	switch rv.Type() {
+	case astReflectType:
+		rv.Set(reflect.ValueOf(ast))
+		return nil
+	case astSliceReflectType:
+		switch ast.Type {
+		case AstType_Nil:
+			rv.Set(reflect.Zero(rv.Type()))
+		case AstType_ListOfAst, AstType_AstCons:
+			rv.Set(reflect.ValueOf(astChildren(ast)))
+		default:
+			return u.mismatch(path, ast, rv)
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		elem := rv.Type().Elem()
+		if ast.Type == AstType_Nil && elem.Kind() != reflect.Struct {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		p := reflect.New(elem)
+		if err := u.decode(path, ast, p.Elem()); err != nil {
+			return err
+		}
+		rv.Set(p)
+		return nil
+
+	case reflect.Interface:
+		if t, ok := u.types[ast.ClassName]; ok {
+			if !t.AssignableTo(rv.Type()) {
+				return u.error(path, ast, "Type "+t.String()+" of the class is not assignable to "+rv.Type().String())
+			}
+			w := reflect.New(t).Elem()
+			if err := u.decode(path, ast, w); err != nil {
+				return err
+			}
+			rv.Set(w)
+			return nil
+		}
+		if rv.NumMethod() != 0 {
+			return u.error(path, ast, "No type is registered for the class "+strconv.Quote(ast.ClassName))
+		}
+		if v := astGenericValue(ast); v != nil {
+			rv.Set(reflect.ValueOf(v))
+		} else {
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return nil
+
+	case reflect.Struct:
+		return u.decodeStruct(path, ast, rv)
+
+	case reflect.Slice:
+		return u.decodeSlice(path, ast, rv)
+
+	default:
+		return u.decodeScalar(path, ast, rv)
+	}
+}
+
+// Decode the list AST into the slice.
+func (u *astUnmarshaller) decodeSlice(path string, ast Ast, rv reflect.Value) error {
+	var items AstSlice
+
+	switch ast.Type {
+	case AstType_Nil:
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	case AstType_ListOfAst, AstType_AstCons:
+		items = astChildren(ast)
+	case AstType_ListOfAny:
+		switch values := ast.Value.(type) {
+		case []interface{}:
+			items = make(AstSlice, 0, len(values))
+			for _, v := range values {
+				items = append(items, astFromAny(v, ast.SourcePosition))
+			}
+		case SliceLike:
+			length := values.Len()
+			items = make(AstSlice, 0, length)
+			for i := 0; i < length; i++ {
+				items = append(items, astFromAny(values.Get(i), ast.SourcePosition))
+			}
+		default:
+			return u.mismatch(path, ast, rv)
+		}
+	default:
+		return u.mismatch(path, ast, rv)
+	}
+
+	w := reflect.MakeSlice(rv.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := u.decode(path+"["+strconv.Itoa(i)+"]", item, w.Index(i)); err != nil {
+			return err
+		}
+	}
+	rv.Set(w)
+	return nil
+}
+
+// Get the value of the Int or Rune AST. It returns false if the value does not match the AST type.
+func intValueOf(ast Ast) (int64, bool) {
+	if ast.Type == AstType_Rune {
+		v, ok := ast.Value.(rune)
+		return int64(v), ok
+	}
+	v, ok := ast.Value.(int64)
+	return v, ok
+}
+
+// Decode the scalar AST into the value.
+func (u *astUnmarshaller) decodeScalar(path string, ast Ast, rv reflect.Value) error {
+	if ast.Type == AstType_Nil {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		switch ast.Type {
+		case AstType_String:
+			v, ok := ast.Value.(string)
+			if !ok {
+				return u.mismatch(path, ast, rv)
+			}
+			rv.SetString(v)
+		case AstType_Rune:
+			v, ok := ast.Value.(rune)
+			if !ok {
+				return u.mismatch(path, ast, rv)
+			}
+			rv.SetString(string(v))
+		default:
+			return u.mismatch(path, ast, rv)
+		}
+
+	case reflect.Bool:
+		v, ok := ast.Value.(bool)
+		if ast.Type != AstType_Bool || !ok {
+			return u.mismatch(path, ast, rv)
+		}
+		rv.SetBool(v)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var v int64
+		switch ast.Type {
+		case AstType_Int, AstType_Rune:
+			w, ok := intValueOf(ast)
+			if !ok {
+				return u.mismatch(path, ast, rv)
+			}
+			v = w
+		case AstType_Uint:
+			w, ok := ast.Value.(uint64)
+			if !ok {
+				return u.mismatch(path, ast, rv)
+			}
+			if math.MaxInt64 < w {
+				return u.error(path, ast, "Value "+strconv.FormatUint(w, 10)+" overflows "+rv.Type().String())
+			}
+			v = int64(w)
+		default:
+			return u.mismatch(path, ast, rv)
+		}
+		if rv.OverflowInt(v) {
+			return u.error(path, ast, "Value "+strconv.FormatInt(v, 10)+" overflows "+rv.Type().String())
+		}
+		rv.SetInt(v)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var v uint64
+		switch ast.Type {
+		case AstType_Uint:
+			w, ok := ast.Value.(uint64)
+			if !ok {
+				return u.mismatch(path, ast, rv)
+			}
+			v = w
+		case AstType_Int, AstType_Rune:
+			w, ok := intValueOf(ast)
+			if !ok {
+				return u.mismatch(path, ast, rv)
+			}
+			if w < 0 {
+				return u.error(path, ast, "Value "+strconv.FormatInt(w, 10)+" overflows "+rv.Type().String())
+			}
+			v = uint64(w)
+		default:
+			return u.mismatch(path, ast, rv)
+		}
+		if rv.OverflowUint(v) {
+			return u.error(path, ast, "Value "+strconv.FormatUint(v, 10)+" overflows "+rv.Type().String())
+		}
+		rv.SetUint(v)
+
+	case reflect.Float32, reflect.Float64:
+		var v float64
+		var ok bool
+		switch ast.Type {
+		case AstType_Float:
+			v, ok = ast.Value.(float64)
+		case AstType_Int:
+			var w int64
+			w, ok = ast.Value.(int64)
+			v = float64(w)
+		case AstType_Uint:
+			var w uint64
+			w, ok = ast.Value.(uint64)
+			v = float64(w)
+		}
+		if !ok {
+			return u.mismatch(path, ast, rv)
+		}
+		rv.SetFloat(v)
+
+	default:
+		return u.error(path, ast, "Unsupported Go type "+rv.Type().String())
+	}
+	return nil
+}
+
+// Find the child by the selector segment. (`Name` or `#n`)
+func (u *astUnmarshaller) child(ast Ast, seg string) (Ast, bool, error) {
+	children := astChildren(ast)
+	if strings.HasPrefix(seg, "#") {
+		n, err := strconv.Atoi(seg[1:])
+		if err != nil {
+			return Ast{}, false, errBadAstTag
+		}
+		if n < 0 {
+			n += len(children)
+		}
+		if n < 0 || len(children) <= n {
+			return Ast{}, false, nil
+		}
+		return children[n], true, nil
+	}
+	for _, child := range children {
+		if child.ClassName == seg {
+			return child, true, nil
+		}
+	}
+	return Ast{}, false, nil
+}
+
+// Make the error of the required child not found.
+func (u *astUnmarshaller) required(path string, ast Ast, seg string) error {
+	if strings.HasPrefix(seg, "#") {
+		return u.error(path, ast, "Child "+seg+" is required")
+	}
+	return u.error(path, ast, "Child of the class "+strconv.Quote(seg)+" is required")
+}
+
+// Decode the part of the AST selected by the last segment of the field tag into the field.
+func (u *astUnmarshaller) decodeField(path string, ast Ast, seg string, required bool, fv reflect.Value) error {
+	switch seg {
+	case "$class":
+		return u.decode(path, Ast{Type: AstType_String, Value: ast.ClassName, SourcePosition: ast.SourcePosition}, fv)
+
+	case "$op":
+		return u.decode(path, Ast{Type: AstType_Uint, Value: uint64(ast.OpCode), SourcePosition: ast.SourcePosition}, fv)
+
+	case "$type":
+		switch {
+		case fv.Type() == astTypeReflectType:
+			fv.Set(reflect.ValueOf(ast.Type))
+		case fv.Kind() == reflect.String:
+			fv.SetString(ast.Type.String())
+		default:
+			return u.error(path, ast, "Field of $type should be AstType or string")
+		}
+		return nil
+
+	case "$pos":
+		switch {
+		case fv.Type() == sourcePositionReflectType:
+			fv.Set(reflect.ValueOf(ast.SourcePosition))
+		case fv.Kind() == reflect.Int:
+			fv.SetInt(int64(ast.Position))
+		default:
+			return u.error(path, ast, "Field of $pos should be SourcePosition or int")
+		}
+		return nil
+
+	case "$value":
+		return u.decode(path, ast, fv)
+
+	case "*":
+		list := Ast{Type: AstType_ListOfAst, Value: astChildren(ast), SourcePosition: ast.SourcePosition}
+		return u.decode(path, list, fv)
+	}
+
+	if !strings.HasPrefix(seg, "#") && fv.Kind() == reflect.Slice {
+		matched := make(AstSlice, 0, 1)
+		for _, child := range astChildren(ast) {
+			if child.ClassName == seg {
+				matched = append(matched, child)
+			}
+		}
+		if len(matched) == 0 && required {
+			return u.required(path, ast, seg)
+		}
+		list := Ast{Type: AstType_ListOfAst, Value: matched, SourcePosition: ast.SourcePosition}
+		return u.decode(path, list, fv)
+	}
+
+	child, ok, err := u.child(ast, seg)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if required {
+			return u.required(path, ast, seg)
+		}
+		return nil
+	}
+	return u.decode(path, child, fv)
+}
+
+// Decode the AST into the struct by the field tags.
+func (u *astUnmarshaller) decodeStruct(path string, ast Ast, rv reflect.Value) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		fv := rv.Field(i)
+		tag, hasTag := f.Tag.Lookup("ast")
+
+		if f.Anonymous && !hasTag && f.Type.Kind() == reflect.Struct {
+			if err := u.decodeStruct(path, ast, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		sel := tag
+		required := false
+		if j := strings.IndexByte(tag, ','); 0 <= j {
+			sel = tag[:j]
+			for _, opt := range strings.Split(tag[j+1:], ",") {
+				if opt == "required" {
+					required = true
+				}
+			}
+		}
+		if sel == "" {
+			sel = f.Name
+		}
+		fpath := path + "." + f.Name
+
+		segs := strings.Split(sel, "/")
+		node, found := ast, true
+		for _, seg := range segs[:len(segs)-1] {
+			child, ok, err := u.child(node, seg)
+			if err != nil {
+				return errors.New("Bad ast tag of the field " + rt.String() + "." + f.Name + ": " + tag)
+			}
+			if !ok {
+				if required {
+					return u.required(fpath, node, seg)
+				}
+				found = false
+				break
+			}
+			node = child
+		}
+		if !found {
+			continue
+		}
+
+		if err := u.decodeField(fpath, node, segs[len(segs)-1], required, fv); err != nil {
+			if err == errBadAstTag {
+				return errors.New("Bad ast tag of the field " + rt.String() + "." + f.Name + ": " + tag)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode the AST into the Go value that v points to.
+//
+// The struct fields are mapped by the `ast` tags:
+//
+//	`ast:"Name"`      The first child of the class name. If the field is a slice, all the children of the class.
+//	                  If the tag is omitted, the field name is used as the class name.
+//	`ast:"#0"`        The child at the index. The negative index counts from the last.
+//	`ast:"*"`         All the children.
+//	`ast:"Name/#0"`   The selectors separated by `/` select the descendant. (e.g. `Args/*`, `Body/Block/$value`)
+//	`ast:"$class"`    ClassName of the AST.
+//	`ast:"$op"`       OpCode of the AST.
+//	`ast:"$type"`     Type of the AST. (AstType or string)
+//	`ast:"$pos"`      SourcePosition of the AST. (SourcePosition or int)
+//	`ast:"$value"`    Value of the AST.
+//	`ast:"-"`         Ignored.
+//	`ast:"...,required"`  An error is returned if the child is not found.
+//
+// The children are the items of ListOfAst, or the car and cdr of AstCons.
+// ListOfAst and ListOfAny are mapped to the slices, and the scalar types are mapped to the compatible kinds.
+// The fields of Ast and AstSlice types get the ASTs as is.
+// The type mismatch error is a *ParseError with the source position of the AST.
+func UnmarshalAst(ast Ast, v interface{}) error {
+	return UnmarshalAstWithOptions(ast, v, AstUnmarshalOptions{})
+}
+
+// Decode the ASTs into the Go value that v points to. The ASTs are treated as a ListOfAst.
+func UnmarshalAstSlice(asts AstSlice, v interface{}) error {
+	return UnmarshalAst(Ast{Type: AstType_ListOfAst, Value: asts}, v)
+}
+
+// Decode the AST into the Go value that v points to, with the options.
+// The interface values are decoded into the types of the class names in opts.Types.
+func UnmarshalAstWithOptions(ast Ast, v interface{}, opts AstUnmarshalOptions) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("Out parameter should be non-nil pointer")
+	}
+
+	u := &astUnmarshaller{
+		types: make(map[string]reflect.Type, len(opts.Types)),
+	}
+	for name, proto := range opts.Types {
+		u.types[name] = reflect.TypeOf(proto)
+	}
+	return u.decode(rv.Elem().Type().String(), ast, rv.Elem())
+}
@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type unmarshalTestExpr interface {
+	expr()
+}
+
+type unmarshalTestNumber struct {
+	Value float64 `ast:"$value"`
+	Pos   int     `ast:"$pos"`
+}
+
+func (unmarshalTestNumber) expr() {}
+
+type unmarshalTestIdent struct {
+	Name string `ast:"$value"`
+}
+
+func (*unmarshalTestIdent) expr() {}
+
+type unmarshalTestCall struct {
+	Class  string              `ast:"$class"`
+	Op     uint8               `ast:"$op"`
+	Callee unmarshalTestIdent  `ast:"#0,required"`
+	Args   []unmarshalTestExpr `ast:"Args/*"`
+	Flags  []bool              `ast:"Flags/$value"`
+	Last   Ast                 `ast:"#-1"`
+	Ident  *unmarshalTestIdent `ast:"Identifier"`
+	Rest   AstSlice            `ast:"*"`
+	Type   string              `ast:"$type"`
+	Any    interface{}         `ast:"Any"`
+	Skip   string              `ast:"-"`
+	Tag    string
+}
+
+func unmarshalTestCallAst() Ast {
+	return Ast{ClassName: "FunctionCall", OpCode: 3, Type: AstType_ListOfAst, Value: AstSlice{
+		{ClassName: "Identifier", Type: AstType_String, Value: "print"},
+		{ClassName: "Args", Type: AstType_ListOfAst, Value: AstSlice{
+			{ClassName: "Number", Type: AstType_Int, Value: int64(1), SourcePosition: SourcePosition{Position: 6}},
+			{ClassName: "Ident", Type: AstType_String, Value: "x"},
+		}},
+		{ClassName: "Flags", Type: AstType_ListOfAny, Value: []interface{}{true, false}},
+		{ClassName: "Any", Type: AstType_ListOfAst, Value: AstSlice{
+			{Type: AstType_Int, Value: int64(1)},
+			{Type: AstType_String, Value: "a"},
+		}},
+		{ClassName: "Tag", Type: AstType_Rune, Value: 't'},
+	}}
+}
+
+func TestUnmarshalAst(t *testing.T) {
+	ast := unmarshalTestCallAst()
+	children := ast.Value.(AstSlice)
+
+	var got unmarshalTestCall
+	err := UnmarshalAstWithOptions(ast, &got, AstUnmarshalOptions{
+		Types: map[string]interface{}{
+			"Number": unmarshalTestNumber{},
+			"Ident":  &unmarshalTestIdent{},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UnmarshalAst() error = %v", err)
+	}
+
+	want := unmarshalTestCall{
+		Class:  "FunctionCall",
+		Op:     3,
+		Callee: unmarshalTestIdent{Name: "print"},
+		Args:   []unmarshalTestExpr{unmarshalTestNumber{Value: 1, Pos: 6}, &unmarshalTestIdent{Name: "x"}},
+		Flags:  []bool{true, false},
+		Last:   children[4],
+		Ident:  &unmarshalTestIdent{Name: "print"},
+		Rest:   children,
+		Type:   "ListOfAst",
+		Any:    []interface{}{int64(1), "a"},
+		Tag:    "t",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalAst() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalAstSlice(t *testing.T) {
+	asts := AstSlice{
+		{Type: AstType_Int, Value: int64(1)},
+		{Type: AstType_Uint, Value: uint64(2)},
+		{Type: AstType_Rune, Value: 'a'},
+		{Type: AstType_Nil},
+	}
+	var got []int16
+	if err := UnmarshalAstSlice(asts, &got); err != nil {
+		t.Fatalf("UnmarshalAstSlice() error = %v", err)
+	}
+	if want := []int16{1, 2, 97, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalAstSlice() = %v, want %v", got, want)
+	}
+}
+
+func TestUnmarshalAstSliceLike(t *testing.T) {
+	var ints []int64
+	if err := UnmarshalAst(Ast{Type: AstType_ListOfAny, Value: binaryTestIntSlice{1, 2, 3}}, &ints); err != nil {
+		t.Fatalf("UnmarshalAst() error = %v", err)
+	}
+	if want := []int64{1, 2, 3}; !reflect.DeepEqual(ints, want) {
+		t.Errorf("UnmarshalAst() = %v, want %v", ints, want)
+	}
+
+	var nested [][]int
+	ast := Ast{Type: AstType_ListOfAny, Value: []interface{}{binaryTestIntSlice{4}, []interface{}{int32(5)}}}
+	if err := UnmarshalAst(ast, &nested); err != nil {
+		t.Fatalf("UnmarshalAst() error = %v", err)
+	}
+	if want := [][]int{{4}, {5}}; !reflect.DeepEqual(nested, want) {
+		t.Errorf("UnmarshalAst() = %v, want %v", nested, want)
+	}
+
+	// int32 in ListOfAny is Int, not Rune.
+	var strs []string
+	err := UnmarshalAst(Ast{Type: AstType_ListOfAny, Value: []interface{}{int32(97)}}, &strs)
+	if err == nil || !strings.HasSuffix(err.Error(), "Cannot unmarshal Int into Go value of type string") {
+		t.Errorf("UnmarshalAst() error = %v", err)
+	}
+}
+
+func TestUnmarshalAstError(t *testing.T) {
+	pos := SourcePosition{Position: 10, Length: 3}
+	tests := []struct {
+		name string
+		ast  Ast
+		v    interface{}
+		msg  string
+	}{{
+		name: "type mismatch",
+		ast: Ast{ClassName: "List", Type: AstType_ListOfAst, Value: AstSlice{
+			{ClassName: "Number", Type: AstType_String, Value: "x", SourcePosition: pos},
+		}},
+		v:   &struct{ Number []int }{},
+		msg: "struct { Number []int }.Number[0]: Cannot unmarshal String into Go value of type int",
+	}, {
+		name: "overflow",
+		ast:  Ast{ClassName: "Number", Type: AstType_Int, Value: int64(300), SourcePosition: pos},
+		v:    new(uint8),
+		msg:  "uint8: Value 300 overflows uint8",
+	}, {
+		name: "negative to unsigned",
+		ast:  Ast{ClassName: "Number", Type: AstType_Int, Value: int64(-1), SourcePosition: pos},
+		v:    new(uint),
+		msg:  "uint: Value -1 overflows uint",
+	}, {
+		name: "mismatched int value",
+		ast:  Ast{ClassName: "Number", Type: AstType_Int, Value: 1, SourcePosition: pos},
+		v:    new(int64),
+		msg:  "int64: Cannot unmarshal Int into Go value of type int64",
+	}, {
+		name: "mismatched string value",
+		ast:  Ast{ClassName: "Name", Type: AstType_String, Value: []byte("x"), SourcePosition: pos},
+		v:    new(string),
+		msg:  "string: Cannot unmarshal String into Go value of type string",
+	}, {
+		name: "mismatched float value",
+		ast:  Ast{ClassName: "Number", Type: AstType_Uint, Value: int64(1), SourcePosition: pos},
+		v:    new(float64),
+		msg:  "float64: Cannot unmarshal Uint into Go value of type float64",
+	}, {
+		name: "bad list of any",
+		ast:  Ast{ClassName: "List", Type: AstType_ListOfAny, Value: 1, SourcePosition: pos},
+		v:    new([]int),
+		msg:  "[]int: Cannot unmarshal ListOfAny into Go value of type []int",
+	}, {
+		name: "required",
+		ast:  Ast{ClassName: "Call", Type: AstType_ListOfAst, Value: AstSlice{}, SourcePosition: pos},
+		v: &struct {
+			Name string `ast:"Identifier,required"`
+		}{},
+		msg: `.Name: Child of the class "Identifier" is required`,
+	}, {
+		name: "unregistered class",
+		ast:  Ast{ClassName: "Number", Type: AstType_Int, Value: int64(1), SourcePosition: pos},
+		v:    new(unmarshalTestExpr),
+		msg:  `parser.unmarshalTestExpr: No type is registered for the class "Number"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := UnmarshalAst(tt.ast, tt.v)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("UnmarshalAst() error = %v", err)
+			}
+			if perr.SourcePosition != pos || perr.ClassName == "" {
+				t.Errorf("UnmarshalAst() error position = %v, class = %v", perr.SourcePosition, perr.ClassName)
+			}
+			if !strings.HasSuffix(err.Error(), tt.msg) {
+				t.Errorf("UnmarshalAst() error = %v, want %v", err, tt.msg)
+			}
+		})
+	}
+
+	if err := UnmarshalAst(Ast{}, unmarshalTestCall{}); err == nil {
+		t.Errorf("UnmarshalAst() non-pointer error = nil")
+	}
+}
+
+func TestUnmarshalAstBadTag(t *testing.T) {
+	var v struct {
+		Name string `ast:"#x"`
+	}
+	err := UnmarshalAst(Ast{Type: AstType_ListOfAst, Value: AstSlice{}}, &v)
+	if err == nil || !strings.HasPrefix(err.Error(), "Bad ast tag of the field") {
+		t.Errorf("UnmarshalAst() error = %v", err)
+	}
+}
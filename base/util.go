@@ -4,72 +4,108 @@ import (
 	"strings"
 )
 
-func GetLineAndColPosition(src string, pos SourcePosition, tabSize int) LineAndColPosition {
-	prevLineIndex := 0
-	lineIndex := 0
-	line := 1
-	col := 1
-	nTabs := 0
+// Get the length of the line break at the index. It is 0 if there is no line break.
+// The line breaks are CR LF, CR, LF, VT, FF and NEL, the same as LineBreak() of the string package.
+func lineBreakLength(src string, i int) int {
+	switch src[i] {
+	case '\r':
+		if i+1 < len(src) && src[i+1] == '\n' {
+			return 2
+		}
+		return 1
+	case '\n', '\v', '\f':
+		return 1
+	case 0xc2:
+		// NEL (U+0085)
+		if i+1 < len(src) && src[i+1] == 0x85 {
+			return 2
+		}
+	}
+	return 0
+}
+
+// Scan the line breaks in src[0:end]. The line break that is not complete at the end is not counted.
+// It returns the line number, the start index of the line, the start index of the previous line,
+// and the line break before the line.
+func scanLineBreaks(src string, end int) (line, lineIndex, prevLineIndex int, lineBreak string) {
+	line = 1
 
-	for i := 0; i < pos.Position; i++ {
-		switch src[i] {
-		case '\r':
-			line++
-			if i+1 < pos.Position && src[i+1] == '\n' {
-				i++
-			}
-			prevLineIndex = lineIndex
-			lineIndex = i + 1
-			nTabs = 0
-			col = 1
-		case '\n':
-			line++
-			prevLineIndex = lineIndex
-			lineIndex = i + 1
-			nTabs = 0
-			col = 1
-		case '\t':
-			nTabs++
-			col++
-		default:
-			col++
+	for i := 0; i < end; {
+		n := lineBreakLength(src, i)
+		if n == 0 {
+			i++
+			continue
+		}
+		if end < i+n {
+			break
 		}
+		line++
+		prevLineIndex = lineIndex
+		lineBreak = src[i : i+n]
+		i += n
+		lineIndex = i
 	}
+	return
+}
 
-	srcLen := len(src)
-	endIndex := pos.Position
-OUTER:
-	for i := pos.Position; i < srcLen; i++ {
-		switch src[i] {
-		case '\r', '\n':
-			break OUTER
-		default:
-			endIndex++
+// Format the source lines and the guide of the error.
+// The previous line is shown if lineBreak is not empty.
+// The line breaks other than CR LF, CR and LF are shown as LF.
+func formatErrSource(prevLine, lineBreak, curLine, beforeErr string, tabSize int) string {
+	tab := strings.Repeat(" ", tabSize)
+	lineEnd := "\n"
+	errSource := ""
+	if lineBreak != "" {
+		if lineBreak == "\r" {
+			lineEnd = "\r"
+		} else if lineBreak != "\r\n" {
+			lineBreak = "\n"
 		}
+		errSource = "   | " + strings.ReplaceAll(prevLine, "\t", tab) + lineBreak
 	}
+	errSource += " > | " + strings.ReplaceAll(curLine, "\t", tab) + lineEnd
+	errSource += "   | " + strings.Repeat(" ", StringWidth(beforeErr, tabSize)) + "^^^^"
+	return errSource
+}
 
-	errGuideCol := col - 1 + nTabs*(tabSize-1)
-	if errGuideCol < 0 {
-		errGuideCol = 0
+// Get the line and column position of the source position.
+// The columns are counted by bytes.
+func GetLineAndColPosition(src string, pos SourcePosition, tabSize int) LineAndColPosition {
+	return GetLineAndColPositionWithMode(src, pos, tabSize, ColumnMode_Byte)
+}
+
+// Get the line and column position of the source position.
+// The columns are counted by the mode. The error guide is always aligned by the display width.
+func GetLineAndColPositionWithMode(src string, pos SourcePosition, tabSize int, mode ColumnMode) LineAndColPosition {
+	line, lineIndex, prevLineIndex, lineBreak := scanLineBreaks(src, pos.Position)
+	col := measureColumns(src[lineIndex:pos.Position], mode, tabSize) + 1
+
+	endPosition := pos.Position + pos.Length
+	if len(src) < endPosition {
+		endPosition = len(src)
 	}
+	endLine, endLineIndex, _, _ := scanLineBreaks(src, endPosition)
+	endCol := measureColumns(src[endLineIndex:endPosition], mode, tabSize) + 1
 
-	errSource := src[prevLineIndex:endIndex]
-	errSource = strings.ReplaceAll(errSource, "\t", strings.Repeat(" ", tabSize))
+	endIndex := lineIndex
+	for endIndex < len(src) && lineBreakLength(src, endIndex) == 0 {
+		endIndex++
+	}
 
-	if strings.Contains(errSource, "\n") {
-		errSource = "   | " + strings.Replace(errSource, "\n", "\n > | ", 1) + "\n"
-	} else if strings.Contains(errSource, "\r") {
-		errSource = "   | " + strings.Replace(errSource, "\r", "\r > | ", 1) + "\r"
-	} else {
-		errSource = " > | " + errSource + "\n"
+	prevLine := ""
+	if 1 < line {
+		prevLine = src[prevLineIndex : lineIndex-len(lineBreak)]
 	}
-	errSource += "   | " + strings.Repeat(" ", errGuideCol) + "^^^^"
+	errSource := formatErrSource(prevLine, lineBreak, src[lineIndex:endIndex], src[lineIndex:pos.Position], tabSize)
 
 	return LineAndColPosition{
-		LineIndex: lineIndex,
-		Line:      line,
-		Col:       col,
-		Position:  pos.Position,
-		ErrSource: errSource,
+		LineIndex:   lineIndex,
+		Line:        line,
+		Col:         col,
+		EndLine:     endLine,
+		EndCol:      endCol,
+		Position:    pos.Position,
+		EndPosition: endPosition,
+		ErrSource:   errSource,
 	}
 }
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetLineAndColPositionWithMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		pos     SourcePosition
+		mode    ColumnMode
+		line    int
+		col     int
+		endLine int
+		endCol  int
+	}{
+		{name: "ascii byte", src: "abc def", pos: SourcePosition{Position: 4, Length: 3}, mode: ColumnMode_Byte, line: 1, col: 5, endLine: 1, endCol: 8},
+		{name: "japanese byte", src: "あいう x", pos: SourcePosition{Position: 10, Length: 1}, mode: ColumnMode_Byte, line: 1, col: 11, endLine: 1, endCol: 12},
+		{name: "japanese rune", src: "あいう x", pos: SourcePosition{Position: 10, Length: 1}, mode: ColumnMode_Rune, line: 1, col: 5, endLine: 1, endCol: 6},
+		{name: "japanese utf16", src: "あいう x", pos: SourcePosition{Position: 10, Length: 1}, mode: ColumnMode_Utf16, line: 1, col: 5, endLine: 1, endCol: 6},
+		{name: "japanese width", src: "あいう x", pos: SourcePosition{Position: 10, Length: 1}, mode: ColumnMode_DisplayWidth, line: 1, col: 8, endLine: 1, endCol: 9},
+		{name: "astral rune", src: "𠮷x", pos: SourcePosition{Position: 4, Length: 1}, mode: ColumnMode_Rune, line: 1, col: 2, endLine: 1, endCol: 3},
+		{name: "astral utf16", src: "𠮷x", pos: SourcePosition{Position: 4, Length: 1}, mode: ColumnMode_Utf16, line: 1, col: 3, endLine: 1, endCol: 4},
+		{name: "combining rune", src: "éx", pos: SourcePosition{Position: 3, Length: 1}, mode: ColumnMode_Rune, line: 1, col: 3, endLine: 1, endCol: 4},
+		{name: "combining grapheme", src: "éx", pos: SourcePosition{Position: 3, Length: 1}, mode: ColumnMode_Grapheme, line: 1, col: 2, endLine: 1, endCol: 3},
+		{name: "combining width", src: "éx", pos: SourcePosition{Position: 3, Length: 1}, mode: ColumnMode_DisplayWidth, line: 1, col: 2, endLine: 1, endCol: 3},
+		{name: "zwj grapheme", src: "👨‍👩‍👧x", pos: SourcePosition{Position: 18, Length: 1}, mode: ColumnMode_Grapheme, line: 1, col: 2, endLine: 1, endCol: 3},
+		{name: "zwj width", src: "👨‍👩‍👧x", pos: SourcePosition{Position: 18, Length: 1}, mode: ColumnMode_DisplayWidth, line: 1, col: 3, endLine: 1, endCol: 4},
+		{name: "flag grapheme", src: "🇯🇵🇺🇸x", pos: SourcePosition{Position: 16, Length: 1}, mode: ColumnMode_Grapheme, line: 1, col: 3, endLine: 1, endCol: 4},
+		{name: "hangul jamo grapheme", src: "각x", pos: SourcePosition{Position: 9, Length: 1}, mode: ColumnMode_Grapheme, line: 1, col: 2, endLine: 1, endCol: 3},
+		{name: "tab width", src: "\tx", pos: SourcePosition{Position: 1, Length: 1}, mode: ColumnMode_DisplayWidth, line: 1, col: 5, endLine: 1, endCol: 6},
+		{name: "second line", src: "abc\r\nあい", pos: SourcePosition{Position: 8, Length: 3}, mode: ColumnMode_Rune, line: 2, col: 2, endLine: 2, endCol: 3},
+		{name: "multiline span", src: "ab\ncd\nef", pos: SourcePosition{Position: 1, Length: 5}, mode: ColumnMode_Byte, line: 1, col: 2, endLine: 3, endCol: 1},
+		{name: "span past the end", src: "abc", pos: SourcePosition{Position: 1, Length: 10}, mode: ColumnMode_Byte, line: 1, col: 2, endLine: 1, endCol: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetLineAndColPositionWithMode(tt.src, tt.pos, 4, tt.mode)
+			if got.Line != tt.line || got.Col != tt.col || got.EndLine != tt.endLine || got.EndCol != tt.endCol {
+				t.Errorf("%v: got %d:%d-%d:%d, want %d:%d-%d:%d",
+					tt.name, got.Line, got.Col, got.EndLine, got.EndCol, tt.line, tt.col, tt.endLine, tt.endCol)
+			}
+		})
+	}
+}
+
+func TestErrSourceGuideAlignment(t *testing.T) {
+	src := "あいう\tx"
+	got := GetLineAndColPosition(src, SourcePosition{Position: 10, Length: 1}, 4)
+
+	want := " > | あいう    x\n" +
+		"   | " + strings.Repeat(" ", 10) + "^^^^"
+	if got.ErrSource != want {
+		t.Errorf("ErrSource = %q, want %q", got.ErrSource, want)
+	}
+	if got.Col != 11 {
+		t.Errorf("Col = %d, want 11", got.Col)
+	}
+}
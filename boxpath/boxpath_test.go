@@ -0,0 +1,321 @@
+package boxpath
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{"1", "", "$", false},
+		{"2", "$", "$", false},
+		{"3", "a.b[3][\"k\"]", "$.a.b[3].k", false},
+		{"4", "$.a.b[-1]", "$.a.b[-1]", false},
+		{"5", "a['k k'][\"x\\\"y\"]", "$.a[\"k k\"][\"x\\\"y\"]", false},
+		{"6", " $.content-type[ 0 ] ", "$.content-type[0]", false},
+		{"7", "a.", "", true},
+		{"8", "a[b]", "", true},
+		{"9", "a[0", "", true},
+		{"10", "a[\"k]", "", true},
+		{"11", "a b", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("Parse() = %v, want %v", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	_, err := Parse("a.b[x]")
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Parse() error = %v, want *ParseError", err)
+	}
+	if perr.Line != 1 || perr.Col != 5 {
+		t.Errorf("Parse() error at %d:%d, want 1:5", perr.Line, perr.Col)
+	}
+}
+
+type testConfig struct {
+	Name    string `json:"name"`
+	Port    int64
+	Ratio   float64 `json:"ratio,omitempty"`
+	Secret  string  `json:"-"`
+	Tags    []string
+	Servers []testServer `json:"servers"`
+	Env     map[string]interface{}
+	Sub     *testConfig `json:"sub"`
+	Extra   interface{}
+	hidden  string
+}
+
+type testServer struct {
+	Host string `json:"host"`
+}
+
+func TestResolveStruct(t *testing.T) {
+	cfg := &testConfig{
+		Name:    "app",
+		Port:    80,
+		Secret:  "s",
+		Tags:    []string{"a", "b", "c"},
+		Servers: []testServer{{Host: "h0"}, {Host: "h1"}},
+		hidden:  "x",
+	}
+
+	tests := []struct {
+		name string
+		path string
+		set  interface{}
+		want interface{}
+	}{
+		{"1", "name", "app2", "app2"},
+		{"2", "$.Port", int64(8080), int64(8080)},
+		{"3", "ratio", 0.5, 0.5},
+		{"4", "Tags[1]", "x", "x"},
+		{"5", "Tags[-1]", "z", "z"},
+		{"6", "servers[1].host", "h9", "h9"},
+		{"7", "sub.sub.name", "deep", "deep"},
+		{"8", "Extra", 42, 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box, err := Resolve(cfg, tt.path)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			box.SetAny(tt.set)
+			if got := box.GetAny(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAny() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if cfg.Name != "app2" || cfg.Port != 8080 || cfg.Tags[1] != "x" || cfg.Tags[2] != "z" ||
+		cfg.Servers[1].Host != "h9" || cfg.Sub.Sub.Name != "deep" || cfg.Extra != 42 {
+		t.Errorf("Resolve() did not update the value: %+v", cfg)
+	}
+}
+
+func TestResolveTypedBox(t *testing.T) {
+	cfg := &testConfig{}
+	tests := []struct {
+		name string
+		path string
+		want Box
+	}{
+		{"1", "name", StringBox{}},
+		{"2", "Port", Int64Box{}},
+		{"3", "ratio", Float64Box{}},
+		{"4", "Extra", AnyBox{}},
+		{"5", "Env", MapBox{}},
+		{"6", "Tags", ReflectionBox{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box, err := Resolve(cfg, tt.path)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if reflect.TypeOf(box) != reflect.TypeOf(tt.want) {
+				t.Errorf("Resolve() = %T, want %T", box, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveMap(t *testing.T) {
+	cfg := &testConfig{}
+
+	box, err := Resolve(cfg, `Env.db["read only"].host`)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if _, ok := box.(*NotInitializedMapContainerReflectionBox); !ok {
+		t.Fatalf("Resolve() = %T, want *NotInitializedMapContainerReflectionBox", box)
+	}
+	box.SetString("localhost")
+
+	want := map[string]interface{}{
+		"db": map[string]interface{}{
+			"read only": map[string]interface{}{"host": "localhost"},
+		},
+	}
+	if !reflect.DeepEqual(cfg.Env, want) {
+		t.Errorf("Env = %v, want %v", cfg.Env, want)
+	}
+
+	box, err = Resolve(cfg, `Env.db["read only"].host`)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if _, ok := box.(MapContainerReflectionBox); !ok {
+		t.Fatalf("Resolve() = %T, want MapContainerReflectionBox", box)
+	}
+	if got := box.GetAny(); got != "localhost" {
+		t.Errorf("GetAny() = %v, want localhost", got)
+	}
+
+	box, err = Resolve(cfg, `Extra.a.b`)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	box.SetInt(1)
+	if got := cfg.Extra.(map[string]interface{})["a"].(map[string]interface{})["b"]; got != int64(1) {
+		t.Errorf("Extra.a.b = %v, want 1", got)
+	}
+
+	ints := map[int]string{}
+	box, err = Resolve(ints, `[3]`)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	box.SetAny("three")
+	if ints[3] != "three" {
+		t.Errorf("ints[3] = %v, want three", ints[3])
+	}
+}
+
+func TestResolveError(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		path    string
+		opts    Options
+		wantErr error
+		wantMsg string
+	}{
+		{"1", &testConfig{}, "Missing", Options{}, ErrNotFound,
+			"$.Missing: Field or key is not found (boxpath.testConfig)"},
+		{"2", &testConfig{}, "Secret", Options{}, ErrNotFound, ""},
+		{"3", &testConfig{}, "hidden", Options{}, ErrNotFound, ""},
+		{"4", &testConfig{Tags: []string{"a"}}, "Tags[1]", Options{}, ErrIndexOutOfRange,
+			"$.Tags[1]: Index out of range ([]string)"},
+		{"5", &testConfig{Tags: []string{"a"}}, "Tags[-2]", Options{}, ErrIndexOutOfRange, ""},
+		{"6", &testConfig{}, "[0]", Options{}, ErrTypeMismatch, ""},
+		{"7", &testConfig{Tags: []string{"a"}}, "Tags.x", Options{}, ErrTypeMismatch, ""},
+		{"8", &testConfig{}, "Port.x", Options{}, ErrTypeMismatch, ""},
+		{"9", &testConfig{}, "Env[0]", Options{}, ErrBadKey, ""},
+		{"10", &testConfig{}, "Extra[0]", Options{}, ErrNilValue, ""},
+		{"11", &testConfig{}, "sub.name", Options{NoInit: true}, ErrNilValue,
+			"$.sub.name: Nil value is referenced (*boxpath.testConfig)"},
+		{"12", &testConfig{}, "Env.a", Options{NoInit: true}, ErrNilValue, ""},
+		{"13", &testConfig{Env: map[string]interface{}{}}, "Env.a.b", Options{NoInit: true}, ErrNotFound, ""},
+		{"14", &testConfig{Env: map[string]interface{}{}}, "Env.a[0]", Options{}, ErrNotFound, ""},
+		{"15", testConfig{}, "sub.name", Options{}, ErrNilValue, ""},
+		{"16", nil, "a", Options{}, ErrNilValue, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ResolveWithOptions(tt.v, tt.path, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			var serr *SegmentError
+			if !errors.As(err, &serr) {
+				t.Fatalf("Resolve() error = %T, want *SegmentError", err)
+			}
+			if tt.wantMsg != "" && err.Error() != tt.wantMsg {
+				t.Errorf("Resolve() error = %v, want %v", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestResolveTagName(t *testing.T) {
+	type yamlConfig struct {
+		Name string `yaml:"title" json:"name"`
+	}
+	cfg := &yamlConfig{}
+
+	box, err := ResolveWithOptions(cfg, "title", Options{TagName: "yaml"})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	box.SetString("x")
+	if cfg.Name != "x" {
+		t.Errorf("Name = %v, want x", cfg.Name)
+	}
+
+	if _, err := ResolveWithOptions(cfg, "name", Options{TagName: "yaml"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestResolveMapElement(t *testing.T) {
+	type point struct {
+		X   int64
+		Sub *point
+		Env map[string]interface{}
+	}
+
+	m := map[string]point{"a": {X: 1}}
+	box, err := Resolve(&m, "a.X")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got := box.GetInt(); got != 1 {
+		t.Errorf("GetInt() = %v, want 1", got)
+	}
+	box.SetInt(2)
+	if m["a"].X != 2 {
+		t.Errorf("m.a.X = %v, want 2", m["a"].X)
+	}
+
+	// The nil values initialized in the copy are stored to the map.
+	box, err = Resolve(m, "a.Sub.Env.k")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if m["a"].Sub == nil || m["a"].Sub.Env == nil {
+		t.Fatalf("m.a.Sub.Env is not initialized: %+v", m["a"])
+	}
+	box.SetString("v")
+	if got := m["a"].Sub.Env["k"]; got != "v" {
+		t.Errorf("m.a.Sub.Env.k = %v, want v", got)
+	}
+
+	arrays := map[string]interface{}{"a": [2]point{}, "b": []point{{}}}
+	box, err = Resolve(arrays, "a[1].X")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	box.SetInt(3)
+	box, err = Resolve(arrays, "b[0].X")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	box.SetInt(4)
+	if arrays["a"].([2]point)[1].X != 3 || arrays["b"].([]point)[0].X != 4 {
+		t.Errorf("arrays = %+v", arrays)
+	}
+
+	nested := map[string]map[string]point{"a": {"b": {}}}
+	box, err = Resolve(nested, "a.b.X")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	box.SetAny(int64(5))
+	if nested["a"]["b"].X != 5 {
+		t.Errorf("nested.a.b.X = %v, want 5", nested["a"]["b"].X)
+	}
+}
@@ -0,0 +1,10 @@
+package classes
+
+const (
+	// `.name` and the first name without `.`
+	Name = ":boxpath:Name"
+	// `["name"]` and `['name']`
+	Key = ":boxpath:Key"
+	// `[3]` and `[-1]`
+	Index = ":boxpath:Index"
+)
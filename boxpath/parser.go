@@ -0,0 +1,106 @@
+package boxpath
+
+import (
+	"strconv"
+
+	. "github.com/shellyln/takenoco/base"
+	clsz "github.com/shellyln/takenoco/boxpath/classes"
+	. "github.com/shellyln/takenoco/string"
+)
+
+var (
+	pathParser ParserFn
+)
+
+func init() {
+	pathParser = pathRule()
+}
+
+// Name (e.g. `foo_bar`, `content-type`)
+func nameRule() ParserFn {
+	return Trans(
+		FlatGroup(
+			First(Alpha(), Seq("_")),
+			ZeroOrMoreTimes(First(Alnum(), CharClass("_", "-"))),
+		),
+		Token(clsz.Name),
+	)
+}
+
+// `[3]`, `[-1]`, `["key"]` or `['key']`
+func bracketRule() ParserFn {
+	return FlatGroup(
+		Erased(Seq("[")),
+		Spaces(),
+		First(
+			Trans(FlatGroup(ZeroOrOnce(Seq("-")), OneOrMoreTimes(Number())), Token(clsz.Index)),
+			Trans(First(Quoted("\""), Quoted("'")), Token(clsz.Key)),
+			Error("An index or a quoted key is expected"),
+		),
+		Spaces(),
+		First(Erased(Seq("]")), Error("']' is expected")),
+	)
+}
+
+func pathRule() ParserFn {
+	return FlatGroup(
+		Start(),
+		Spaces(),
+		ZeroOrOnce(Erased(Seq("$"))),
+		ZeroOrOnce(nameRule()),
+		ZeroOrMoreTimes(First(
+			FlatGroup(
+				Erased(Seq(".")),
+				First(nameRule(), Error("A name is expected after '.'")),
+			),
+			bracketRule(),
+		)),
+		Spaces(),
+		First(End(), Error("Unexpected character")),
+	)
+}
+
+// Parse the path expression. (e.g. `a.b[3]["k"]`, `$.a.b[-1]`)
+// The empty path and `$` refer to the root.
+func Parse(s string) (Path, error) {
+	out, err := pathParser(*NewStringParserContext(s))
+	if err != nil {
+		return nil, ToParseError(out.SourcePosition, "", err).Locate(s, 4)
+	}
+	if out.MatchStatus != MatchStatus_Matched {
+		return nil, NewParseError(out.SourcePosition, out.ClassName, "Parse failed").Locate(s, 4)
+	}
+
+	path := make(Path, 0, len(out.AstStack))
+	for _, ast := range out.AstStack {
+		seg := Segment{SourcePosition: ast.SourcePosition}
+		text := ast.Value.(string)
+
+		switch ast.ClassName {
+		case clsz.Name:
+			seg.Type = SegmentType_Name
+			seg.Name = text
+		case clsz.Key:
+			seg.Type = SegmentType_Name
+			if seg.Name, err = UnquoteString(text); err != nil {
+				return nil, NewParseError(ast.SourcePosition, ast.ClassName, "Bad quoted key").Locate(s, 4)
+			}
+		case clsz.Index:
+			seg.Type = SegmentType_Index
+			if seg.Index, err = strconv.Atoi(text); err != nil {
+				return nil, NewParseError(ast.SourcePosition, ast.ClassName, "Bad index").Locate(s, 4)
+			}
+		}
+		path = append(path, seg)
+	}
+	return path, nil
+}
+
+// Parse the path expression. It panics if the expression is invalid.
+func MustParse(s string) Path {
+	path, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
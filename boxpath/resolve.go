@@ -0,0 +1,399 @@
+package boxpath
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Type of the path segment
+type SegmentType int
+
+const (
+	// Struct field or map key. (`.name`, `["name"]`)
+	SegmentType_Name SegmentType = iota
+	// Slice, array or string element, or integer map key. (`[3]`, `[-1]`)
+	SegmentType_Index
+)
+
+// Path segment
+type Segment struct {
+	Type SegmentType
+	Name string
+	// Negative index counts from the end.
+	Index int
+	SourcePosition
+}
+
+// Test whether the name can be written as `.name`.
+func isPlainName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i != 0 && (c == '-' || '0' <= c && c <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Format the segment as `.name`, `["name"]` or `[3]`.
+func (s Segment) String() string {
+	if s.Type == SegmentType_Index {
+		return "[" + strconv.Itoa(s.Index) + "]"
+	}
+	if isPlainName(s.Name) {
+		return "." + s.Name
+	}
+	return "[" + strconv.Quote(s.Name) + "]"
+}
+
+// Parsed path expression
+type Path []Segment
+
+// Format the path as the JSONPath. (e.g. `$.a.b[3]["k k"]`)
+func (p Path) String() string {
+	var sb strings.Builder
+	sb.WriteString("$")
+	for _, seg := range p {
+		sb.WriteString(seg.String())
+	}
+	return sb.String()
+}
+
+var (
+	// Struct field or map key is not found.
+	ErrNotFound = errors.New("Field or key is not found")
+	// Index is out of range.
+	ErrIndexOutOfRange = errors.New("Index out of range")
+	// Segment cannot be applied to the type. (e.g. index of struct)
+	ErrTypeMismatch = errors.New("Segment cannot be applied to the type")
+	// Nil value cannot be initialized.
+	ErrNilValue = errors.New("Nil value is referenced")
+	// Segment cannot be converted to the key type of the map.
+	ErrBadKey = errors.New("Segment cannot be converted to the key type")
+)
+
+// Error of the invalid segment. It wraps one of the Err* errors.
+type SegmentError struct {
+	// Path to the invalid segment
+	Path Path
+	// Type of the value that the segment is applied to. It is nil for the nil interface.
+	Type reflect.Type
+	Err  error
+}
+
+// Implements error.
+func (e *SegmentError) Error() string {
+	typeName := "nil"
+	if e.Type != nil {
+		typeName = e.Type.String()
+	}
+	return e.Path.String() + ": " + e.Err.Error() + " (" + typeName + ")"
+}
+
+// Returns the wrapped error.
+func (e *SegmentError) Unwrap() error {
+	return e.Err
+}
+
+// Options of Resolve().
+type Options struct {
+	// Tag name of the struct fields. Default is "json".
+	// The fields are found by the tag names first, and by the field names next.
+	TagName string
+	// If true, the nil maps, pointers and interfaces are not initialized, and ErrNilValue is returned.
+	NoInit bool
+}
+
+// Map entry that holds the addressable copy of the element.
+type writeBack struct {
+	container reflect.Value
+	key       reflect.Value
+	value     reflect.Value
+}
+
+// Resolver state
+type resolver struct {
+	opts       Options
+	path       Path
+	writeBacks []writeBack
+	// True if the resolver initialized some values.
+	changed bool
+}
+
+// Store the copies of the map elements to the maps, from the innermost.
+func flushWriteBacks(writeBacks []writeBack) {
+	for i := len(writeBacks) - 1; 0 <= i; i-- {
+		w := writeBacks[i]
+		w.container.SetMapIndex(w.key, w.value)
+	}
+}
+
+// Make the addressable copy of the map element if it is a struct or an array.
+// The copy is stored to the map when the value is set through the box.
+func (r *resolver) addressable(container, key, elem reflect.Value) reflect.Value {
+	v := elem
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct && v.Kind() != reflect.Array {
+		return elem
+	}
+	w := reflect.New(v.Type()).Elem()
+	w.Set(v)
+	r.writeBacks = append(r.writeBacks, writeBack{container: container, key: key, value: w})
+	return w
+}
+
+// Make the error of the i-th segment.
+func (r *resolver) error(i int, v reflect.Value, err error) error {
+	var t reflect.Type
+	if v.IsValid() {
+		t = v.Type()
+	}
+	return &SegmentError{Path: r.path[:i+1], Type: t, Err: err}
+}
+
+// Find the struct field by the tag name or the field name.
+func (r *resolver) field(v reflect.Value, name string) (reflect.Value, bool) {
+	fields := reflect.VisibleFields(v.Type())
+	for _, f := range fields {
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get(r.opts.TagName)
+		if i := strings.Index(tag, ","); i >= 0 {
+			tag = tag[:i]
+		}
+		if tag != "" && tag != "-" && tag == name {
+			return v.FieldByIndex(f.Index), true
+		}
+	}
+	for _, f := range fields {
+		if f.PkgPath == "" && !f.Anonymous && f.Name == name && f.Tag.Get(r.opts.TagName) != "-" {
+			return v.FieldByIndex(f.Index), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Convert the segment to the map key.
+func mapKey(t reflect.Type, seg Segment) (reflect.Value, bool) {
+	switch t.Kind() {
+	case reflect.String:
+		if seg.Type == SegmentType_Name {
+			return reflect.ValueOf(seg.Name).Convert(t), true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if seg.Type == SegmentType_Index {
+			return reflect.ValueOf(int64(seg.Index)).Convert(t), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if seg.Type == SegmentType_Index && seg.Index >= 0 {
+			return reflect.ValueOf(uint64(seg.Index)).Convert(t), true
+		}
+	case reflect.Interface:
+		if seg.Type == SegmentType_Name {
+			return reflect.ValueOf(seg.Name), true
+		}
+		return reflect.ValueOf(seg.Index), true
+	}
+	return reflect.Value{}, false
+}
+
+// Make the zero value to store in the map for the next segment. The maps and pointers are allocated.
+func (r *resolver) newElem(t reflect.Type, next Segment) (reflect.Value, bool) {
+	switch t.Kind() {
+	case reflect.Map:
+		return reflect.MakeMap(t), true
+	case reflect.Ptr:
+		return reflect.New(t.Elem()), true
+	case reflect.Interface:
+		if next.Type == SegmentType_Name {
+			return reflect.ValueOf(map[string]interface{}{}), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// Dereference the pointers and interfaces. The nil values are initialized if they are settable.
+func (r *resolver) indirect(i int, v reflect.Value) (reflect.Value, error) {
+	for {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if v.IsNil() {
+				if r.opts.NoInit || !v.CanSet() {
+					return v, r.error(i, v, ErrNilValue)
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+				r.changed = true
+			}
+			v = v.Elem()
+		case reflect.Interface:
+			if v.IsNil() {
+				if r.opts.NoInit || !v.CanSet() || r.path[i].Type != SegmentType_Name {
+					return v, r.error(i, v, ErrNilValue)
+				}
+				v.Set(reflect.ValueOf(map[string]interface{}{}))
+				r.changed = true
+			}
+			v = v.Elem()
+		default:
+			return v, nil
+		}
+	}
+}
+
+// Walk the value, and get the box of the destination.
+func (r *resolver) resolve(v reflect.Value) (Box, error) {
+	if !v.IsValid() {
+		return nil, &SegmentError{Path: Path{}, Err: ErrNilValue}
+	}
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	for i, seg := range r.path {
+		last := i == len(r.path)-1
+
+		var err error
+		if v, err = r.indirect(i, v); err != nil {
+			return nil, err
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			if seg.Type != SegmentType_Name {
+				return nil, r.error(i, v, ErrTypeMismatch)
+			}
+			f, ok := r.field(v, seg.Name)
+			if !ok {
+				return nil, r.error(i, v, ErrNotFound)
+			}
+			v = f
+
+		case reflect.Slice, reflect.Array, reflect.String:
+			if seg.Type != SegmentType_Index {
+				return nil, r.error(i, v, ErrTypeMismatch)
+			}
+			index := seg.Index
+			if index < 0 {
+				index += v.Len()
+			}
+			if index < 0 || v.Len() <= index {
+				return nil, r.error(i, v, ErrIndexOutOfRange)
+			}
+			v = v.Index(index)
+
+		case reflect.Map:
+			key, ok := mapKey(v.Type().Key(), seg)
+			if !ok {
+				return nil, r.error(i, v, ErrBadKey)
+			}
+			if v.IsNil() {
+				if r.opts.NoInit || !v.CanSet() {
+					return nil, r.error(i, v, ErrNilValue)
+				}
+				v.Set(reflect.MakeMap(v.Type()))
+				r.changed = true
+			}
+
+			elem := v.MapIndex(key)
+			if last {
+				if !elem.IsValid() {
+					return r.box(&NotInitializedMapContainerReflectionBox{Container: v, Key: key}), nil
+				}
+				return r.box(MapContainerReflectionBox{Container: v, Key: key}), nil
+			}
+			if !elem.IsValid() || elem.Kind() == reflect.Interface && elem.IsNil() {
+				created, ok := r.newElem(v.Type().Elem(), r.path[i+1])
+				if !ok || r.opts.NoInit {
+					return nil, r.error(i, v, ErrNotFound)
+				}
+				v.SetMapIndex(key, created)
+				r.changed = true
+				elem = created
+			}
+			// The map elements are not addressable.
+			v = r.addressable(v, key, elem)
+
+		default:
+			return nil, r.error(i, v, ErrTypeMismatch)
+		}
+	}
+	return r.box(typedBox(v)), nil
+}
+
+// Wrap the box if the path goes through the copies of the map elements.
+// If the resolver initialized some values in the copies, they are stored here.
+func (r *resolver) box(box Box) Box {
+	if len(r.writeBacks) == 0 {
+		return box
+	}
+	if r.changed {
+		flushWriteBacks(r.writeBacks)
+	}
+	return writeBackBox{Box: box, writeBacks: r.writeBacks}
+}
+
+// Get the typed box of the addressable value, or ReflectionBox.
+func typedBox(v reflect.Value) Box {
+	if v.CanAddr() {
+		switch p := v.Addr().Interface().(type) {
+		case *int64:
+			return Int64Box{Ptr: p}
+		case *float64:
+			return Float64Box{Ptr: p}
+		case *string:
+			return StringBox{Ptr: p}
+		case *[]byte:
+			return BytesBox{Ptr: p}
+		case *interface{}:
+			return AnyBox{Ptr: p}
+		case *[]interface{}:
+			return SliceBox{Ptr: p}
+		case *map[string]interface{}:
+			return MapBox{Ptr: p}
+		}
+	}
+	return ReflectionBox{Val: v}
+}
+
+// Walk the value along the path, and get the box of the destination.
+// The value should be a pointer to be set through the box.
+//
+// The nil maps and pointers on the way are initialized, and the missing map entries are created.
+// If the path goes through the struct or array elements of the maps, the box sets the copies of them,
+// and stores the copies to the maps.
+// If the destination is the missing map entry, NotInitializedMapContainerReflectionBox is returned.
+// If the destination is addressable int64, float64, string, []byte, interface{}, []interface{} or
+// map[string]interface{}, the typed box is returned.
+func (p Path) Resolve(v interface{}, opts Options) (Box, error) {
+	if opts.TagName == "" {
+		opts.TagName = "json"
+	}
+	r := &resolver{opts: opts, path: p}
+	return r.resolve(reflect.ValueOf(v))
+}
+
+// Parse the path expression, and walk the value into the box.
+func Resolve(v interface{}, path string) (Box, error) {
+	return ResolveWithOptions(v, path, Options{})
+}
+
+// Parse the path expression, and walk the value into the box.
+func ResolveWithOptions(v interface{}, path string, opts Options) (Box, error) {
+	p, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Resolve(v, opts)
+}
@@ -0,0 +1,68 @@
+package boxpath
+
+import (
+	"unsafe"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Implements the interface Box.
+// It sets the value to the copies of the map elements, and stores the copies to the maps.
+//
+// NOTE: The value set through GetPointer() is not stored to the maps until one of the setters is called.
+type writeBackBox struct {
+	Box
+	writeBacks []writeBack
+}
+
+func (s writeBackBox) SetAny(v interface{}) {
+	s.Box.SetAny(v)
+	flushWriteBacks(s.writeBacks)
+}
+
+func (s writeBackBox) SetInt(v int64) {
+	s.Box.SetInt(v)
+	flushWriteBacks(s.writeBacks)
+}
+
+func (s writeBackBox) SetUint(v uint64) {
+	s.Box.SetUint(v)
+	flushWriteBacks(s.writeBacks)
+}
+
+func (s writeBackBox) SetFloat(v float64) {
+	s.Box.SetFloat(v)
+	flushWriteBacks(s.writeBacks)
+}
+
+func (s writeBackBox) SetBool(v bool) {
+	s.Box.SetBool(v)
+	flushWriteBacks(s.writeBacks)
+}
+
+func (s writeBackBox) SetString(v string) {
+	s.Box.SetString(v)
+	flushWriteBacks(s.writeBacks)
+}
+
+func (s writeBackBox) SetBytes(v []byte) {
+	s.Box.SetBytes(v)
+	flushWriteBacks(s.writeBacks)
+}
+
+func (s writeBackBox) SetPointer(v unsafe.Pointer) {
+	s.Box.SetPointer(v)
+	flushWriteBacks(s.writeBacks)
+}
+
+func (s writeBackBox) Index(i int) Box {
+	return writeBackBox{Box: s.Box.Index(i), writeBacks: s.writeBacks}
+}
+
+func (s writeBackBox) MapIndex(k string) Box {
+	return writeBackBox{Box: s.Box.MapIndex(k), writeBacks: s.writeBacks}
+}
+
+func (s writeBackBox) ComplexMapIndex(k interface{}) Box {
+	return writeBackBox{Box: s.Box.ComplexMapIndex(k), writeBacks: s.writeBacks}
+}
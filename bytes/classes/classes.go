@@ -0,0 +1,32 @@
+package classes
+
+const (
+	Any          = ":bytes:Any"
+	End          = ":bytes:End"
+	Byte         = ":bytes:Byte"
+	ByteN        = ":bytes:ByteN"
+	ByteRange    = ":bytes:ByteRange"
+	Bytes        = ":bytes:Bytes"
+	BytesByCount = ":bytes:BytesByCount"
+	U8           = ":bytes:U8"
+	I8           = ":bytes:I8"
+	U16LE        = ":bytes:U16LE"
+	U16BE        = ":bytes:U16BE"
+	I16LE        = ":bytes:I16LE"
+	I16BE        = ":bytes:I16BE"
+	U32LE        = ":bytes:U32LE"
+	U32BE        = ":bytes:U32BE"
+	I32LE        = ":bytes:I32LE"
+	I32BE        = ":bytes:I32BE"
+	U64LE        = ":bytes:U64LE"
+	U64BE        = ":bytes:U64BE"
+	I64LE        = ":bytes:I64LE"
+	I64BE        = ":bytes:I64BE"
+	F32LE        = ":bytes:F32LE"
+	F32BE        = ":bytes:F32BE"
+	F64LE        = ":bytes:F64LE"
+	F64BE        = ":bytes:F64BE"
+	Uleb128      = ":bytes:Uleb128"
+	Sleb128      = ":bytes:Sleb128"
+	Varint       = ":bytes:Varint"
+)
@@ -0,0 +1,22 @@
+package byteparser
+
+import (
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Constructor
+func NewBytesParserContext(b []byte) *ParserContext {
+	return &ParserContext{
+		Bytes:    b,
+		AstStack: make(AstSlice, 0, 1024),
+	}
+}
+
+// Constructor
+func NewBytesParserContextWithTag(b []byte, t interface{}) *ParserContext {
+	return &ParserContext{
+		Bytes:    b,
+		AstStack: make(AstSlice, 0, 1024),
+		Tag:      t,
+	}
+}
@@ -0,0 +1,382 @@
+package byteparser
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	. "github.com/shellyln/takenoco/base"
+	clsz "github.com/shellyln/takenoco/bytes/classes"
+)
+
+// Byte range property of ByteRange().
+type Range struct {
+	// The smallest byte in the range.
+	Start byte
+	// The largest byte in the range.
+	End byte
+}
+
+// Common implementation for the parsers of single byte.
+func singleByte(className string, fn func(b byte) bool) ParserFn {
+	return LightBaseParser(className, func(ctx ParserContext) (ParserContext, error) {
+		ctx.MatchStatus = MatchStatus_Unmatched
+
+		if ctx.Position+1 <= len(ctx.Bytes) {
+			b := ctx.Bytes[ctx.Position]
+			if fn(b) {
+				ctx.AstStack = append(ctx.AstStack, Ast{
+					ClassName:      className,
+					Type:           AstType_Uint,
+					Value:          uint64(b),
+					SourcePosition: SourcePosition{Position: ctx.Position, Length: 1, FileId: ctx.FileId},
+				})
+				ctx.Position += 1
+				ctx.Length = 1
+				ctx.MatchStatus = MatchStatus_Matched
+			}
+		}
+		return ctx, nil
+	})
+}
+
+// Common implementation for the parsers of fixed width values.
+func fixedWidth(className string, size int, fn func(b []byte) (AstType, interface{})) ParserFn {
+	return LightBaseParser(className, func(ctx ParserContext) (ParserContext, error) {
+		ctx.MatchStatus = MatchStatus_Unmatched
+
+		if ctx.Position+size <= len(ctx.Bytes) {
+			typ, v := fn(ctx.Bytes[ctx.Position : ctx.Position+size])
+			ctx.AstStack = append(ctx.AstStack, Ast{
+				ClassName:      className,
+				Type:           typ,
+				Value:          v,
+				SourcePosition: SourcePosition{Position: ctx.Position, Length: size, FileId: ctx.FileId},
+			})
+			ctx.Position += size
+			ctx.Length = size
+			ctx.MatchStatus = MatchStatus_Matched
+		}
+		return ctx, nil
+	})
+}
+
+// Assertion that always match.
+func Any() ParserFn {
+	return singleByte(clsz.Any, func(b byte) bool {
+		return true
+	})
+}
+
+// Zero-width assertion at the end of the source.
+func End() ParserFn {
+	const ClassName = clsz.End
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		ctx.MatchStatus = MatchStatus_Unmatched
+
+		if ctx.Position == len(ctx.Bytes) {
+			ctx.Length = 0
+			ctx.MatchStatus = MatchStatus_Matched
+		}
+
+		return ctx, nil
+	})
+}
+
+// Assertion that match if a value belongs to a set of bytes.
+func Byte(bs ...byte) ParserFn {
+	return singleByte(clsz.Byte, func(b byte) bool {
+		for _, w := range bs {
+			if b == w {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Assertion that match if a value does not belong to a set of bytes.
+func ByteN(bs ...byte) ParserFn {
+	return singleByte(clsz.ByteN, func(b byte) bool {
+		for _, w := range bs {
+			if b == w {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Assertion that match a range of bytes.
+func ByteRange(br ...Range) ParserFn {
+	return singleByte(clsz.ByteRange, func(b byte) bool {
+		for _, r := range br {
+			if r.Start <= b && b <= r.End {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Assertion that match a sequence of bytes.
+// The value is the []byte that shares the memory with the source.
+func Bytes(seq []byte) ParserFn {
+	const ClassName = clsz.Bytes
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		ctx.MatchStatus = MatchStatus_Unmatched
+
+		length := len(seq)
+		if ctx.Position+length <= len(ctx.Bytes) {
+			w := ctx.Bytes[ctx.Position : ctx.Position+length]
+			if string(w) == string(seq) {
+				ctx.AstStack = append(ctx.AstStack, Ast{
+					ClassName:      ClassName,
+					Type:           AstType_Any,
+					Value:          w,
+					SourcePosition: SourcePosition{Position: ctx.Position, Length: length, FileId: ctx.FileId},
+				})
+				ctx.Position += length
+				ctx.Length = length
+				ctx.MatchStatus = MatchStatus_Matched
+			}
+		}
+		return ctx, nil
+	})
+}
+
+// Assertion that match n bytes. (length-prefixed field)
+// n is read from the AST produced by the prefix parser.
+// The value is the []byte that shares the memory with the source.
+func BytesByCount(prefix ParserFn) ParserFn {
+	const ClassName = clsz.BytesByCount
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		out, n, err := ParseCountPrefix(prefix, ctx)
+		if err != nil {
+			return out, err
+		}
+		if out.MatchStatus != MatchStatus_Matched || n > len(out.Bytes)-out.Position {
+			ctx.MatchStatus = MatchStatus_Unmatched
+			return ctx, nil
+		}
+
+		out.AstStack = append(out.AstStack, Ast{
+			ClassName:      ClassName,
+			Type:           AstType_Any,
+			Value:          out.Bytes[out.Position : out.Position+n],
+			SourcePosition: SourcePosition{Position: out.Position, Length: n, FileId: out.FileId},
+		})
+		out.Position += n
+		out.Length = out.Position - ctx.Position
+		return out, nil
+	})
+}
+
+// Unsigned 8-bit integer.
+func U8() ParserFn {
+	return fixedWidth(clsz.U8, 1, func(b []byte) (AstType, interface{}) {
+		return AstType_Uint, uint64(b[0])
+	})
+}
+
+// Signed 8-bit integer.
+func I8() ParserFn {
+	return fixedWidth(clsz.I8, 1, func(b []byte) (AstType, interface{}) {
+		return AstType_Int, int64(int8(b[0]))
+	})
+}
+
+// Unsigned 16-bit integer. (little endian)
+func U16LE() ParserFn {
+	return fixedWidth(clsz.U16LE, 2, func(b []byte) (AstType, interface{}) {
+		return AstType_Uint, uint64(binary.LittleEndian.Uint16(b))
+	})
+}
+
+// Unsigned 16-bit integer. (big endian)
+func U16BE() ParserFn {
+	return fixedWidth(clsz.U16BE, 2, func(b []byte) (AstType, interface{}) {
+		return AstType_Uint, uint64(binary.BigEndian.Uint16(b))
+	})
+}
+
+// Signed 16-bit integer. (little endian)
+func I16LE() ParserFn {
+	return fixedWidth(clsz.I16LE, 2, func(b []byte) (AstType, interface{}) {
+		return AstType_Int, int64(int16(binary.LittleEndian.Uint16(b)))
+	})
+}
+
+// Signed 16-bit integer. (big endian)
+func I16BE() ParserFn {
+	return fixedWidth(clsz.I16BE, 2, func(b []byte) (AstType, interface{}) {
+		return AstType_Int, int64(int16(binary.BigEndian.Uint16(b)))
+	})
+}
+
+// Unsigned 32-bit integer. (little endian)
+func U32LE() ParserFn {
+	return fixedWidth(clsz.U32LE, 4, func(b []byte) (AstType, interface{}) {
+		return AstType_Uint, uint64(binary.LittleEndian.Uint32(b))
+	})
+}
+
+// Unsigned 32-bit integer. (big endian)
+func U32BE() ParserFn {
+	return fixedWidth(clsz.U32BE, 4, func(b []byte) (AstType, interface{}) {
+		return AstType_Uint, uint64(binary.BigEndian.Uint32(b))
+	})
+}
+
+// Signed 32-bit integer. (little endian)
+func I32LE() ParserFn {
+	return fixedWidth(clsz.I32LE, 4, func(b []byte) (AstType, interface{}) {
+		return AstType_Int, int64(int32(binary.LittleEndian.Uint32(b)))
+	})
+}
+
+// Signed 32-bit integer. (big endian)
+func I32BE() ParserFn {
+	return fixedWidth(clsz.I32BE, 4, func(b []byte) (AstType, interface{}) {
+		return AstType_Int, int64(int32(binary.BigEndian.Uint32(b)))
+	})
+}
+
+// Unsigned 64-bit integer. (little endian)
+func U64LE() ParserFn {
+	return fixedWidth(clsz.U64LE, 8, func(b []byte) (AstType, interface{}) {
+		return AstType_Uint, binary.LittleEndian.Uint64(b)
+	})
+}
+
+// Unsigned 64-bit integer. (big endian)
+func U64BE() ParserFn {
+	return fixedWidth(clsz.U64BE, 8, func(b []byte) (AstType, interface{}) {
+		return AstType_Uint, binary.BigEndian.Uint64(b)
+	})
+}
+
+// Signed 64-bit integer. (little endian)
+func I64LE() ParserFn {
+	return fixedWidth(clsz.I64LE, 8, func(b []byte) (AstType, interface{}) {
+		return AstType_Int, int64(binary.LittleEndian.Uint64(b))
+	})
+}
+
+// Signed 64-bit integer. (big endian)
+func I64BE() ParserFn {
+	return fixedWidth(clsz.I64BE, 8, func(b []byte) (AstType, interface{}) {
+		return AstType_Int, int64(binary.BigEndian.Uint64(b))
+	})
+}
+
+// IEEE 754 single precision floating point number. (little endian)
+func F32LE() ParserFn {
+	return fixedWidth(clsz.F32LE, 4, func(b []byte) (AstType, interface{}) {
+		return AstType_Float, float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	})
+}
+
+// IEEE 754 single precision floating point number. (big endian)
+func F32BE() ParserFn {
+	return fixedWidth(clsz.F32BE, 4, func(b []byte) (AstType, interface{}) {
+		return AstType_Float, float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	})
+}
+
+// IEEE 754 double precision floating point number. (little endian)
+func F64LE() ParserFn {
+	return fixedWidth(clsz.F64LE, 8, func(b []byte) (AstType, interface{}) {
+		return AstType_Float, math.Float64frombits(binary.LittleEndian.Uint64(b))
+	})
+}
+
+// IEEE 754 double precision floating point number. (big endian)
+func F64BE() ParserFn {
+	return fixedWidth(clsz.F64BE, 8, func(b []byte) (AstType, interface{}) {
+		return AstType_Float, math.Float64frombits(binary.BigEndian.Uint64(b))
+	})
+}
+
+const msgVarintOverflow = "Variable length integer overflows 64-bit"
+
+// Read the LEB128 bytes. It returns the raw value, the shift count and the length.
+// If the length is 0, the bytes are truncated.
+// The 10th byte holds only the bit 63, and the rest bits should be its sign extension if signed is true,
+// otherwise they should be 0.
+func readLeb128(b []byte, signed bool) (uint64, uint, int, error) {
+	var v uint64
+	var shift uint
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if shift == 63 {
+			if signed && c != 0x00 && c != 0x7f || !signed && c > 1 {
+				return 0, 0, 0, errors.New(msgVarintOverflow)
+			}
+		}
+		v |= uint64(c&0x7f) << shift
+		shift += 7
+		if c < 0x80 {
+			return v, shift, i + 1, nil
+		}
+	}
+	return 0, 0, 0, nil
+}
+
+// Common implementation for the parsers of variable length integers.
+func varLength(className string, signed bool, fn func(v uint64, shift uint, last byte) (AstType, interface{})) ParserFn {
+	return LightBaseParser(className, func(ctx ParserContext) (ParserContext, error) {
+		ctx.MatchStatus = MatchStatus_Unmatched
+
+		v, shift, length, err := readLeb128(ctx.Bytes[ctx.Position:], signed)
+		if err != nil {
+			ctx.Length = 0
+			ctx.MatchStatus = MatchStatus_Error
+			return ctx, err
+		}
+		if length == 0 {
+			return ctx, nil
+		}
+
+		typ, w := fn(v, shift, ctx.Bytes[ctx.Position+length-1])
+		ctx.AstStack = append(ctx.AstStack, Ast{
+			ClassName:      className,
+			Type:           typ,
+			Value:          w,
+			SourcePosition: SourcePosition{Position: ctx.Position, Length: length, FileId: ctx.FileId},
+		})
+		ctx.Position += length
+		ctx.Length = length
+		ctx.MatchStatus = MatchStatus_Matched
+		return ctx, nil
+	})
+}
+
+// Unsigned LEB128 integer. It is the same as the unsigned varint of Protocol Buffers.
+func Uleb128() ParserFn {
+	return varLength(clsz.Uleb128, false, func(v uint64, shift uint, last byte) (AstType, interface{}) {
+		return AstType_Uint, v
+	})
+}
+
+// Signed LEB128 integer.
+func Sleb128() ParserFn {
+	return varLength(clsz.Sleb128, true, func(v uint64, shift uint, last byte) (AstType, interface{}) {
+		if shift < 64 && last&0x40 != 0 {
+			v |= ^uint64(0) << shift
+		}
+		return AstType_Int, int64(v)
+	})
+}
+
+// Unsigned varint of Protocol Buffers. It is the same as Uleb128().
+func Uvarint() ParserFn {
+	return Uleb128()
+}
+
+// Signed (ZigZag encoded) varint of Protocol Buffers.
+func Varint() ParserFn {
+	return varLength(clsz.Varint, false, func(v uint64, shift uint, last byte) (AstType, interface{}) {
+		return AstType_Int, int64(v>>1) ^ -int64(v&1)
+	})
+}
@@ -0,0 +1,174 @@
+package byteparser
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+func TestFixedWidth(t *testing.T) {
+	src := []byte{0xfe, 0xff, 0x00, 0x00, 0x80, 0x3f}
+
+	tests := []struct {
+		name   string
+		parser ParserFn
+		pos    int
+		typ    AstType
+		want   interface{}
+	}{
+		{name: "U8", parser: U8(), pos: 0, typ: AstType_Uint, want: uint64(0xfe)},
+		{name: "I8", parser: I8(), pos: 0, typ: AstType_Int, want: int64(-2)},
+		{name: "U16LE", parser: U16LE(), pos: 0, typ: AstType_Uint, want: uint64(0xfffe)},
+		{name: "U16BE", parser: U16BE(), pos: 0, typ: AstType_Uint, want: uint64(0xfeff)},
+		{name: "I16LE", parser: I16LE(), pos: 0, typ: AstType_Int, want: int64(-2)},
+		{name: "I16BE", parser: I16BE(), pos: 0, typ: AstType_Int, want: int64(-257)},
+		{name: "U32LE", parser: U32LE(), pos: 0, typ: AstType_Uint, want: uint64(0x0000fffe)},
+		{name: "I32BE", parser: I32BE(), pos: 0, typ: AstType_Int, want: int64(-16842752)},
+		{name: "F32LE", parser: F32LE(), pos: 2, typ: AstType_Float, want: float64(1)},
+		{name: "U64BE", parser: U64BE(), pos: 0, typ: AstType_Uint, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := *NewBytesParserContext(src)
+			ctx.Position = tt.pos
+			got, err := tt.parser(ctx)
+			if err != nil {
+				t.Errorf("%v() err is %v", tt.name, err)
+				return
+			}
+			if tt.want == nil {
+				if got.MatchStatus != MatchStatus_Unmatched {
+					t.Errorf("%v() got.MatchStatus is %v", tt.name, got.MatchStatus)
+				}
+				return
+			}
+			if got.MatchStatus != MatchStatus_Matched {
+				t.Errorf("%v() got.MatchStatus is %v", tt.name, got.MatchStatus)
+				return
+			}
+			if got.AstStack[0].Type != tt.typ || got.AstStack[0].Value != tt.want {
+				t.Errorf("%v() = %v, want %v", tt.name, got.AstStack[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestFloat64(t *testing.T) {
+	b := []byte{0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18}
+	got, err := F64BE()(*NewBytesParserContext(b))
+	if err != nil || got.AstStack[0].Value != math.Pi {
+		t.Errorf("F64BE() = %v, err is %v", got.AstStack, err)
+	}
+}
+
+func TestVarint(t *testing.T) {
+	tests := []struct {
+		name    string
+		parser  ParserFn
+		src     []byte
+		want    interface{}
+		wantLen int
+		wantErr bool
+	}{
+		{name: "Uleb128 1", parser: Uleb128(), src: []byte{0x02}, want: uint64(2), wantLen: 1},
+		{name: "Uleb128 2", parser: Uleb128(), src: []byte{0xe5, 0x8e, 0x26}, want: uint64(624485), wantLen: 3},
+		{name: "Uleb128 max", parser: Uleb128(), src: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, want: uint64(math.MaxUint64), wantLen: 10},
+		{name: "Uleb128 overflow", parser: Uleb128(), src: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}, wantErr: true},
+		{name: "Uleb128 truncated", parser: Uleb128(), src: []byte{0xe5, 0x8e}, want: nil},
+		{name: "Sleb128 1", parser: Sleb128(), src: []byte{0x7f}, want: int64(-1), wantLen: 1},
+		{name: "Sleb128 2", parser: Sleb128(), src: []byte{0xc0, 0xbb, 0x78}, want: int64(-123456), wantLen: 3},
+		{name: "Sleb128 3", parser: Sleb128(), src: []byte{0x3f}, want: int64(63), wantLen: 1},
+		{name: "Sleb128 -1 10 bytes", parser: Sleb128(), src: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, want: int64(-1), wantLen: 10},
+		{name: "Sleb128 min", parser: Sleb128(), src: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}, want: int64(math.MinInt64), wantLen: 10},
+		{name: "Sleb128 max", parser: Sleb128(), src: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}, want: int64(math.MaxInt64), wantLen: 10},
+		{name: "Sleb128 overflow", parser: Sleb128(), src: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, wantErr: true},
+		{name: "Sleb128 overflow 2", parser: Sleb128(), src: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7e}, wantErr: true},
+		{name: "Sleb128 too long", parser: Sleb128(), src: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, wantErr: true},
+		{name: "Varint 1", parser: Varint(), src: []byte{0x03}, want: int64(-2), wantLen: 1},
+		{name: "Varint 2", parser: Varint(), src: []byte{0xac, 0x02}, want: int64(150), wantLen: 2},
+		{name: "Varint min", parser: Varint(), src: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, want: int64(math.MinInt64), wantLen: 10},
+		{name: "Uvarint", parser: Uvarint(), src: []byte{0x96, 0x01}, want: uint64(150), wantLen: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parser(*NewBytesParserContext(tt.src))
+			if tt.wantErr != (err != nil) {
+				t.Errorf("%v() err is %v", tt.name, err)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.want == nil {
+				if got.MatchStatus != MatchStatus_Unmatched {
+					t.Errorf("%v() got.MatchStatus is %v", tt.name, got.MatchStatus)
+				}
+				return
+			}
+			if got.MatchStatus != MatchStatus_Matched || got.Position != tt.wantLen {
+				t.Errorf("%v() got.MatchStatus is %v, got.Position is %v", tt.name, got.MatchStatus, got.Position)
+				return
+			}
+			if got.AstStack[0].Value != tt.want {
+				t.Errorf("%v() = %v, want %v", tt.name, got.AstStack[0].Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestBytesByCount(t *testing.T) {
+	// Length-prefixed record: magic, u16be length, payload, and terminator.
+	record := FlatGroup(
+		Trans(Bytes([]byte("TK")), Erase),
+		BytesByCount(U16BE()),
+		Byte(0x00, 0xff),
+		End(),
+	)
+
+	got, err := record(*NewBytesParserContext([]byte{'T', 'K', 0x00, 0x03, 'a', 'b', 'c', 0xff}))
+	if err != nil || got.MatchStatus != MatchStatus_Matched {
+		t.Errorf("record() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+		return
+	}
+	if len(got.AstStack) != 2 || string(got.AstStack[0].Value.([]byte)) != "abc" || got.AstStack[1].Value != uint64(0xff) {
+		t.Errorf("record() = %v", got.AstStack)
+	}
+
+	got, err = record(*NewBytesParserContext([]byte{'T', 'K', 0x00, 0x04, 'a', 'b', 'c', 0xff}))
+	if err != nil || got.MatchStatus != MatchStatus_Unmatched {
+		t.Errorf("record() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+
+	// The count near MaxInt64 does not overflow.
+	huge := BytesByCount(U64BE())
+	got, err = huge(*NewBytesParserContext([]byte{0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 'a', 'b', 'c'}))
+	if err != nil || got.MatchStatus != MatchStatus_Unmatched {
+		t.Errorf("huge() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+}
+
+func TestByteRange(t *testing.T) {
+	digits := Trans(OneOrMoreTimes(ByteRange(Range{Start: '0', End: '9'})), bytesTestToString)
+	got, err := FlatGroup(digits, ByteN('0'), End())(*NewBytesParserContext([]byte("123x")))
+	if err != nil || got.MatchStatus != MatchStatus_Matched {
+		t.Errorf("ByteRange() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+		return
+	}
+	if got.AstStack[0].Value != "123" || got.AstStack[1].Value != uint64('x') {
+		t.Errorf("ByteRange() = %v", got.AstStack)
+	}
+}
+
+func bytesTestToString(_ ParserContext, asts AstSlice) (AstSlice, error) {
+	b := make([]byte, 0, len(asts))
+	for _, w := range asts {
+		b = append(b, byte(w.Value.(uint64)))
+	}
+	return AstSlice{{
+		Type:  AstType_String,
+		Value: string(b),
+	}}, nil
+}
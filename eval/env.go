@@ -0,0 +1,51 @@
+package eval
+
+import (
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Lexical environment
+type Env struct {
+	parent *Env
+	vars   map[string]Ast
+}
+
+// Constructor
+func NewEnv(parent *Env) *Env {
+	return &Env{
+		parent: parent,
+		vars:   make(map[string]Ast),
+	}
+}
+
+// Enclosing environment. It is nil for the global environment.
+func (e *Env) Parent() *Env {
+	return e.parent
+}
+
+// Define the variable in this environment. It shadows the variable of the enclosing environments.
+func (e *Env) Define(name string, value Ast) {
+	e.vars[name] = value
+}
+
+// Find the variable from this environment to the global environment.
+func (e *Env) Lookup(name string) (Ast, bool) {
+	for env := e; env != nil; env = env.parent {
+		if v, ok := env.vars[name]; ok {
+			return v, true
+		}
+	}
+	return Ast{}, false
+}
+
+// Assign the value to the variable of the nearest environment that defines it.
+// It returns false if the variable is not defined.
+func (e *Env) Set(name string, value Ast) bool {
+	for env := e; env != nil; env = env.parent {
+		if _, ok := env.vars[name]; ok {
+			env.vars[name] = value
+			return true
+		}
+	}
+	return false
+}
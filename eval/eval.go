@@ -0,0 +1,246 @@
+package eval
+
+import (
+	"errors"
+	"strconv"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Result of the evaluation.
+// PcIncr and Opcode control the execution position of the enclosing WayThereMode_Last list.
+type Result struct {
+	Value  Ast
+	PcIncr int16
+	Opcode TraverseOpcode
+	// Thrown value. (*Exception, or the signal of Interpreter.Return())
+	Thrown interface{}
+}
+
+// Result of the normal completion.
+func Normal(value Ast) Result {
+	return Result{Value: value}
+}
+
+// Result that moves the execution position to the end of the enclosing list.
+func Break() Result {
+	return Result{Opcode: TraverseOpcode_Break}
+}
+
+// Result that moves the execution position to the first of the enclosing list.
+func Continue() Result {
+	return Result{Opcode: TraverseOpcode_Continue}
+}
+
+// Result that skips the next n items of the enclosing list. (n can be negative)
+func Jump(value Ast, n int16) Result {
+	return Result{Value: value, PcIncr: n}
+}
+
+// Callback handler that evaluates the AST.
+// The children are already evaluated, unless the mode of the handler is WayThereMode_Lazy.
+type FnEval func(it *Interpreter, ast Ast) (Result, error)
+
+// Handler of the opcode.
+type Handler struct {
+	// Mode of the outbound trip.
+	// WayThereMode_Lazy handlers (special forms) evaluate the children by themselves with Interpreter.Eval().
+	// WayThereMode_Last handlers (blocks) get the list of the last value.
+	Mode WayThereMode
+	// Called on the outbound trip. (e.g. push the scope) It is optional.
+	Enter func(it *Interpreter, ast Ast) error
+	// Called on the return trip.
+	Eval FnEval
+	// Called after Eval, or when the child throws or returns an error. (e.g. pop the scope) It is optional.
+	Leave func(it *Interpreter, ast Ast)
+}
+
+// Handler of the block. It evaluates the children in a new scope, and results in the last value.
+func BlockHandler() Handler {
+	return Handler{
+		Mode: WayThereMode_Last,
+		Enter: func(it *Interpreter, ast Ast) error {
+			it.PushScope()
+			return nil
+		},
+		Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			if children, ok := ast.Value.(AstSlice); ok && len(children) != 0 {
+				return Normal(children[0]), nil
+			}
+			return Normal(Ast{Type: AstType_Nil}), nil
+		},
+		Leave: func(it *Interpreter, ast Ast) {
+			it.PopScope()
+		},
+	}
+}
+
+// Handler of the self-evaluating AST. It is used for OpCode 0.
+func LiteralHandler() Handler {
+	return Handler{
+		Mode: WayThereMode_Lazy,
+		Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			return Normal(ast), nil
+		},
+	}
+}
+
+// Map from OpCode to Handler
+type Registry struct {
+	handlers map[AstOpCodeType]Handler
+}
+
+// Constructor
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[AstOpCodeType]Handler)}
+}
+
+// Register the handler of the opcode. It returns the registry itself for chaining.
+func (r *Registry) Register(op AstOpCodeType, h Handler) *Registry {
+	r.handlers[op] = h
+	return r
+}
+
+// Find the handler of the opcode.
+// If no handler is registered for OpCode 0, LiteralHandler() is returned.
+func (r *Registry) Lookup(op AstOpCodeType) (Handler, bool) {
+	h, ok := r.handlers[op]
+	if !ok && op == 0 {
+		return LiteralHandler(), true
+	}
+	return h, ok
+}
+
+// Options of the interpreter.
+type Options struct {
+	// Maximum depth of the call frames. Default is 1000.
+	MaxCallDepth int
+}
+
+// Tree-walking interpreter
+type Interpreter struct {
+	registry *Registry
+	opts     Options
+	globals  *Env
+	env      *Env
+	frames   []Frame
+}
+
+// Constructor
+func NewInterpreter(registry *Registry, opts Options) *Interpreter {
+	if opts.MaxCallDepth == 0 {
+		opts.MaxCallDepth = 1000
+	}
+	globals := NewEnv(nil)
+	return &Interpreter{
+		registry: registry,
+		opts:     opts,
+		globals:  globals,
+		env:      globals,
+		frames:   make([]Frame, 0),
+	}
+}
+
+// Global environment
+func (it *Interpreter) Globals() *Env {
+	return it.globals
+}
+
+// Current environment
+func (it *Interpreter) Env() *Env {
+	return it.env
+}
+
+// Replace the current environment, and get the previous one.
+func (it *Interpreter) SetEnv(env *Env) *Env {
+	prev := it.env
+	it.env = env
+	return prev
+}
+
+// Push the new scope enclosed by the current environment.
+func (it *Interpreter) PushScope() *Env {
+	it.env = NewEnv(it.env)
+	return it.env
+}
+
+// Pop the current scope.
+func (it *Interpreter) PopScope() {
+	if it.env.parent != nil {
+		it.env = it.env.parent
+	}
+}
+
+// Get the handler of the AST.
+func (it *Interpreter) handler(ast Ast) (Handler, error) {
+	h, ok := it.registry.Lookup(ast.OpCode)
+	if !ok || h.Eval == nil {
+		return h, ToParseError(
+			ast.SourcePosition, ast.ClassName,
+			errors.New("No handler is registered for the opcode "+strconv.FormatUint(uint64(ast.OpCode), 10)))
+	}
+	return h, nil
+}
+
+func (it *Interpreter) wayThere(ctx interface{}, ast Ast) (Ast, WayThereMode, error) {
+	h, err := it.handler(ast)
+	if err != nil {
+		return ast, WayThereMode_None, err
+	}
+	if h.Enter != nil {
+		if err := h.Enter(it, ast); err != nil {
+			return ast, WayThereMode_None, ToParseError(ast.SourcePosition, ast.ClassName, err)
+		}
+	}
+	return ast, h.Mode, nil
+}
+
+func (it *Interpreter) wayBack(ctx interface{}, ast Ast) (Ast, int16, TraverseOpcode, interface{}, error) {
+	h, err := it.handler(ast)
+	if err != nil {
+		return ast, 0, 0, nil, err
+	}
+	r, err := h.Eval(it, ast)
+	if h.Leave != nil {
+		h.Leave(it, ast)
+	}
+	if err != nil {
+		return ast, 0, 0, nil, ToParseError(ast.SourcePosition, ast.ClassName, err)
+	}
+	return r.Value, r.PcIncr, r.Opcode, r.Thrown, nil
+}
+
+func (it *Interpreter) childrenErr(ctx interface{}, ast Ast, child Ast, thrown interface{}, err error) {
+	if h, ok := it.registry.Lookup(ast.OpCode); ok && h.Leave != nil {
+		h.Leave(it, ast)
+	}
+}
+
+// Evaluate the AST in the current environment.
+// The thrown value is returned in the result, and it is not an error.
+func (it *Interpreter) Eval(ast Ast) (Result, error) {
+	value, pcIncr, opcode, thrown, err := ast.Traverse(it.wayThere, it.wayBack, it.childrenErr, it)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Value: value, PcIncr: pcIncr, Opcode: opcode, Thrown: thrown}, nil
+}
+
+// Evaluate the program.
+// The uncaught exception is returned as the *Exception error.
+func (it *Interpreter) Run(ast Ast) (Ast, error) {
+	r, err := it.Eval(ast)
+	if err != nil {
+		return Ast{}, err
+	}
+	switch thrown := r.Thrown.(type) {
+	case nil:
+		return r.Value, nil
+	case returnSignal:
+		return thrown.value, nil
+	case *Exception:
+		return Ast{}, thrown
+	default:
+		return Ast{}, &Exception{Value: Ast{Type: AstType_Any, Value: thrown}}
+	}
+}
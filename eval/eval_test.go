@@ -0,0 +1,241 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	"github.com/shellyln/takenoco/sexpr"
+)
+
+const (
+	opBlock AstOpCodeType = iota + 1
+	opDef
+	opVar
+	opSet
+	opAdd
+	opLt
+	opIf
+	opLoop
+	opBreak
+	opContinue
+	opLambda
+	opCall
+	opReturn
+	opThrow
+	opTry
+)
+
+func children(ast Ast) AstSlice {
+	w, _ := ast.Value.(AstSlice)
+	return w
+}
+
+func newTestRegistry() *Registry {
+	return NewRegistry().
+		Register(opBlock, BlockHandler()).
+		Register(opDef, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			args := children(ast)
+			it.Env().Define(args[0].Value.(string), args[1])
+			return Normal(args[1]), nil
+		}}).
+		Register(opVar, Handler{Mode: WayThereMode_Lazy, Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			v, ok := it.Env().Lookup(ast.Value.(string))
+			if !ok {
+				return Result{}, errors.New("Undefined variable: " + ast.Value.(string))
+			}
+			return Normal(v), nil
+		}}).
+		Register(opSet, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			args := children(ast)
+			if !it.Env().Set(args[0].Value.(string), args[1]) {
+				return Result{}, errors.New("Undefined variable: " + args[0].Value.(string))
+			}
+			return Normal(args[1]), nil
+		}}).
+		Register(opAdd, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			args := children(ast)
+			return Normal(Ast{Type: AstType_Int, Value: args[0].Value.(int64) + args[1].Value.(int64)}), nil
+		}}).
+		Register(opLt, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			args := children(ast)
+			return Normal(Ast{Type: AstType_Bool, Value: args[0].Value.(int64) < args[1].Value.(int64)}), nil
+		}}).
+		Register(opIf, Handler{Mode: WayThereMode_Lazy, Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			args := children(ast)
+			cond, err := it.Eval(args[0])
+			if err != nil || cond.Thrown != nil {
+				return cond, err
+			}
+			if cond.Value.Value.(bool) {
+				return it.Eval(args[1])
+			}
+			return it.Eval(args[2])
+		}}).
+		Register(opLoop, Handler{Mode: WayThereMode_Last, Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			return Normal(children(ast)[0]), nil
+		}}).
+		Register(opBreak, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			return Break(), nil
+		}}).
+		Register(opContinue, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			return Continue(), nil
+		}}).
+		Register(opLambda, Handler{Mode: WayThereMode_Lazy, Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			args := children(ast)
+			params := make([]string, 0)
+			for _, p := range children(args[0]) {
+				params = append(params, p.Value.(string))
+			}
+			return Normal(it.Closure(ast.ClassName, params, args[1], ast.SourcePosition)), nil
+		}}).
+		Register(opCall, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			args := children(ast)
+			return it.Call(args[0], args[1:], ast.SourcePosition)
+		}}).
+		Register(opReturn, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			return it.Return(children(ast)[0]), nil
+		}}).
+		Register(opThrow, Handler{Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			return it.Throw(children(ast)[0], ast.SourcePosition), nil
+		}}).
+		Register(opTry, Handler{Mode: WayThereMode_Lazy, Eval: func(it *Interpreter, ast Ast) (Result, error) {
+			args := children(ast)
+			saved := it.Env()
+			r, err := it.Eval(args[0])
+			if err != nil {
+				return r, err
+			}
+			ex, ok := r.Thrown.(*Exception)
+			if !ok {
+				return r, nil
+			}
+			it.SetEnv(NewEnv(saved))
+			defer it.SetEnv(saved)
+			it.Env().Define("e", ex.Value)
+			return it.Eval(args[1])
+		}})
+}
+
+func TestInterpreter(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{{
+		name: "loop",
+		src: `(Block :op 1
+			(Def :op 2 "n" 0)
+			(Def :op 2 "sum" 0)
+			(Loop :op 8
+				(If :op 7 (Lt :op 6 (Var :op 3 "n") 5) nil (Break :op 9 nil))
+				(Set :op 4 "sum" (Add :op 5 (Var :op 3 "sum") (Var :op 3 "n")))
+				(Set :op 4 "n" (Add :op 5 (Var :op 3 "n") 1))
+				(Continue :op 10 nil))
+			(Var :op 3 "sum"))`,
+		want: "10",
+	}, {
+		name: "closure",
+		src: `(Block :op 1
+			(Def :op 2 "k" 10)
+			(Def :op 2 "addK" (Lambda :op 11 (Params (|| "x")) (Add :op 5 (Var :op 3 "x") (Var :op 3 "k"))))
+			(Block :op 1
+				(Def :op 2 "k" 100)
+				(Call :op 12 (Var :op 3 "addK") 1)))`,
+		want: "11",
+	}, {
+		name: "return",
+		src: `(Block :op 1
+			(Def :op 2 "f" (Lambda :op 11 (Params "a" "b")
+				(Block :op 1
+					(If :op 7 (Lt :op 6 (Var :op 3 "a") (Var :op 3 "b")) (Return :op 13 (|| "lt")) nil)
+					"ge")))
+			(Call :op 12 (Var :op 3 "f") 1 2))`,
+		want: "lt",
+	}, {
+		name: "native",
+		src:  `(Call :op 12 (Var :op 3 "twice") 21)`,
+		want: "42",
+	}, {
+		name: "catch",
+		src: `(Block :op 1
+			(Def :op 2 "f" (Lambda :op 11 (Params) (Block :op 1 (Def :op 2 "tmp" 1) (Throw :op 14 (|| "boom")))))
+			(Try :op 15 (Call :op 12 (Var :op 3 "f")) (Var :op 3 "e")))`,
+		want: "boom",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := NewInterpreter(newTestRegistry(), Options{})
+			it.DefineNative("twice", func(it *Interpreter, args AstSlice) (Result, error) {
+				return Normal(Ast{Type: AstType_Int, Value: args[0].Value.(int64) * 2}), nil
+			})
+
+			got, err := it.Run(sexpr.MustRead(tt.src)[0])
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if s := fmt.Sprint(got.Value); s != tt.want {
+				t.Errorf("Run() = %v, want %v", s, tt.want)
+			}
+			if it.Env() != it.Globals() || len(it.Frames()) != 0 {
+				t.Errorf("Run() did not restore the environment")
+			}
+		})
+	}
+}
+
+func TestInterpreterError(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		msg  string
+	}{{
+		name: "uncaught",
+		src: `(Block :op 1
+			(Def :op 2 "f" (Lambda :op 11 (Params) (Throw :op 14 (|| "boom"))))
+			(Call :op 12 (Var :op 3 "f")))`,
+		msg: "Uncaught exception: boom",
+	}, {
+		name: "handler error",
+		src:  `(Block :op 1 (Def :op 2 "x" 1) (Var :op 3 "y"))`,
+		msg:  "Undefined variable: y",
+	}, {
+		name: "no handler",
+		src:  `(Block :op 1 (Foo :op 99 1))`,
+		msg:  "No handler is registered for the opcode 99",
+	}, {
+		name: "arity",
+		src:  `(Call :op 12 (Lambda :op 11 (Params (|| "a")) (Var :op 3 "a")) 1 2)`,
+		msg:  "Function Lambda expects 1 arguments, but 2 given",
+	}, {
+		name: "stack overflow",
+		src: `(Block :op 1
+			(Def :op 2 "f" 0)
+			(Set :op 4 "f" (Lambda :op 11 (Params) (Call :op 12 (Var :op 3 "f"))))
+			(Call :op 12 (Var :op 3 "f")))`,
+		msg: "Call stack overflow",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := NewInterpreter(newTestRegistry(), Options{MaxCallDepth: 50})
+			_, err := it.Run(sexpr.MustRead(tt.src)[0])
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Fatalf("Run() error = %v, want %v", err, tt.msg)
+			}
+			if it.Env() != it.Globals() || len(it.Frames()) != 0 {
+				t.Errorf("Run() did not restore the environment")
+			}
+		})
+	}
+
+	it := NewInterpreter(newTestRegistry(), Options{})
+	_, err := it.Run(sexpr.MustRead(`(Block :op 1
+		(Def :op 2 "f" (Lambda :op 11 (Params) (Throw :op 14 (|| 1))))
+		(Call :op 12 (Var :op 3 "f")))`)[0])
+	var ex *Exception
+	if !errors.As(err, &ex) || len(ex.Frames) != 1 || ex.Frames[0].Function.Name != "Lambda" {
+		t.Errorf("Run() exception = %+v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Native function
+type NativeFn func(it *Interpreter, args AstSlice) (Result, error)
+
+// Function value. It is stored in the Ast as AstType_Function.
+type Function struct {
+	Name string
+	// Parameter names of the closure
+	Params []string
+	// Body of the closure
+	Body Ast
+	// Environment captured by the closure
+	Env *Env
+	// If it is not nil, the function is native and Params, Body and Env are not used.
+	Native NativeFn
+}
+
+// Make the AST of the function value.
+func NewFunctionAst(fn *Function, pos SourcePosition) Ast {
+	return Ast{
+		ClassName:      "Function",
+		Type:           AstType_Function,
+		Value:          fn,
+		SourcePosition: pos,
+	}
+}
+
+// Make the AST of the closure captures the current environment.
+func (it *Interpreter) Closure(name string, params []string, body Ast, pos SourcePosition) Ast {
+	return NewFunctionAst(&Function{Name: name, Params: params, Body: body, Env: it.env}, pos)
+}
+
+// Define the native function in the global environment.
+func (it *Interpreter) DefineNative(name string, fn NativeFn) {
+	it.globals.Define(name, NewFunctionAst(&Function{Name: name, Native: fn}, SourcePosition{}))
+}
+
+// Call frame
+type Frame struct {
+	Function *Function
+	// Environment of the function body
+	Env *Env
+	// Source position of the call site
+	SourcePosition
+}
+
+// Copy of the call frames. The last one is the innermost.
+func (it *Interpreter) Frames() []Frame {
+	w := make([]Frame, len(it.frames))
+	copy(w, it.frames)
+	return w
+}
+
+// Exception thrown by Interpreter.Throw().
+// It is propagated to the enclosing handlers as the thrown value of Traverse.
+type Exception struct {
+	Value Ast
+	// Call frames at the time of the throw
+	Frames []Frame
+	SourcePosition
+}
+
+// Implements error.
+func (e *Exception) Error() string {
+	return "Uncaught exception: " + fmt.Sprint(e.Value.Value)
+}
+
+// Signal of Interpreter.Return(). It is caught by Interpreter.Call().
+type returnSignal struct {
+	value Ast
+}
+
+// Result that throws the exception.
+func (it *Interpreter) Throw(value Ast, pos SourcePosition) Result {
+	return Result{Thrown: &Exception{Value: value, Frames: it.Frames(), SourcePosition: pos}}
+}
+
+// Result that returns from the current function.
+func (it *Interpreter) Return(value Ast) Result {
+	return Result{Thrown: returnSignal{value: value}}
+}
+
+// Call the function value.
+// The closure is evaluated in the new scope enclosed by the captured environment.
+// Break and continue do not leak out of the function, and the exceptions are returned in the result.
+func (it *Interpreter) Call(callee Ast, args AstSlice, pos SourcePosition) (Result, error) {
+	fn, ok := callee.Value.(*Function)
+	if callee.Type != AstType_Function || !ok {
+		return Result{}, errors.New("Not a function: " + callee.Type.String())
+	}
+	if len(it.frames) >= it.opts.MaxCallDepth {
+		return Result{}, errors.New("Call stack overflow")
+	}
+
+	var r Result
+	var err error
+
+	if fn.Native != nil {
+		it.frames = append(it.frames, Frame{Function: fn, Env: it.env, SourcePosition: pos})
+		r, err = fn.Native(it, args)
+		it.frames = it.frames[:len(it.frames)-1]
+	} else {
+		if len(args) != len(fn.Params) {
+			return Result{}, errors.New(
+				"Function " + fn.Name + " expects " + strconv.Itoa(len(fn.Params)) +
+					" arguments, but " + strconv.Itoa(len(args)) + " given")
+		}
+		env := NewEnv(fn.Env)
+		for i, name := range fn.Params {
+			env.Define(name, args[i])
+		}
+
+		saved := it.SetEnv(env)
+		it.frames = append(it.frames, Frame{Function: fn, Env: env, SourcePosition: pos})
+		r, err = it.Eval(fn.Body)
+		it.frames = it.frames[:len(it.frames)-1]
+		it.SetEnv(saved)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	if ret, ok := r.Thrown.(returnSignal); ok {
+		return Normal(ret.value), nil
+	}
+	if r.Thrown != nil {
+		return Result{Thrown: r.Thrown}, nil
+	}
+	return Normal(r.Value), nil
+}
@@ -0,0 +1,22 @@
+package classes
+
+const (
+	// Tag grammar
+	Alt           = ":grammar:Alt"
+	Seq           = ":grammar:Seq"
+	Group         = ":grammar:Group"
+	Optional      = ":grammar:Optional"
+	ZeroOrMore    = ":grammar:ZeroOrMore"
+	OneOrMore     = ":grammar:OneOrMore"
+	Capture       = ":grammar:Capture"
+	CaptureStruct = ":grammar:CaptureStruct"
+	Literal       = ":grammar:Literal"
+	Terminal      = ":grammar:Terminal"
+	// Leading `|` of the field tag.
+	Or = ":grammar:Or"
+
+	// Generated parsers
+	Token = ":grammar:Token"
+	Field = ":grammar:Field"
+	Node  = ":grammar:Node"
+)
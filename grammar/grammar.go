@@ -0,0 +1,620 @@
+package grammar
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	. "github.com/shellyln/takenoco/base"
+	clsz "github.com/shellyln/takenoco/grammar/classes"
+	. "github.com/shellyln/takenoco/string"
+)
+
+// Concrete types of the interface.
+type Union struct {
+	// Pointer to the interface. (e.g. `(*Expr)(nil)`)
+	Interface interface{}
+	// Prototypes of the member types. They are tried in order. (e.g. `Number{}`, `&Ident{}`)
+	Members []interface{}
+}
+
+// Options of Build().
+type Options struct {
+	// User-defined terminals by name. They override the built-in terminals.
+	// The parser should push the string ASTs, or a single AST of any value.
+	Terminals map[string]ParserFn
+	// Concrete types of the interfaces captured by `@@`.
+	Unions []Union
+	// Parser of the skipped text. (e.g. whitespaces and comments)
+	// It is applied zero or more times before each token. If it is nil, Whitespace() is used.
+	Skip ParserFn
+}
+
+// Parser generated from the annotated struct.
+type Parser struct {
+	root reflect.Type
+	rule ParserFn
+	doc  ParserFn
+}
+
+var sourcePositionType = reflect.TypeOf(SourcePosition{})
+
+// Builder state
+type builder struct {
+	opts      Options
+	skip      ParserFn
+	terminals map[string]ParserFn
+	unions    map[reflect.Type][]reflect.Type
+	types     map[reflect.Type]ParserFn
+}
+
+// Context of the field being built.
+type fieldContext struct {
+	owner reflect.Type
+	index int
+	field reflect.StructField
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c)
+}
+
+func isIdentRest(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// Make the transformer that unquotes the Go string literal.
+func unquote(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	s, err := strconv.Unquote(asts[0].Value.(string))
+	if err != nil {
+		return asts, NewTransformError(ctx, asts, errors.New("Bad string literal"))
+	}
+	asts[0].Value = s
+	return asts[:1], nil
+}
+
+// Built-in terminals.
+func builtinTerminals() map[string]ParserFn {
+	digits := OneOrMoreTimes(Number())
+	exponent := FlatGroup(CharClass("e", "E"), ZeroOrOnce(CharClass("+", "-")), digits)
+
+	ident := Trans(
+		FlatGroup(CharClassFn(isIdentStart), ZeroOrMoreTimes(CharClassFn(isIdentRest))),
+		Token(clsz.Token),
+	)
+	integer := Trans(digits, Token(clsz.Token))
+	float := Trans(
+		FlatGroup(
+			digits,
+			First(
+				FlatGroup(Seq("."), digits, ZeroOrOnce(exponent)),
+				exponent,
+			),
+		),
+		Token(clsz.Token),
+	)
+	str := Trans(
+		FlatGroup(
+			Seq("\""),
+			ZeroOrMoreTimes(First(
+				FlatGroup(Seq("\\"), Any()),
+				CharClassN("\"", "\\", "\n"),
+			)),
+			Seq("\""),
+		),
+		Token(clsz.Token),
+		unquote,
+	)
+
+	return map[string]ParserFn{
+		// Identifier. (letters, digits and `_`, not starting with a digit)
+		"Ident": ident,
+		// Decimal integer.
+		"Int": integer,
+		// Decimal float number. (with `.` or the exponent)
+		"Float": float,
+		// Float or Int.
+		"Number": First(float, integer),
+		// Double-quoted Go string literal. The captured value is unquoted.
+		"String": str,
+	}
+}
+
+// Convert the user-defined terminal's ASTs to a token.
+func userToken(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	if len(asts) == 1 {
+		if _, ok := asts[0].Value.(string); !ok {
+			asts[0].ClassName = clsz.Token
+			return asts, nil
+		}
+	}
+	for _, ast := range asts {
+		if _, ok := ast.Value.(string); !ok {
+			return asts, NewTransformError(ctx, asts, errors.New("Terminal should push the string ASTs"))
+		}
+	}
+	if len(asts) == 0 {
+		return AstSlice{{
+			ClassName:      clsz.Token,
+			Type:           AstType_String,
+			Value:          "",
+			SourcePosition: SourcePosition{Position: ctx.Position, FileId: ctx.FileId},
+		}}, nil
+	}
+	return Token(clsz.Token)(ctx, asts)
+}
+
+// Get the parser of the terminal.
+func (b *builder) terminal(name string) (ParserFn, error) {
+	if fn, ok := b.opts.Terminals[name]; ok {
+		return FlatGroup(b.skip, Trans(fn, userToken)), nil
+	}
+	if fn, ok := b.terminals[name]; ok {
+		return FlatGroup(b.skip, fn), nil
+	}
+	return nil, errors.New("Unknown terminal: " + name)
+}
+
+// Get the parser of the literal. The literal ending with an identifier character does not match the prefix of a word.
+func (b *builder) literal(s string) ParserFn {
+	fn := Seq(s)
+	if c, _ := utf8.DecodeLastRuneInString(s); isIdentRest(c) {
+		fn = FlatGroup(fn, LookAheadN(CharClassFn(isIdentRest)))
+	}
+	return FlatGroup(b.skip, Trans(fn, Token(clsz.Token)))
+}
+
+// Make the transformer that captures the matched text into the field.
+func (b *builder) capture(fc fieldContext) TransformerFn {
+	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		ast := Ast{
+			ClassName:      clsz.Field,
+			OpCode:         AstOpCodeType(fc.index),
+			Type:           AstType_String,
+			SourcePosition: SpanOf(ctx, asts),
+		}
+		if len(asts) == 1 {
+			if _, ok := asts[0].Value.(string); !ok {
+				ast.Type = AstType_Any
+				ast.Value = asts[0].Value
+				return AstSlice{ast}, nil
+			}
+		}
+		var sb strings.Builder
+		for _, w := range asts {
+			s, ok := w.Value.(string)
+			if !ok {
+				return asts, NewTransformError(ctx, asts, errors.New("Cannot capture the non-string value"))
+			}
+			sb.WriteString(s)
+		}
+		ast.Value = sb.String()
+		return AstSlice{ast}, nil
+	}
+}
+
+// Make the transformer that captures the node into the field.
+func (b *builder) captureNode(fc fieldContext) TransformerFn {
+	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		w := asts[0]
+		w.ClassName = clsz.Field
+		w.OpCode = AstOpCodeType(fc.index)
+		return AstSlice{w}, nil
+	}
+}
+
+// Test whether the tag expression contains the captures.
+func hasCapture(node Ast) bool {
+	switch node.ClassName {
+	case clsz.Capture, clsz.CaptureStruct:
+		return true
+	}
+	if node.Type == AstType_ListOfAst {
+		for _, child := range node.Value.(AstSlice) {
+			if hasCapture(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Make the error of the field.
+func fieldError(fc fieldContext, err error) error {
+	return fmt.Errorf("%s.%s: %w", fc.owner, fc.field.Name, err)
+}
+
+// Build the parser from the tag expression.
+func (b *builder) expr(node Ast, fc fieldContext) (ParserFn, error) {
+	switch node.ClassName {
+	case clsz.Literal:
+		s, err := UnquoteString(node.Value.(string))
+		if err != nil || s == "" {
+			return nil, fieldError(fc, errors.New("Bad literal: "+node.Value.(string)))
+		}
+		return b.literal(s), nil
+
+	case clsz.Terminal:
+		fn, err := b.terminal(node.Value.(string))
+		if err != nil {
+			return nil, fieldError(fc, err)
+		}
+		return fn, nil
+
+	case clsz.CaptureStruct:
+		t := fc.field.Type
+		if t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		fn, err := b.typeParser(t)
+		if err != nil {
+			return nil, fieldError(fc, err)
+		}
+		return Trans(fn, b.captureNode(fc)), nil
+	}
+
+	children := node.Value.(AstSlice)
+	fns := make([]ParserFn, 0, len(children))
+	for _, child := range children {
+		fn, err := b.expr(child, fc)
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+	}
+
+	switch node.ClassName {
+	case clsz.Alt:
+		if len(fns) == 1 {
+			return fns[0], nil
+		}
+		return First(fns...), nil
+	case clsz.Seq:
+		if len(fns) == 1 {
+			return fns[0], nil
+		}
+		return FlatGroup(fns...), nil
+	case clsz.Group:
+		return fns[0], nil
+	case clsz.Optional:
+		return ZeroOrOnce(fns...), nil
+	case clsz.ZeroOrMore:
+		return ZeroOrMoreTimes(fns...), nil
+	case clsz.OneOrMore:
+		return OneOrMoreTimes(fns...), nil
+	case clsz.Capture:
+		if hasCapture(children[0]) {
+			return nil, fieldError(fc, errors.New("Nested capture"))
+		}
+		return Trans(fns[0], b.capture(fc)), nil
+	default:
+		return nil, fieldError(fc, errors.New("Unknown expression: "+node.ClassName))
+	}
+}
+
+// Get the parser of the type captured by `@@`.
+func (b *builder) typeParser(t reflect.Type) (ParserFn, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if fn, ok := b.types[t]; ok {
+		return fn, nil
+	}
+
+	var built ParserFn
+	b.types[t] = Indirect(func() ParserFn {
+		return built
+	})
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fn, err := b.structParser(t)
+		if err != nil {
+			return nil, err
+		}
+		built = fn
+	case reflect.Interface:
+		members, ok := b.unions[t]
+		if !ok {
+			return nil, errors.New("No union is registered for the interface " + t.String())
+		}
+		fns := make([]ParserFn, 0, len(members))
+		for _, m := range members {
+			fn, err := b.typeParser(m)
+			if err != nil {
+				return nil, err
+			}
+			if m.Kind() != reflect.Ptr {
+				fn = Trans(fn, dereference)
+			}
+			fns = append(fns, fn)
+		}
+		built = First(fns...)
+	default:
+		return nil, errors.New("Type " + t.String() + " cannot be captured by @@")
+	}
+	return b.types[t], nil
+}
+
+// Build the parser of the struct. It is the sequence of the field tags.
+// The field tag starting with `|` starts the alternative of the preceding fields.
+func (b *builder) structParser(t reflect.Type) (ParserFn, error) {
+	alts := make([]ParserFn, 0, 1)
+	fns := make([]ParserFn, 0, t.NumField())
+	posIndex := -1
+	numFields := 0
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("parse")
+		if !ok {
+			if f.Name == "Pos" && f.Type == sourcePositionType {
+				posIndex = i
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		fc := fieldContext{owner: t, index: i, field: f}
+		if f.PkgPath != "" {
+			return nil, fieldError(fc, errors.New("Field should be exported"))
+		}
+
+		node, or, err := parseTag(tag)
+		if err != nil {
+			return nil, fieldError(fc, err)
+		}
+		if or {
+			if len(fns) == 0 {
+				return nil, fieldError(fc, errors.New("No preceding fields of '|'"))
+			}
+			alts = append(alts, FlatGroup(fns...))
+			fns = make([]ParserFn, 0, t.NumField()-i)
+		}
+		fn, err := b.expr(node, fc)
+		if err != nil {
+			return nil, err
+		}
+		fns = append(fns, fn)
+		numFields++
+	}
+	if numFields == 0 {
+		return nil, errors.New("Struct " + t.String() + " has no parse tag")
+	}
+	alts = append(alts, FlatGroup(fns...))
+
+	var fn ParserFn
+	if len(alts) == 1 {
+		fn = alts[0]
+	} else {
+		fn = First(alts...)
+	}
+	return Trans(fn, makeNode(t, posIndex)), nil
+}
+
+// Make the transformer that fills the struct with the captured fields.
+// The result is an AST of AstType_Any, and its value is the pointer to the struct.
+func makeNode(t reflect.Type, posIndex int) TransformerFn {
+	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		pv := reflect.New(t)
+		v := pv.Elem()
+		pos := SpanOf(ctx, asts)
+
+		for _, ast := range asts {
+			if ast.ClassName != clsz.Field {
+				continue
+			}
+			f := t.Field(int(ast.OpCode))
+			var err error
+			if s, ok := ast.Value.(string); ok && ast.Type == AstType_String {
+				err = assignText(v.Field(int(ast.OpCode)), s)
+			} else {
+				err = assignValue(v.Field(int(ast.OpCode)), reflect.ValueOf(ast.Value))
+			}
+			if err != nil {
+				return asts, NewParseError(ast.SourcePosition, clsz.Field, t.String()+"."+f.Name+": "+err.Error())
+			}
+		}
+		if 0 <= posIndex {
+			v.Field(posIndex).Set(reflect.ValueOf(pos))
+		}
+
+		return AstSlice{{
+			ClassName:      clsz.Node,
+			Type:           AstType_Any,
+			Value:          pv.Interface(),
+			SourcePosition: pos,
+		}}, nil
+	}
+}
+
+// Transform the pointer to the struct into the struct value. (for the non-pointer union members)
+func dereference(_ ParserContext, asts AstSlice) (AstSlice, error) {
+	asts[0].Value = reflect.ValueOf(asts[0].Value).Elem().Interface()
+	return asts, nil
+}
+
+// Set the captured text to the field. The strings are concatenated, and the slices are appended.
+func assignText(fv reflect.Value, s string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(fv.String() + s)
+	case reflect.Bool:
+		fv.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Ptr:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return assignText(fv.Elem(), s)
+	case reflect.Slice:
+		elem := reflect.New(fv.Type().Elem()).Elem()
+		if err := assignText(elem, s); err != nil {
+			return err
+		}
+		fv.Set(reflect.Append(fv, elem))
+	default:
+		return errors.New("Cannot capture the text into " + fv.Type().String())
+	}
+	return nil
+}
+
+// Set the captured value to the field. The value of the struct is the pointer.
+func assignValue(fv reflect.Value, v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	switch {
+	case v.Type().AssignableTo(fv.Type()):
+		fv.Set(v)
+	case v.Kind() == reflect.Ptr && v.Elem().Type().AssignableTo(fv.Type()):
+		fv.Set(v.Elem())
+	case fv.Kind() == reflect.Slice:
+		elem := reflect.New(fv.Type().Elem()).Elem()
+		if err := assignValue(elem, v); err != nil {
+			return err
+		}
+		fv.Set(reflect.Append(fv, elem))
+	case v.Kind() != reflect.Ptr && v.Type().ConvertibleTo(fv.Type()):
+		fv.Set(v.Convert(fv.Type()))
+	default:
+		return errors.New("Cannot capture " + v.Type().String() + " into " + fv.Type().String())
+	}
+	return nil
+}
+
+// Build the parser from the annotated struct.
+// proto is the struct or the pointer to the struct. (e.g. `&Program{}`)
+//
+//	type Assign struct {
+//		Name  string `parse:"@Ident"`
+//		Value Expr   `parse:"'=' @@"`
+//	}
+//
+// The tag of each field is an expression, and the struct is the sequence of them.
+//
+//	'lit' "lit"  Literal. The literal ending with an identifier character does not match the prefix of a word.
+//	Name         Terminal. (Ident, Int, Float, Number, String, and Options.Terminals)
+//	@expr        Capture the matched text into the field.
+//	             The strings are concatenated, the numbers are parsed, bool is set to true, and the slices are appended.
+//	@@           Parse the type of the field (struct, pointer to struct, interface of Options.Unions, or slice of them).
+//	( expr )     Group.
+//	a b          Sequence.
+//	a | b        Alternatives. The first matched one is used.
+//	expr? expr* expr+
+//	             Quantifiers.
+//
+// If the tag starts with `|`, the field is the alternative of the preceding fields.
+//
+//	type Value struct {
+//		Number *float64 `parse:"  @Number"`
+//		Str    *string  `parse:"| @String"`
+//	}
+//
+// The untagged field `Pos SourcePosition` is set to the span of the struct.
+func Build(proto interface{}, opts Options) (*Parser, error) {
+	t := reflect.TypeOf(proto)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("Prototype should be struct or pointer to struct")
+	}
+
+	b := &builder{
+		opts:      opts,
+		terminals: builtinTerminals(),
+		unions:    make(map[reflect.Type][]reflect.Type),
+		types:     make(map[reflect.Type]ParserFn),
+	}
+	if opts.Skip != nil {
+		b.skip = Erased(ZeroOrMoreTimes(opts.Skip))
+	} else {
+		b.skip = Spaces()
+	}
+	for _, u := range opts.Unions {
+		it := reflect.TypeOf(u.Interface)
+		if it == nil || it.Kind() != reflect.Ptr || it.Elem().Kind() != reflect.Interface {
+			return nil, errors.New("Union.Interface should be pointer to interface")
+		}
+		it = it.Elem()
+		for _, m := range u.Members {
+			mt := reflect.TypeOf(m)
+			if mt == nil || !mt.Implements(it) && !reflect.PtrTo(mt).Implements(it) {
+				return nil, fmt.Errorf("Union member %v does not implement %v", mt, it)
+			}
+			b.unions[it] = append(b.unions[it], mt)
+		}
+	}
+
+	rule, err := b.typeParser(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Parser{
+		root: t,
+		rule: rule,
+		doc: FlatGroup(
+			Start(),
+			rule,
+			b.skip,
+			First(End(), Error("Unexpected character")),
+		),
+	}, nil
+}
+
+// Build the parser from the annotated struct. It panics if an error occurs.
+func MustBuild(proto interface{}, opts Options) *Parser {
+	p, err := Build(proto, opts)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Generated parser of the root struct.
+// It pushes an AST of AstType_Any, and its value is the pointer to the struct.
+func (p *Parser) Rule() ParserFn {
+	return p.rule
+}
+
+// Parse the whole string into the struct that v points to.
+// The returned error is a *ParseError located in the source.
+func (p *Parser) ParseString(s string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Type() != p.root {
+		return errors.New("Out parameter should be pointer to " + p.root.String())
+	}
+
+	out, err := p.doc(*NewStringParserContext(s))
+	if err != nil {
+		return ToParseError(out.SourcePosition, "", err).Locate(s, 4)
+	}
+	if out.MatchStatus != MatchStatus_Matched {
+		return NewParseError(out.SourcePosition, out.ClassName, "Parse failed").Locate(s, 4)
+	}
+	rv.Elem().Set(reflect.ValueOf(out.AstStack[0].Value).Elem())
+	return nil
+}
@@ -0,0 +1,208 @@
+package grammar
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/string"
+)
+
+type testProgram struct {
+	Pos   SourcePosition
+	Stmts []testStmt `parse:"@@*"`
+}
+
+type testStmt interface {
+	stmt()
+}
+
+type testAssign struct {
+	Const bool      `parse:"@'const'?"`
+	Name  string    `parse:"@Ident"`
+	Value *testExpr `parse:"'=' @@ ';'"`
+}
+
+func (testAssign) stmt() {}
+
+type testPrint struct {
+	Args []testExpr `parse:"'print' '(' (@@ (',' @@)*)? ')' ';'"`
+}
+
+func (*testPrint) stmt() {}
+
+type testExpr struct {
+	Left testTerm     `parse:"@@"`
+	Ops  []testOpTerm `parse:"@@*"`
+}
+
+type testOpTerm struct {
+	Op   string   `parse:"@('+' | '-')"`
+	Term testTerm `parse:"@@"`
+}
+
+type testTerm struct {
+	Number *float64  `parse:"  @Number"`
+	Str    *string   `parse:"| @String"`
+	Ident  string    `parse:"| @Ident"`
+	Sub    *testExpr `parse:"| '(' @@ ')'"`
+}
+
+func newTestParser() *Parser {
+	return MustBuild(&testProgram{}, Options{
+		Unions: []Union{{
+			Interface: (*testStmt)(nil),
+			Members:   []interface{}{&testPrint{}, testAssign{}},
+		}},
+	})
+}
+
+func TestParseString(t *testing.T) {
+	p := newTestParser()
+
+	src := "const x = 1.5 + (y - 2);\nprint(\"a\\tb\", x);\nprinter = x;"
+	var got testProgram
+	if err := p.ParseString(src, &got); err != nil {
+		t.Fatalf("ParseString() error = %v", err)
+	}
+
+	f := func(v float64) *float64 { return &v }
+	s := func(v string) *string { return &v }
+	want := testProgram{
+		Pos: SourcePosition{Position: 0, Length: len(src)},
+		Stmts: []testStmt{
+			testAssign{
+				Const: true,
+				Name:  "x",
+				Value: &testExpr{
+					Left: testTerm{Number: f(1.5)},
+					Ops: []testOpTerm{{Op: "+", Term: testTerm{Sub: &testExpr{
+						Left: testTerm{Ident: "y"},
+						Ops:  []testOpTerm{{Op: "-", Term: testTerm{Number: f(2)}}},
+					}}}},
+				},
+			},
+			&testPrint{Args: []testExpr{
+				{Left: testTerm{Str: s("a\tb")}},
+				{Left: testTerm{Ident: "x"}},
+			}},
+			testAssign{
+				Name:  "printer",
+				Value: &testExpr{Left: testTerm{Ident: "x"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseString() = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseStringError(t *testing.T) {
+	p := newTestParser()
+
+	var got testProgram
+	err := p.ParseString("x = 1;\ny = ;", &got)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("ParseString() error = %v", err)
+	}
+	if perr.Line != 2 || perr.Col != 1 {
+		t.Errorf("ParseString() error = %v", err)
+	}
+
+	if err := p.ParseString("", &struct{}{}); err == nil {
+		t.Errorf("ParseString() out parameter error = nil")
+	}
+}
+
+type testKeyValue struct {
+	Key   string   `parse:"@Ident ':'"`
+	Value int      `parse:"@Int"`
+	Flags []string `parse:"('[' @Flag (',' @Flag)* ']')?"`
+}
+
+func TestCustomTerminal(t *testing.T) {
+	p := MustBuild(testKeyValue{}, Options{
+		Terminals: map[string]ParserFn{
+			"Flag": OneOrMoreTimes(CharClass("a", "b", "c")),
+		},
+		Skip: First(Whitespace(), FlatGroup(Seq("#"), ZeroOrMoreTimes(CharClassN("\n")))),
+	})
+
+	var got testKeyValue
+	if err := p.ParseString("size # comment\n : 42 [ab, c]", &got); err != nil {
+		t.Fatalf("ParseString() error = %v", err)
+	}
+	want := testKeyValue{Key: "size", Value: 42, Flags: []string{"ab", "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseString() = %#v, want %#v", got, want)
+	}
+
+	ctx, err := p.Rule()(*NewStringParserContext("n:1"))
+	if err != nil || ctx.MatchStatus != MatchStatus_Matched {
+		t.Fatalf("Rule() = %v, %v", ctx.MatchStatus, err)
+	}
+	if v, ok := ctx.AstStack[0].Value.(*testKeyValue); !ok || v.Key != "n" || v.Value != 1 {
+		t.Errorf("Rule() = %#v", ctx.AstStack[0].Value)
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	tests := []struct {
+		name  string
+		proto interface{}
+		opts  Options
+		msg   string
+	}{
+		{"bad tag", &struct {
+			A string `parse:"@(Ident"`
+		}{}, Options{}, "')' is expected"},
+		{"unknown terminal", &struct {
+			A string `parse:"@Foo"`
+		}{}, Options{}, "Unknown terminal: Foo"},
+		{"nested capture", &struct {
+			A string `parse:"@(@Ident)"`
+		}{}, Options{}, "Nested capture"},
+		{"no union", &struct {
+			A testStmt `parse:"@@"`
+		}{}, Options{}, "No union is registered"},
+		{"leading or", &struct {
+			A string `parse:"| @Ident"`
+		}{}, Options{}, "No preceding fields"},
+		{"no tag", &struct{ A string }{}, Options{}, "has no parse tag"},
+		{"not struct", 1, Options{}, "Prototype should be struct"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build(tt.proto, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Build() error = %v, want %v", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseTagSpan(t *testing.T) {
+	node, _, err := parseTag(`'a' @( Ident | Number )* "b"`)
+	if err != nil {
+		t.Fatalf("parseTag() err = %v", err)
+	}
+
+	if got, want := node.SourcePosition, (SourcePosition{Position: 0, Length: 28}); got != want {
+		t.Errorf("alt span = %+v, want %+v", got, want)
+	}
+	seq := node.Value.(AstSlice)[0]
+	if got, want := seq.SourcePosition, (SourcePosition{Position: 0, Length: 28}); got != want {
+		t.Errorf("seq span = %+v, want %+v", got, want)
+	}
+	many := seq.Value.(AstSlice)[1]
+	if got, want := many.SourcePosition, (SourcePosition{Position: 4, Length: 20}); got != want {
+		t.Errorf("quantified span = %+v, want %+v", got, want)
+	}
+	group := many.Value.(AstSlice)[0].Value.(AstSlice)[0]
+	if got, want := group.SourcePosition, (SourcePosition{Position: 5, Length: 18}); got != want {
+		t.Errorf("group span = %+v, want %+v", got, want)
+	}
+}
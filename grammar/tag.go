@@ -0,0 +1,135 @@
+package grammar
+
+import (
+	. "github.com/shellyln/takenoco/base"
+	clsz "github.com/shellyln/takenoco/grammar/classes"
+	. "github.com/shellyln/takenoco/string"
+)
+
+var (
+	tagParser ParserFn
+)
+
+func init() {
+	tagParser = tagRule()
+}
+
+// Terminal name (e.g. `Ident`)
+func terminalRule() ParserFn {
+	return Trans(
+		FlatGroup(
+			First(Alpha(), Seq("_")),
+			ZeroOrMoreTimes(First(Alnum(), Seq("_"))),
+		),
+		Token(clsz.Terminal),
+	)
+}
+
+func atomRule() ParserFn {
+	return First(
+		Trans(
+			FlatGroup(
+				Erased(Seq("(")),
+				Spaces(),
+				Indirect(altRule),
+				Spaces(),
+				First(Seq(")"), Error("')' is expected")),
+			),
+			Enclosed(clsz.Group),
+		),
+		Trans(Quoted("'"), Token(clsz.Literal)),
+		Trans(Quoted("\""), Token(clsz.Literal)),
+		terminalRule(),
+	)
+}
+
+// Capture or atom, and the quantifier
+func termRule() ParserFn {
+	return Trans(
+		FlatGroup(
+			First(
+				Trans(Seq("@@"), Token(clsz.CaptureStruct)),
+				Trans(
+					FlatGroup(
+						Erased(Seq("@")),
+						Spaces(),
+						First(atomRule(), Error("An expression is expected after '@'")),
+					),
+					GroupAs(clsz.Capture),
+				),
+				atomRule(),
+			),
+			ZeroOrOnce(Spaces(), First(
+				Trans(Seq("?"), Token(clsz.Optional)),
+				Trans(Seq("*"), Token(clsz.ZeroOrMore)),
+				Trans(Seq("+"), Token(clsz.OneOrMore)),
+			)),
+		),
+		quantify,
+	)
+}
+
+// Wrap the term with the quantifier.
+func quantify(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	if len(asts) == 1 {
+		return asts, nil
+	}
+	return AstSlice{{
+		ClassName:      asts[1].ClassName,
+		Type:           AstType_ListOfAst,
+		Value:          AstSlice{asts[0]},
+		SourcePosition: SpanOf(ctx, asts),
+	}}, nil
+}
+
+func seqRule() ParserFn {
+	return Trans(
+		FlatGroup(
+			termRule(),
+			ZeroOrMoreTimes(Spaces(), termRule()),
+		),
+		GroupAs(clsz.Seq),
+	)
+}
+
+func altRule() ParserFn {
+	return Trans(
+		FlatGroup(
+			seqRule(),
+			ZeroOrMoreTimes(
+				Spaces(),
+				Erased(Seq("|")),
+				Spaces(),
+				First(seqRule(), Error("An expression is expected after '|'")),
+			),
+		),
+		GroupAs(clsz.Alt),
+	)
+}
+
+func tagRule() ParserFn {
+	return FlatGroup(
+		Start(),
+		Spaces(),
+		ZeroOrOnce(Trans(Seq("|"), Token(clsz.Or)), Spaces()),
+		altRule(),
+		Spaces(),
+		First(End(), Error("Unexpected character")),
+	)
+}
+
+// Parse the `parse` tag of the field.
+// The result `or` is true if the tag starts with `|`.
+func parseTag(tag string) (node Ast, or bool, err error) {
+	out, err := tagParser(*NewStringParserContext(tag))
+	if err != nil {
+		return Ast{}, false, ToParseError(out.SourcePosition, "", err).Locate(tag, 4)
+	}
+	if out.MatchStatus != MatchStatus_Matched {
+		return Ast{}, false, NewParseError(out.SourcePosition, out.ClassName, "Parse failed").Locate(tag, 4)
+	}
+	if out.AstStack[0].ClassName == clsz.Or {
+		return out.AstStack[1], true, nil
+	}
+	return out.AstStack[0], false, nil
+}
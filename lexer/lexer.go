@@ -0,0 +1,146 @@
+package lexer
+
+import (
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Token rule of the lexer.
+type TokenRule struct {
+	// Class name of the token.
+	ClassName string
+	// Opcode of the token.
+	OpCode AstOpCodeType
+	// String parser that matches the token.
+	Parser ParserFn
+	// If true, the matched token is not emitted. (e.g. whitespaces and comments)
+	Skip bool
+}
+
+// Type of the lexer function.
+type LexerFn func(s string) (AstSlice, error)
+
+// Type of the lexer function that lexes the source of the context.
+type ContextLexerFn func(ctx ParserContext) (AstSlice, error)
+
+// Build the lexer from the token rules.
+//
+// At each position, the rule that matches the longest text is used.
+// If two or more rules match the same length, the first one is used.
+// If the rule's parser produces exactly one AST, its type and value become the token's.
+// Otherwise, the token's value is the matched text.
+// The tokens have the source positions in the source string.
+func Lexer(rules ...TokenRule) LexerFn {
+	lexer := ContextLexer(rules...)
+	return func(s string) (AstSlice, error) {
+		tokens, err := lexer(ParserContext{Str: s})
+		if perr, ok := err.(*ParseError); ok {
+			pc := GetLineAndColPosition(s, perr.SourcePosition, 4)
+			perr.Line = pc.Line
+			perr.Col = pc.Col
+		}
+		return tokens, err
+	}
+}
+
+// Build the lexer from the token rules. It is the same as Lexer(),
+// but lexes ctx.Str from ctx.Position, and the tokens and the error have ctx.FileId.
+// The error is not located. Call SourceSet.Locate() or ParseError.Locate() with the tab size.
+func ContextLexer(rules ...TokenRule) ContextLexerFn {
+	return func(ctx ParserContext) (AstSlice, error) {
+		s := ctx.Str
+		tokens := make(AstSlice, 0, (len(s)-ctx.Position)/4+1)
+		stack := make(AstSlice, 0, 16)
+
+		for pos := ctx.Position; pos < len(s); {
+			var best Ast
+			bestRule := -1
+			bestEnd := pos
+
+			for i, rule := range rules {
+				out, err := rule.Parser(ParserContext{
+					Str:      s,
+					AstStack: stack[:0],
+					SourcePosition: SourcePosition{
+						Position: pos,
+						FileId:   ctx.FileId,
+					},
+				})
+				if err != nil {
+					return tokens, err
+				}
+				stack = out.AstStack[:0]
+
+				if out.MatchStatus != MatchStatus_Matched || out.Position <= bestEnd {
+					continue
+				}
+
+				bestRule = i
+				bestEnd = out.Position
+				if len(out.AstStack) == 1 {
+					best = out.AstStack[0]
+				} else {
+					best = Ast{
+						Type:  AstType_String,
+						Value: s[pos:out.Position],
+					}
+				}
+			}
+
+			if bestRule < 0 {
+				return tokens, NewParseError(SourcePosition{Position: pos, Length: 1, FileId: ctx.FileId}, "", "No token rule is matched")
+			}
+
+			rule := &rules[bestRule]
+			if !rule.Skip {
+				tokens = append(tokens, Ast{
+					OpCode:    rule.OpCode,
+					ClassName: rule.ClassName,
+					Type:      best.Type,
+					Value:     best.Value,
+					SourcePosition: SourcePosition{
+						Position: pos,
+						Length:   bestEnd - pos,
+						FileId:   ctx.FileId,
+					},
+				})
+			}
+			pos = bestEnd
+		}
+
+		return tokens, nil
+	}
+}
+
+// Convert the position in the token slice to the position in the source string.
+// pos.Position is the index of the token, and pos.Length is the number of the tokens.
+func SourcePositionOf(tokens AstSlice, pos SourcePosition) SourcePosition {
+	length := len(tokens)
+	if length == 0 {
+		return SourcePosition{}
+	}
+	if length <= pos.Position {
+		last := tokens[length-1]
+		return SourcePosition{
+			Position: last.Position + last.Length,
+			FileId:   last.FileId,
+		}
+	}
+
+	first := tokens[pos.Position]
+	end := pos.Position + pos.Length
+	if length < end {
+		end = length
+	}
+	if end <= pos.Position {
+		return SourcePosition{
+			Position: first.Position,
+			FileId:   first.FileId,
+		}
+	}
+	last := tokens[end-1]
+	return SourcePosition{
+		Position: first.Position,
+		Length:   last.Position + last.Length - first.Position,
+		FileId:   first.FileId,
+	}
+}
@@ -0,0 +1,170 @@
+package lexer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/lexer"
+	objparser "github.com/shellyln/takenoco/object"
+	. "github.com/shellyln/takenoco/string"
+)
+
+func testRules() []TokenRule {
+	return []TokenRule{{
+		ClassName: "Space",
+		Parser:    OneOrMoreTimes(Whitespace()),
+		Skip:      true,
+	}, {
+		ClassName: "Comment",
+		Parser:    FlatGroup(Seq("#"), ZeroOrMoreTimes(CharClassN("\n"))),
+		Skip:      true,
+	}, {
+		ClassName: "Keyword",
+		Parser:    Trans(FlatGroup(Seq("let"), WordBoundary()), Concat),
+	}, {
+		ClassName: "Ident",
+		Parser:    Trans(OneOrMoreTimes(Alpha()), Concat),
+	}, {
+		ClassName: "Number",
+		Parser:    Trans(OneOrMoreTimes(Number()), ParseInt),
+	}, {
+		ClassName: "Punct",
+		Parser:    CharClass("=", "+", ";"),
+	}}
+}
+
+func testLexer() LexerFn {
+	return Lexer(testRules()...)
+}
+
+func TestLexer(t *testing.T) {
+	tokens, err := testLexer()("let x = 1 + 23; # comment\nlet letter = x;")
+	if err != nil {
+		t.Errorf("Lexer() err is %v", err)
+		return
+	}
+
+	want := []struct {
+		className string
+		value     interface{}
+		pos       int
+		length    int
+	}{
+		{"Keyword", "let", 0, 3},
+		{"Ident", "x", 4, 1},
+		{"Punct", "=", 6, 1},
+		{"Number", int64(1), 8, 1},
+		{"Punct", "+", 10, 1},
+		{"Number", int64(23), 12, 2},
+		{"Punct", ";", 14, 1},
+		{"Keyword", "let", 26, 3},
+		{"Ident", "letter", 30, 6},
+		{"Punct", "=", 37, 1},
+		{"Ident", "x", 39, 1},
+		{"Punct", ";", 40, 1},
+	}
+
+	if len(tokens) != len(want) {
+		t.Errorf("Lexer() = %v", tokens)
+		return
+	}
+	for i, w := range want {
+		g := tokens[i]
+		if g.ClassName != w.className || g.Value != w.value || g.Position != w.pos || g.Length != w.length {
+			t.Errorf("Lexer()[%v] = %v, want %v", i, g, w)
+		}
+	}
+}
+
+func TestLexerError(t *testing.T) {
+	_, err := testLexer()("let x = 1;\nlet y = ?;")
+	if err == nil {
+		t.Errorf("Lexer() err is nil")
+		return
+	}
+	if err.Error() != "No token rule is matched at Line 2, Col 9" {
+		t.Errorf("Lexer() err is %v", err)
+	}
+}
+
+func TestTokenParser(t *testing.T) {
+	src := "let x = 1 + 23;\nlet y = x + ;"
+	tokens, err := testLexer()(src)
+	if err != nil {
+		t.Errorf("Lexer() err is %v", err)
+		return
+	}
+
+	operand := First(
+		objparser.Token("Number"),
+		objparser.Token("Ident"),
+	)
+	statement := Group(
+		Trans(objparser.TokenValue("Keyword", "let"), Erase),
+		objparser.Token("Ident"),
+		Trans(objparser.TokenValue("Punct", "="), Erase),
+		operand,
+		ZeroOrMoreTimes(
+			Trans(objparser.TokenValue("Punct", "+"), Erase),
+			First(operand, Error("Operand required")),
+		),
+		Trans(objparser.TokenValue("Punct", ";"), Erase),
+	)
+	program := FlatGroup(Start(), ZeroOrMoreTimes(statement), objparser.End())
+
+	out, err := program(*objparser.NewObjectParserContext(tokens))
+	if err == nil {
+		t.Errorf("program() err is nil")
+		return
+	}
+
+	pos := SourcePositionOf(tokens, out.SourcePosition)
+	pc := GetLineAndColPosition(src, pos, 4)
+	if pc.Line != 2 || pc.Col != 13 {
+		t.Errorf("program() error position is Line %v, Col %v", pc.Line, pc.Col)
+	}
+
+	out, err = program(*objparser.NewObjectParserContext(tokens[:7]))
+	if err != nil || out.MatchStatus != MatchStatus_Matched {
+		t.Errorf("program() err is %v, status is %v", err, out.MatchStatus)
+		return
+	}
+	stmt := out.AstStack[0].Value.(AstSlice)
+	if len(stmt) != 3 || stmt[0].Value != "x" || stmt[2].Value != int64(23) || stmt[2].Position != 12 {
+		t.Errorf("program() = %v", stmt)
+	}
+}
+
+func TestContextLexer(t *testing.T) {
+	ss := NewSourceSet()
+	ss.Add("main.txt", "let a = 1;")
+	lib := ss.Add("lib.txt", "let x = 1;\nlet y = ?;")
+
+	tokens, err := ContextLexer(testRules()...)(*NewSourceFileParserContext(lib))
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("ContextLexer() err is %v", err)
+	}
+	if perr.FileId != lib.Id {
+		t.Errorf("ContextLexer() err FileId = %v, want %v", perr.FileId, lib.Id)
+	}
+	if perr.Line != 0 {
+		t.Errorf("ContextLexer() err is located: %v", perr)
+	}
+	if msg := ss.Locate(perr, 4).Error(); !strings.HasPrefix(msg, "No token rule is matched at lib.txt:2:9\n") {
+		t.Errorf("ContextLexer() err is %q", msg)
+	}
+	if len(tokens) != 8 {
+		t.Fatalf("ContextLexer() = %v", tokens)
+	}
+	for i, token := range tokens {
+		if token.FileId != lib.Id {
+			t.Errorf("ContextLexer()[%v] FileId = %v, want %v", i, token.FileId, lib.Id)
+		}
+	}
+	if s := ss.FormatPosition(tokens[6].SourcePosition, 4, ColumnMode_Byte); s != "lib.txt:2:5" {
+		t.Errorf("FormatPosition() = %q", s)
+	}
+}
@@ -7,4 +7,6 @@ const (
 	ObjClass   = ":object:ObjClass"
 	ObjClassN  = ":object:ObjClassN"
 	ObjClassFn = ":object:ObjClassFn"
+	Token      = ":object:Token"
+	TokenValue = ":object:TokenValue"
 )
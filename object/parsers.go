@@ -1,6 +1,8 @@
 package objparser
 
 import (
+	"reflect"
+
 	. "github.com/shellyln/takenoco/base"
 	clsz "github.com/shellyln/takenoco/object/classes"
 )
@@ -149,3 +151,43 @@ func ObjClassFn(fn func(c interface{}) bool) ParserFn {
 		return ctx, nil
 	})
 }
+
+// Assertion that match a token of the class.
+// The token is an Ast produced by the lexer, and it is pushed to the AST stack as is.
+func Token(className string) ParserFn {
+	const ClassName = clsz.Token
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		ctx.MatchStatus = MatchStatus_Unmatched
+
+		if ctx.Position+1 <= ctx.Slice.Len() {
+			w, ok := ctx.Slice.Get(ctx.Position).(Ast)
+			if ok && w.ClassName == className {
+				ctx.AstStack = append(ctx.AstStack, w)
+				ctx.Position += 1
+				ctx.Length = 1
+				ctx.MatchStatus = MatchStatus_Matched
+			}
+		}
+		return ctx, nil
+	})
+}
+
+// Assertion that match a token of the class and the value.
+// The token is an Ast produced by the lexer, and it is pushed to the AST stack as is.
+func TokenValue(className string, v interface{}) ParserFn {
+	const ClassName = clsz.TokenValue
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		ctx.MatchStatus = MatchStatus_Unmatched
+
+		if ctx.Position+1 <= ctx.Slice.Len() {
+			w, ok := ctx.Slice.Get(ctx.Position).(Ast)
+			if ok && w.ClassName == className && reflect.DeepEqual(w.Value, v) {
+				ctx.AstStack = append(ctx.AstStack, w)
+				ctx.Position += 1
+				ctx.Length = 1
+				ctx.MatchStatus = MatchStatus_Matched
+			}
+		}
+		return ctx, nil
+	})
+}
@@ -0,0 +1,17 @@
+package classes
+
+const (
+	Query      = ":query:Query"
+	Selector   = ":query:Selector"
+	Step       = ":query:Step"
+	Child      = ":query:Child"
+	Descendant = ":query:Descendant"
+	Name       = ":query:Name"
+	Universal  = ":query:Universal"
+	Predicate  = ":query:Predicate"
+	Attr       = ":query:Attr"
+	Operator   = ":query:Operator"
+	String     = ":query:String"
+	Rune       = ":query:Rune"
+	Word       = ":query:Word"
+)
@@ -0,0 +1,334 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	. "github.com/shellyln/takenoco/base"
+	clsz "github.com/shellyln/takenoco/query/classes"
+	. "github.com/shellyln/takenoco/string"
+)
+
+var (
+	queryParser ParserFn
+)
+
+func init() {
+	queryParser = queryRule()
+}
+
+// Characters that cannot be used in the class names.
+const nameDelimiters = ",>[]()*=!<^$~+\"'|"
+
+// Class name
+func name() ParserFn {
+	return Trans(
+		OneOrMoreTimes(CharClassFn(func(c rune) bool {
+			return !unicode.IsSpace(c) && !strings.ContainsRune(nameDelimiters, c)
+		})),
+		Token(clsz.Name),
+	)
+}
+
+// Unquoted value (number, true, false, nil, type name) or index
+func word() ParserFn {
+	return Trans(
+		OneOrMoreTimes(CharClassFn(func(c rune) bool {
+			return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("_.+-", c)
+		})),
+		Token(clsz.Word),
+	)
+}
+
+// Comparison operator
+func operator() ParserFn {
+	return Trans(
+		First(
+			Seq("!="), Seq("^="), Seq("$="), Seq("*="), Seq("<="), Seq(">="),
+			Seq("="), Seq("<"), Seq(">"),
+		),
+		Token(clsz.Operator),
+	)
+}
+
+// `[attr]`, `[attr op value]` or `[index]`
+func predicateRule() ParserFn {
+	return Trans(
+		FlatGroup(
+			Erased(Seq("[")),
+			Spaces(),
+			First(
+				FlatGroup(
+					Trans(OneOrMoreTimes(Alpha()), Token(clsz.Attr)),
+					ZeroOrOnce(
+						Spaces(),
+						operator(),
+						Spaces(),
+						First(
+							Trans(Quoted("\""), Token(clsz.String)),
+							Trans(Quoted("'"), Token(clsz.Rune)),
+							word(),
+							Error("A value is expected"),
+						),
+					),
+				),
+				word(),
+				Error("An attribute or index is expected"),
+			),
+			Spaces(),
+			First(
+				Seq("]"),
+				Error("']' is expected"),
+			),
+		),
+		Enclosed(clsz.Predicate),
+	)
+}
+
+// Name test and predicates
+func stepRule() ParserFn {
+	return Trans(
+		First(
+			FlatGroup(
+				First(
+					Trans(Seq("*"), Token(clsz.Universal)),
+					name(),
+				),
+				ZeroOrMoreTimes(predicateRule()),
+			),
+			OneOrMoreTimes(predicateRule()),
+		),
+		GroupAs(clsz.Step),
+	)
+}
+
+// Combinator and the next step
+func combinator() ParserFn {
+	return First(
+		FlatGroup(
+			Trans(FlatGroup(Spaces(), Seq(">"), Spaces()), Token(clsz.Child)),
+			First(stepRule(), Error("A selector is expected")),
+		),
+		FlatGroup(
+			Trans(OneOrMoreTimes(Whitespace()), Token(clsz.Descendant)),
+			stepRule(),
+		),
+	)
+}
+
+func selectorRule() ParserFn {
+	return Trans(
+		FlatGroup(
+			ZeroOrOnce(Trans(FlatGroup(Seq(">"), Spaces()), Token(clsz.Child))),
+			First(stepRule(), Error("A selector is expected")),
+			ZeroOrMoreTimes(combinator()),
+		),
+		GroupAs(clsz.Selector),
+	)
+}
+
+func queryRule() ParserFn {
+	return FlatGroup(
+		Start(),
+		Spaces(),
+		selectorRule(),
+		ZeroOrMoreTimes(Spaces(), Erased(Seq(",")), Spaces(), selectorRule()),
+		Spaces(),
+		First(End(), Error("Unexpected character")),
+	)
+}
+
+// Compiler state
+type compiler struct {
+	src string
+}
+
+// Make the error at the position of the element.
+func (c *compiler) error(e Ast, msg string) error {
+	return NewParseError(e.SourcePosition, e.ClassName, msg).Locate(c.src, 4)
+}
+
+// Convert the selector element.
+func (c *compiler) selector(e Ast) (selector, error) {
+	items := e.Value.(AstSlice)
+	sel := selector{steps: make([]step, 0, len(items))}
+
+	ax := axis_Descendant
+	for _, item := range items {
+		switch item.ClassName {
+		case clsz.Child:
+			ax = axis_Child
+		case clsz.Descendant:
+			ax = axis_Descendant
+		case clsz.Step:
+			st, err := c.step(item)
+			if err != nil {
+				return sel, err
+			}
+			st.axis = ax
+			sel.steps = append(sel.steps, st)
+		}
+	}
+	return sel, nil
+}
+
+// Convert the step element.
+func (c *compiler) step(e Ast) (step, error) {
+	var st step
+	for _, item := range e.Value.(AstSlice) {
+		switch item.ClassName {
+		case clsz.Universal:
+			// Nothing to do.
+		case clsz.Name:
+			st.name = item.Value.(string)
+			st.hasName = true
+		case clsz.Predicate:
+			pred, err := c.predicate(item)
+			if err != nil {
+				return st, err
+			}
+			st.preds = append(st.preds, pred)
+		}
+	}
+	return st, nil
+}
+
+// Convert the predicate element.
+func (c *compiler) predicate(e Ast) (predicate, error) {
+	var pred predicate
+	items := e.Value.(AstSlice)
+	head := items[0]
+
+	if head.ClassName == clsz.Word {
+		n, err := strconv.Atoi(head.Value.(string))
+		if err != nil {
+			return pred, c.error(head, "Bad index: "+head.Value.(string))
+		}
+		pred.attr = attr_Index
+		pred.index = n
+		return pred, nil
+	}
+
+	switch head.Value.(string) {
+	case "cn", "class":
+		pred.attr = attr_ClassName
+	case "op", "opcode":
+		pred.attr = attr_OpCode
+	case "ty", "type":
+		pred.attr = attr_Type
+	case "v", "value":
+		pred.attr = attr_Value
+	default:
+		return pred, c.error(head, "Unknown attribute: "+head.Value.(string))
+	}
+
+	if len(items) == 1 {
+		pred.op = op_Exists
+		return pred, nil
+	}
+
+	switch items[1].Value.(string) {
+	case "=":
+		pred.op = op_Eq
+	case "!=":
+		pred.op = op_Ne
+	case "^=":
+		pred.op = op_Prefix
+	case "$=":
+		pred.op = op_Suffix
+	case "*=":
+		pred.op = op_Contains
+	case "<":
+		pred.op = op_Lt
+	case "<=":
+		pred.op = op_Le
+	case ">":
+		pred.op = op_Gt
+	case ">=":
+		pred.op = op_Ge
+	}
+
+	lit, err := c.literal(items[2])
+	if err != nil {
+		return pred, err
+	}
+	pred.lit = lit
+	return pred, nil
+}
+
+// Convert the value element.
+func (c *compiler) literal(e Ast) (literal, error) {
+	s := e.Value.(string)
+	switch e.ClassName {
+	case clsz.String:
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return literal{}, c.error(e, "Bad string literal")
+		}
+		return literal{kind: literal_String, str: v}, nil
+	case clsz.Rune:
+		v, _, tail, err := strconv.UnquoteChar(s[1:], '\'')
+		if err != nil || tail != "'" {
+			return literal{}, c.error(e, "Bad rune literal")
+		}
+		return literal{kind: literal_Number, num: number{i: int64(v)}}, nil
+	}
+
+	switch s {
+	case "true":
+		return literal{kind: literal_Bool, b: true}, nil
+	case "false":
+		return literal{kind: literal_Bool, b: false}, nil
+	case "nil":
+		return literal{kind: literal_Nil}, nil
+	}
+
+	if ch := s[0]; ch == '+' || ch == '-' || ch == '.' || ('0' <= ch && ch <= '9') {
+		if strings.HasSuffix(s, "u") {
+			if v, err := strconv.ParseUint(s[:len(s)-1], 10, 64); err == nil {
+				return literal{kind: literal_Number, num: number{u: v, isUint: true}}, nil
+			}
+		} else if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return literal{kind: literal_Number, num: number{i: v}}, nil
+		} else if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return literal{kind: literal_Number, num: number{f: v, isFloat: true}}, nil
+		}
+		return literal{}, c.error(e, "Bad number literal: "+s)
+	}
+	return literal{kind: literal_Word, str: s}, nil
+}
+
+// Compile the selector.
+func Compile(s string) (*Query, error) {
+	out, err := queryParser(*NewStringParserContext(s))
+	if err != nil {
+		return nil, ToParseError(out.SourcePosition, "", err).Locate(s, 4)
+	}
+	if out.MatchStatus != MatchStatus_Matched {
+		return nil, NewParseError(out.SourcePosition, out.ClassName, "Parse failed").Locate(s, 4)
+	}
+
+	c := &compiler{src: s}
+	q := &Query{
+		src:       s,
+		selectors: make([]selector, 0, len(out.AstStack)),
+	}
+	for _, e := range out.AstStack {
+		sel, err := c.selector(e)
+		if err != nil {
+			return nil, err
+		}
+		q.selectors = append(q.selectors, sel)
+	}
+	return q, nil
+}
+
+// Compile the selector. It panics if an error occurs.
+func MustCompile(s string) *Query {
+	q, err := Compile(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
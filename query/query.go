@@ -0,0 +1,520 @@
+package query
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Axis of the step. It is the relation to the previous step.
+type axisType int
+
+const (
+	axis_Descendant axisType = iota
+	axis_Child
+)
+
+// Attribute of the predicate.
+type attrType int
+
+const (
+	attr_Index attrType = iota
+	attr_ClassName
+	attr_OpCode
+	attr_Type
+	attr_Value
+)
+
+// Operator of the predicate.
+type opType int
+
+const (
+	op_Exists opType = iota
+	op_Eq
+	op_Ne
+	op_Prefix
+	op_Suffix
+	op_Contains
+	op_Lt
+	op_Le
+	op_Gt
+	op_Ge
+)
+
+// Kind of the literal.
+type literalKind int
+
+const (
+	literal_Nil literalKind = iota
+	literal_Bool
+	literal_Number
+	literal_String
+	// Unquoted identifier. (e.g. type name)
+	literal_Word
+)
+
+// Integer or float number.
+type number struct {
+	i       int64
+	u       uint64
+	f       float64
+	isUint  bool
+	isFloat bool
+}
+
+// Literal of the predicate.
+type literal struct {
+	kind literalKind
+	b    bool
+	num  number
+	str  string
+}
+
+// Predicate of the step.
+type predicate struct {
+	attr  attrType
+	op    opType
+	lit   literal
+	index int
+}
+
+// Name test and predicates.
+type step struct {
+	axis    axisType
+	name    string
+	hasName bool
+	preds   []predicate
+}
+
+// Steps from the left to the right.
+type selector struct {
+	steps []step
+}
+
+// Compiled selector.
+//
+//	Program FunctionCall > Identifier[v="print"], Number[v>=10]
+//
+// Syntax:
+//
+//	Name       Matches the AST whose ClassName is Name.
+//	*          Matches any AST.
+//	A B        B is a descendant of A.
+//	A > B      B is a child of A. (the items of ListOfAst, and the car and cdr of AstCons)
+//	> A        A is the root.
+//	A, B       A or B.
+//	[attr]     The attribute is not empty.
+//	[attr op value]
+//	           attr: cn (class), op (opcode), ty (type), v (value)
+//	           op:   = != ^= $= *= < <= > >=
+//	           value: "string", 'rune', 1, -1, 1u, 1.5, true, false, nil, TypeName (for ty)
+//	[n]        The AST is the n-th child of its parent. The negative index counts from the last.
+type Query struct {
+	src       string
+	selectors []selector
+}
+
+// Path from the root to the AST.
+// Each item is the index of ListOfAst, or 0 (car) / 1 (cdr) of AstCons.
+// For SelectSlice(), the first item is the index of the slice.
+type Path []int
+
+// Format the path as `/0/2/1`. The root is `/`.
+func (p Path) String() string {
+	if len(p) == 0 {
+		return "/"
+	}
+	var sb strings.Builder
+	for _, i := range p {
+		sb.WriteString("/")
+		sb.WriteString(strconv.Itoa(i))
+	}
+	return sb.String()
+}
+
+// Get the AST at the path from the root.
+func (p Path) Resolve(root Ast) (Ast, bool) {
+	ast := root
+	for _, i := range p {
+		children := childrenOf(ast)
+		if i < 0 || len(children) <= i {
+			return Ast{}, false
+		}
+		ast = children[i]
+	}
+	return ast, true
+}
+
+// Get the AST at the path from the slice.
+func (p Path) ResolveSlice(asts AstSlice) (Ast, bool) {
+	if len(p) == 0 || p[0] < 0 || len(asts) <= p[0] {
+		return Ast{}, false
+	}
+	return p[1:].Resolve(asts[p[0]])
+}
+
+// Matched AST and its path.
+type Result struct {
+	Path Path
+	Ast  Ast
+}
+
+// Get the child ASTs.
+func childrenOf(ast Ast) AstSlice {
+	switch ast.Type {
+	case AstType_ListOfAst:
+		children, _ := ast.Value.(AstSlice)
+		return children
+	case AstType_AstCons:
+		cons, ok := ast.Value.(AstCons)
+		if !ok {
+			return nil
+		}
+		return AstSlice{cons.Car, cons.Cdr}
+	default:
+		return nil
+	}
+}
+
+// AST and its position in the parent.
+type frame struct {
+	ast      Ast
+	index    int
+	siblings int
+}
+
+// Test the name and predicates.
+func (st *step) test(f *frame) bool {
+	if st.hasName && f.ast.ClassName != st.name {
+		return false
+	}
+	for i := range st.preds {
+		if !st.preds[i].test(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// Test the selector from the step i at the depth d of the chain, from the right to the left.
+func (sel *selector) match(i int, chain []frame, d int) bool {
+	st := &sel.steps[i]
+	if !st.test(&chain[d]) {
+		return false
+	}
+	if i == 0 {
+		return st.axis != axis_Child || d == 0
+	}
+	if st.axis == axis_Child {
+		return 0 < d && sel.match(i-1, chain, d-1)
+	}
+	for k := d - 1; 0 <= k; k-- {
+		if sel.match(i-1, chain, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Selector evaluation state
+type evaluator struct {
+	q     *Query
+	chain []frame
+	path  Path
+	out   []Result
+	limit int
+}
+
+// Walk the tree in the document order. It returns false if the limit is reached.
+func (e *evaluator) walk(f frame) bool {
+	e.chain = append(e.chain, f)
+	d := len(e.chain) - 1
+
+	for i := range e.q.selectors {
+		sel := &e.q.selectors[i]
+		if sel.match(len(sel.steps)-1, e.chain, d) {
+			path := make(Path, len(e.path))
+			copy(path, e.path)
+			e.out = append(e.out, Result{Path: path, Ast: f.ast})
+			if 0 < e.limit && e.limit <= len(e.out) {
+				return false
+			}
+			break
+		}
+	}
+
+	children := childrenOf(f.ast)
+	for i, child := range children {
+		e.path = append(e.path, i)
+		ok := e.walk(frame{ast: child, index: i, siblings: len(children)})
+		e.path = e.path[:len(e.path)-1]
+		if !ok {
+			return false
+		}
+	}
+
+	e.chain = e.chain[:d]
+	return true
+}
+
+// Get the ASTs matched to the query in the document order (depth-first, pre-order).
+// The root itself can match.
+func (q *Query) Select(ast Ast) []Result {
+	e := &evaluator{q: q}
+	e.walk(frame{ast: ast, siblings: 1})
+	return e.out
+}
+
+// Get the ASTs matched to the query in the ASTs.
+// Each AST of the slice is treated as a root.
+func (q *Query) SelectSlice(asts AstSlice) []Result {
+	e := &evaluator{q: q}
+	for i, ast := range asts {
+		e.path = append(e.path[:0], i)
+		e.chain = e.chain[:0]
+		e.walk(frame{ast: ast, index: i, siblings: len(asts)})
+	}
+	return e.out
+}
+
+// Get the first AST matched to the query.
+func (q *Query) First(ast Ast) (Result, bool) {
+	e := &evaluator{q: q, limit: 1}
+	e.walk(frame{ast: ast, siblings: 1})
+	if len(e.out) == 0 {
+		return Result{}, false
+	}
+	return e.out[0], true
+}
+
+// Source of the query.
+func (q *Query) String() string {
+	return q.src
+}
+
+// Compile the selector and get the matched ASTs.
+func Select(ast Ast, s string) ([]Result, error) {
+	q, err := Compile(s)
+	if err != nil {
+		return nil, err
+	}
+	return q.Select(ast), nil
+}
+
+// Test the predicate.
+func (p *predicate) test(f *frame) bool {
+	ast := f.ast
+
+	switch p.attr {
+	case attr_Index:
+		index := p.index
+		if index < 0 {
+			index += f.siblings
+		}
+		return f.index == index
+
+	case attr_ClassName:
+		if p.op == op_Exists {
+			return ast.ClassName != ""
+		}
+		return p.compareString(ast.ClassName)
+
+	case attr_OpCode:
+		if p.op == op_Exists {
+			return ast.OpCode != 0
+		}
+		return p.compareNumber(number{u: uint64(ast.OpCode), isUint: true})
+
+	case attr_Type:
+		switch p.op {
+		case op_Exists:
+			return ast.Type != AstType_Nil
+		case op_Eq, op_Ne:
+			if p.lit.kind == literal_Number {
+				return p.compareNumber(number{u: uint64(ast.Type), isUint: true})
+			}
+			return p.compareString(ast.Type.String())
+		default:
+			return false
+		}
+
+	default:
+		if p.op == op_Exists {
+			return ast.Type != AstType_Nil
+		}
+		return p.compareValue(ast)
+	}
+}
+
+// Apply the result of the comparison to the operator.
+// If ok is false, the operands are not comparable.
+func (p *predicate) result(c int, ok bool) bool {
+	if p.op == op_Ne {
+		return !ok || c != 0
+	}
+	if !ok {
+		return false
+	}
+	switch p.op {
+	case op_Eq:
+		return c == 0
+	case op_Lt:
+		return c < 0
+	case op_Le:
+		return c <= 0
+	case op_Gt:
+		return 0 < c
+	case op_Ge:
+		return 0 <= c
+	default:
+		return false
+	}
+}
+
+// Compare the string with the literal.
+func (p *predicate) compareString(s string) bool {
+	if p.lit.kind != literal_String && p.lit.kind != literal_Word {
+		return p.result(0, false)
+	}
+	switch p.op {
+	case op_Prefix:
+		return strings.HasPrefix(s, p.lit.str)
+	case op_Suffix:
+		return strings.HasSuffix(s, p.lit.str)
+	case op_Contains:
+		return strings.Contains(s, p.lit.str)
+	default:
+		return p.result(strings.Compare(s, p.lit.str), true)
+	}
+}
+
+// Compare the number with the literal.
+func (p *predicate) compareNumber(n number) bool {
+	if p.lit.kind != literal_Number {
+		return p.result(0, false)
+	}
+	c, ok := compareNumbers(n, p.lit.num)
+	return p.result(c, ok)
+}
+
+// Compare the value of the AST with the literal.
+// The value that does not match the AST type does not match any literal.
+func (p *predicate) compareValue(ast Ast) bool {
+	switch ast.Type {
+	case AstType_Nil:
+		return p.result(0, p.lit.kind == literal_Nil)
+	case AstType_Bool:
+		v, ok := ast.Value.(bool)
+		if !ok {
+			return false
+		}
+		if p.lit.kind != literal_Bool {
+			return p.result(0, false)
+		}
+		c := 0
+		if v != p.lit.b {
+			c = 1
+		}
+		return p.result(c, p.op == op_Eq || p.op == op_Ne)
+	case AstType_String:
+		v, ok := ast.Value.(string)
+		if !ok {
+			return false
+		}
+		if p.lit.kind != literal_String {
+			return p.result(0, false)
+		}
+		return p.compareString(v)
+	case AstType_Rune:
+		v, ok := ast.Value.(rune)
+		if !ok {
+			return false
+		}
+		if p.lit.kind == literal_String {
+			return p.compareString(string(v))
+		}
+		return p.compareNumber(number{i: int64(v)})
+	case AstType_Int:
+		v, ok := ast.Value.(int64)
+		if !ok {
+			return false
+		}
+		return p.compareNumber(number{i: v})
+	case AstType_Uint:
+		v, ok := ast.Value.(uint64)
+		if !ok {
+			return false
+		}
+		return p.compareNumber(number{u: v, isUint: true})
+	case AstType_Float:
+		v, ok := ast.Value.(float64)
+		if !ok {
+			return false
+		}
+		return p.compareNumber(number{f: v, isFloat: true})
+	default:
+		return p.result(0, false)
+	}
+}
+
+// Convert the number to float64.
+func (n number) float() float64 {
+	switch {
+	case n.isFloat:
+		return n.f
+	case n.isUint:
+		return float64(n.u)
+	default:
+		return float64(n.i)
+	}
+}
+
+// Sign and magnitude of the integer.
+func (n number) signMag() (bool, uint64) {
+	if n.isUint {
+		return false, n.u
+	}
+	if n.i < 0 {
+		return true, uint64(-(n.i + 1)) + 1
+	}
+	return false, uint64(n.i)
+}
+
+// Compare the numbers. If either is NaN, ok is false.
+func compareNumbers(a, b number) (int, bool) {
+	if a.isFloat || b.isFloat {
+		x, y := a.float(), b.float()
+		switch {
+		case math.IsNaN(x) || math.IsNaN(y):
+			return 0, false
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	aNeg, aMag := a.signMag()
+	bNeg, bMag := b.signMag()
+	switch {
+	case aNeg && !bNeg:
+		return -1, true
+	case !aNeg && bNeg:
+		return 1, true
+	}
+
+	c := 0
+	if aMag < bMag {
+		c = -1
+	} else if aMag > bMag {
+		c = 1
+	}
+	if aNeg {
+		c = -c
+	}
+	return c, true
+}
@@ -0,0 +1,175 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	"github.com/shellyln/takenoco/sexpr"
+	. "github.com/shellyln/takenoco/string"
+)
+
+const fixture = `
+(Program
+  (FunctionCall (Identifier "print") (Args (String "hello") (Number 10)))
+  (FunctionCall (Identifier "len") (Args (Identifier "print")))
+  (Assign :op 3 (Identifier "x") (Number 1.5)))
+`
+
+func TestSelect(t *testing.T) {
+	root := sexpr.MustRead(fixture)[0]
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"class name", "Identifier", []string{"/0/0", "/1/0", "/1/1/0", "/2/0"}},
+		{"child", `FunctionCall > Identifier[v="print"]`, []string{"/0/0"}},
+		{"descendant", `FunctionCall Identifier[v="print"]`, []string{"/0/0", "/1/1/0"}},
+		{"universal", "Args > *", []string{"/0/1/0", "/0/1/1", "/1/1/0"}},
+		{"root", "> Program > FunctionCall", []string{"/0", "/1"}},
+		{"not root", "> FunctionCall", nil},
+		{"union in document order", "Number, String", []string{"/0/1/0", "/0/1/1", "/2/1"}},
+		{"index", "Args > *[0]", []string{"/0/1/0", "/1/1/0"}},
+		{"last index", "Args > *[-1]", []string{"/0/1/1", "/1/1/0"}},
+		{"number", "Number[v>=10]", []string{"/0/1/1"}},
+		{"float", "Number[v<2]", []string{"/2/1"}},
+		{"prefix", `Identifier[v^="pr"]`, []string{"/0/0", "/1/1/0"}},
+		{"suffix and contains", `Identifier[v$="n"][v*="e"]`, []string{"/1/0"}},
+		{"not equal", `Identifier[v!="print"]`, []string{"/1/0", "/2/0"}},
+		{"opcode", "*[op=3]", []string{"/2"}},
+		{"opcode exists", "*[op]", []string{"/2"}},
+		{"type", "*[ty=Float]", []string{"/2/1"}},
+		{"class attribute", `*[cn^="Func"] > *[type=String]`, []string{"/0/0", "/1/0"}},
+		{"predicate only", "[v=1.5]", []string{"/2/1"}},
+		{"class name with colon", ":sexpr:List", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := Select(root, tt.query)
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			var got []string
+			for _, m := range matches {
+				got = append(got, m.Path.String())
+				ast, ok := m.Path.Resolve(root)
+				if !ok || !reflect.DeepEqual(ast, m.Ast) {
+					t.Errorf("Path.Resolve(%v) = %v, %v", m.Path, ast, ok)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectCons(t *testing.T) {
+	root := sexpr.MustRead(`(Pair (Key "k") . (Value 1))`)[0]
+	got := MustCompile("Pair > *[1]").Select(root)
+	if len(got) != 1 || got[0].Path.String() != "/1" || got[0].Ast.ClassName != "Value" {
+		t.Errorf("Select() = %v", got)
+	}
+}
+
+func TestSelectMismatchedValue(t *testing.T) {
+	root := Ast{ClassName: "Root", Type: AstType_ListOfAst, Value: AstSlice{
+		{ClassName: "Number", Type: AstType_Int, Value: 1},
+		{ClassName: "Pair", Type: AstType_AstCons, Value: "x"},
+		{ClassName: "Number", Type: AstType_Int, Value: int64(1)},
+	}}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"Number[value=1]", "/2"},
+		{"Number[value!=2]", "/2"},
+		{"Pair > *", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var paths []string
+			for _, m := range MustCompile(tt.query).Select(root) {
+				paths = append(paths, m.Path.String())
+			}
+			if got := strings.Join(paths, " "); got != tt.want {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectSlice(t *testing.T) {
+	asts := sexpr.MustRead(`(A (B 1)) (B 2)`)
+	got := MustCompile("B").SelectSlice(asts)
+	if len(got) != 2 || got[0].Path.String() != "/0/0" || got[1].Path.String() != "/1" {
+		t.Fatalf("SelectSlice() = %v", got)
+	}
+	if ast, ok := got[0].Path.ResolveSlice(asts); !ok || ast.Value != int64(1) {
+		t.Errorf("Path.ResolveSlice() = %v, %v", ast, ok)
+	}
+}
+
+func TestFirst(t *testing.T) {
+	root := sexpr.MustRead(fixture)[0]
+	m, ok := MustCompile("Identifier").First(root)
+	if !ok || m.Path.String() != "/0/0" {
+		t.Errorf("First() = %v, %v", m, ok)
+	}
+	if _, ok := MustCompile("Nothing").First(root); ok {
+		t.Errorf("First() matched")
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{"", 0},
+		{"A >", 3},
+		{"A[", 2},
+		{"A[v=]", 4},
+		{"A[v=1", 5},
+		{"A[foo=1]", 2},
+		{"A[1.5]", 2},
+		{"A, ", 3},
+		{`A[v="x]`, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Compile(tt.query)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			if perr.Position != tt.pos {
+				t.Errorf("Compile() error position = %v, want %v (%v)", perr.Position, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	out, err := queryParser(*NewStringParserContext("A > B[x = 1] "))
+	if err != nil || out.MatchStatus != MatchStatus_Matched {
+		t.Fatalf("parse failed: %v %v", out.MatchStatus, err)
+	}
+
+	selector := out.AstStack[0]
+	if got, want := selector.SourcePosition, (SourcePosition{Position: 0, Length: 12}); got != want {
+		t.Errorf("selector span = %+v, want %+v", got, want)
+	}
+	items := selector.Value.(AstSlice)
+	step := items[2]
+	if got, want := step.SourcePosition, (SourcePosition{Position: 4, Length: 8}); got != want {
+		t.Errorf("step span = %+v, want %+v", got, want)
+	}
+	if got, want := step.Value.(AstSlice)[1].SourcePosition, (SourcePosition{Position: 5, Length: 7}); got != want {
+		t.Errorf("predicate span = %+v, want %+v", got, want)
+	}
+}
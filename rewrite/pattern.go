@@ -0,0 +1,263 @@
+package rewrite
+
+import (
+	"reflect"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Metavariables in the patterns and templates are the ASTs with the following class names.
+// The type and value of the metavariable AST are ignored.
+//
+//	$name        Matches any AST and binds it to name.
+//	$name:Class  Matches the AST of the class name and binds it to name.
+//	$$name       Matches zero or more items of ListOfAst and binds them to name.
+//	$_           Matches any AST. It is not bound.
+//
+// If the same name appears twice in the pattern, the bound ASTs should be equal.
+// In the template, `$name` is replaced by the bound AST, `$name:Class` is replaced by
+// the bound AST with the class name changed, and `$$name` is spliced into the list.
+const (
+	varPrefix    = "$"
+	seqVarPrefix = "$$"
+	wildcard     = "$_"
+)
+
+// Make the metavariable `$name`.
+func Var(name string) Ast {
+	return Ast{ClassName: varPrefix + name}
+}
+
+// Make the metavariable `$name:Class`.
+func VarOf(name, className string) Ast {
+	return Ast{ClassName: varPrefix + name + ":" + className}
+}
+
+// Make the sequence metavariable `$$name`.
+func SeqVar(name string) Ast {
+	return Ast{ClassName: seqVarPrefix + name}
+}
+
+// Parsed metavariable.
+type metaVar struct {
+	name      string
+	className string
+	hasClass  bool
+	seq       bool
+}
+
+// Parse the class name as a metavariable.
+func parseVar(className string) (metaVar, bool) {
+	var v metaVar
+	switch {
+	case strings.HasPrefix(className, seqVarPrefix):
+		v.name = className[len(seqVarPrefix):]
+		v.seq = true
+	case strings.HasPrefix(className, varPrefix):
+		v.name = className[len(varPrefix):]
+		if i := strings.IndexByte(v.name, ':'); 0 <= i {
+			v.className = v.name[i+1:]
+			v.hasClass = true
+			v.name = v.name[:i]
+		}
+	default:
+		return v, false
+	}
+	return v, v.name != ""
+}
+
+// Bound ASTs of the metavariables.
+type Bindings struct {
+	nodes map[string]Ast
+	seqs  map[string]AstSlice
+}
+
+// Constructor
+func newBindings() *Bindings {
+	return &Bindings{
+		nodes: make(map[string]Ast),
+		seqs:  make(map[string]AstSlice),
+	}
+}
+
+// Copy the bindings. (for backtracking)
+func (b *Bindings) clone() *Bindings {
+	w := newBindings()
+	for k, v := range b.nodes {
+		w.nodes[k] = v
+	}
+	for k, v := range b.seqs {
+		w.seqs[k] = v
+	}
+	return w
+}
+
+// Get the AST bound to `$name`.
+func (b *Bindings) Get(name string) (Ast, bool) {
+	ast, ok := b.nodes[name]
+	return ast, ok
+}
+
+// Get the AST bound to `$name`. It returns the zero value if not bound.
+func (b *Bindings) Node(name string) Ast {
+	return b.nodes[name]
+}
+
+// Get the ASTs bound to `$$name`. It returns nil if not bound.
+func (b *Bindings) Seq(name string) AstSlice {
+	return b.seqs[name]
+}
+
+// Bind the AST to the name. If it is already bound, the ASTs should be equal.
+func (b *Bindings) bind(name string, ast Ast) bool {
+	if name == "_" {
+		return true
+	}
+	if bound, ok := b.nodes[name]; ok {
+		return Equal(bound, ast)
+	}
+	b.nodes[name] = ast
+	return true
+}
+
+// Bind the ASTs to the name. If they are already bound, the ASTs should be equal.
+func (b *Bindings) bindSeq(name string, asts AstSlice) bool {
+	if name == "_" {
+		return true
+	}
+	if bound, ok := b.seqs[name]; ok {
+		return equalSlice(bound, asts)
+	}
+	b.seqs[name] = asts
+	return true
+}
+
+// Test whether the ASTs are structurally equal.
+// The source positions and addresses are ignored.
+func Equal(a, b Ast) bool {
+	if a.ClassName != b.ClassName || a.OpCode != b.OpCode || a.Type != b.Type {
+		return false
+	}
+	switch a.Type {
+	case AstType_ListOfAst:
+		x, _ := a.Value.(AstSlice)
+		y, _ := b.Value.(AstSlice)
+		return equalSlice(x, y)
+	case AstType_AstCons:
+		x, okX := a.Value.(AstCons)
+		y, okY := b.Value.(AstCons)
+		return okX && okY && Equal(x.Car, y.Car) && Equal(x.Cdr, y.Cdr)
+	case AstType_Function:
+		return false
+	default:
+		return reflect.DeepEqual(a.Value, b.Value)
+	}
+}
+
+// Test whether the AST slices are structurally equal.
+func equalSlice(a, b AstSlice) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match the AST to the pattern.
+func match(pattern, ast Ast, b *Bindings) bool {
+	if v, ok := parseVar(pattern.ClassName); ok && !v.seq {
+		if v.hasClass && ast.ClassName != v.className {
+			return false
+		}
+		return b.bind(v.name, ast)
+	}
+
+	if pattern.ClassName != ast.ClassName || pattern.Type != ast.Type {
+		return false
+	}
+	if pattern.OpCode != 0 && pattern.OpCode != ast.OpCode {
+		return false
+	}
+
+	switch pattern.Type {
+	case AstType_ListOfAst:
+		x, _ := pattern.Value.(AstSlice)
+		y, _ := ast.Value.(AstSlice)
+		return matchSlice(x, y, b)
+	case AstType_AstCons:
+		x, okX := pattern.Value.(AstCons)
+		y, okY := ast.Value.(AstCons)
+		return okX && okY && match(x.Car, y.Car, b) && match(x.Cdr, y.Cdr, b)
+	case AstType_Function:
+		return false
+	default:
+		return reflect.DeepEqual(pattern.Value, ast.Value)
+	}
+}
+
+// Match the list items to the patterns. The sequence metavariables are matched by backtracking.
+func matchSlice(patterns, asts AstSlice, b *Bindings) bool {
+	if len(patterns) == 0 {
+		return len(asts) == 0
+	}
+
+	v, ok := parseVar(patterns[0].ClassName)
+	if !ok || !v.seq {
+		return 0 < len(asts) && match(patterns[0], asts[0], b) && matchSlice(patterns[1:], asts[1:], b)
+	}
+
+	for n := 0; n <= len(asts); n++ {
+		w := b.clone()
+		if w.bindSeq(v.name, asts[:n]) && matchSlice(patterns[1:], asts[n:], w) {
+			*b = *w
+			return true
+		}
+	}
+	return false
+}
+
+// Build the AST from the template.
+func build(template Ast, b *Bindings) Ast {
+	if v, ok := parseVar(template.ClassName); ok && !v.seq {
+		ast, bound := b.nodes[v.name]
+		if !bound {
+			return template
+		}
+		if v.hasClass {
+			ast.ClassName = v.className
+		}
+		return ast
+	}
+
+	switch template.Type {
+	case AstType_ListOfAst:
+		items, _ := template.Value.(AstSlice)
+		template.Value = buildSlice(items, b)
+	case AstType_AstCons:
+		if cons, ok := template.Value.(AstCons); ok {
+			template.Value = AstCons{
+				Car: build(cons.Car, b),
+				Cdr: build(cons.Cdr, b),
+			}
+		}
+	}
+	return template
+}
+
+// Build the list items from the templates. The sequence metavariables are spliced.
+func buildSlice(templates AstSlice, b *Bindings) AstSlice {
+	w := make(AstSlice, 0, len(templates))
+	for _, item := range templates {
+		if v, ok := parseVar(item.ClassName); ok && v.seq {
+			w = append(w, b.seqs[v.name]...)
+			continue
+		}
+		w = append(w, build(item, b))
+	}
+	return w
+}
@@ -0,0 +1,185 @@
+package rewrite
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// The number of the rule applications exceeded Options.MaxSteps.
+var ErrStepLimit = errors.New("Rewrite step limit exceeded")
+
+// Order of the rule applications.
+type StrategyType int
+
+const (
+	// Rewrite the children first, then the parent.
+	Strategy_BottomUp StrategyType = iota
+	// Rewrite the parent first, then the children of the result.
+	Strategy_TopDown
+)
+
+// Rewrite rule. (pattern → template)
+type Rule struct {
+	// Rule name for the error messages.
+	Name string
+	// Pattern with the metavariables.
+	Pattern Ast
+	// Replacement template with the metavariables.
+	Template Ast
+	// Additional condition. If it is nil, the rule is applied whenever the pattern matches.
+	Where func(b *Bindings) bool
+	// Build the replacement instead of Template. (e.g. constant folding)
+	// If it returns ok == false, the rule is not applied.
+	Build func(b *Bindings) (ast Ast, ok bool, err error)
+}
+
+// Options of the Rewriter.
+type Options struct {
+	Strategy StrategyType
+	// Maximum number of the rule applications in total. (termination guard)
+	// If it is 0, 10000 is used.
+	MaxSteps int
+}
+
+// Rewriter applies the rules to the AST until no rule matches.
+type Rewriter struct {
+	rules []Rule
+	opts  Options
+}
+
+// Constructor
+func NewRewriter(opts Options, rules ...Rule) *Rewriter {
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = 10000
+	}
+	return &Rewriter{
+		rules: rules,
+		opts:  opts,
+	}
+}
+
+// Rewrite state
+type state struct {
+	r       *Rewriter
+	steps   int
+	changed bool
+}
+
+// Apply the first matched rule to the AST.
+func (s *state) applyOnce(ast Ast) (Ast, bool, error) {
+	for i := range s.r.rules {
+		rule := &s.r.rules[i]
+		b := newBindings()
+		if !match(rule.Pattern, ast, b) {
+			continue
+		}
+		if rule.Where != nil && !rule.Where(b) {
+			continue
+		}
+
+		var out Ast
+		if rule.Build != nil {
+			w, ok, err := rule.Build(b)
+			if err != nil {
+				return ast, false, fmt.Errorf("Rule %s: %w", rule.Name, err)
+			}
+			if !ok {
+				continue
+			}
+			out = w
+		} else {
+			out = build(rule.Template, b)
+		}
+		if out.SourcePosition == (SourcePosition{}) {
+			out.SourcePosition = ast.SourcePosition
+		}
+
+		s.steps++
+		if s.r.opts.MaxSteps < s.steps {
+			return ast, false, fmt.Errorf("Rule %s: %w", rule.Name, ErrStepLimit)
+		}
+		return out, true, nil
+	}
+	return ast, false, nil
+}
+
+// Apply the rules to the AST until no rule matches.
+func (s *state) apply(ast Ast) (Ast, error) {
+	for {
+		out, ok, err := s.applyOnce(ast)
+		if err != nil {
+			return ast, err
+		}
+		if !ok {
+			return ast, nil
+		}
+		s.changed = true
+		ast = out
+	}
+}
+
+func (s *state) wayThere(ctx interface{}, ast Ast) (Ast, WayThereMode, error) {
+	if s.r.opts.Strategy == Strategy_TopDown {
+		out, err := s.apply(ast)
+		return out, WayThereMode_None, err
+	}
+	return ast, WayThereMode_None, nil
+}
+
+func (s *state) wayBack(ctx interface{}, ast Ast) (Ast, int16, TraverseOpcode, interface{}, error) {
+	if s.r.opts.Strategy == Strategy_BottomUp {
+		out, err := s.apply(ast)
+		return out, 0, TraverseOpcode_Nop, nil, err
+	}
+	return ast, 0, TraverseOpcode_Nop, nil, nil
+}
+
+func (s *state) childrenErr(ctx interface{}, ast Ast, child Ast, thrown interface{}, err error) {
+}
+
+// Rewrite the AST to a fixpoint.
+// Each pass walks the tree with Ast.Traverse, and the passes are repeated while any rule is applied.
+// It returns an error wrapping ErrStepLimit if the rules are applied more than Options.MaxSteps times.
+func (r *Rewriter) Rewrite(ast Ast) (Ast, error) {
+	s := &state{r: r}
+	for {
+		s.changed = false
+		out, _, _, _, err := ast.Traverse(s.wayThere, s.wayBack, s.childrenErr, s)
+		if err != nil {
+			return ast, err
+		}
+		ast = out
+		if !s.changed {
+			return ast, nil
+		}
+	}
+}
+
+// Rewrite each AST of the slice to a fixpoint.
+func (r *Rewriter) RewriteSlice(asts AstSlice) (AstSlice, error) {
+	w := make(AstSlice, 0, len(asts))
+	for _, ast := range asts {
+		out, err := r.Rewrite(ast)
+		if err != nil {
+			return nil, err
+		}
+		w = append(w, out)
+	}
+	return w, nil
+}
+
+// Test whether the AST matches the pattern, and get the bindings.
+func MatchPattern(pattern, ast Ast) (*Bindings, bool) {
+	b := newBindings()
+	if !match(pattern, ast, b) {
+		return nil, false
+	}
+	return b, true
+}
+
+// Build the AST from the template and the bindings.
+func BuildTemplate(template Ast, b *Bindings) Ast {
+	return build(template, b)
+}
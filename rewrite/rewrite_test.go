@@ -0,0 +1,173 @@
+package rewrite
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	"github.com/shellyln/takenoco/sexpr"
+)
+
+func read(s string) Ast {
+	return sexpr.MustRead(s)[0]
+}
+
+func format(ast Ast) string {
+	return sexpr.Format(ast, sexpr.Options{})
+}
+
+// Constant folding of `Add` and `Mul`.
+func foldRule(className string, fn func(a, b int64) int64) Rule {
+	return Rule{
+		Name:    "fold" + className,
+		Pattern: read(`(` + className + ` ($a:Number) ($b:Number))`),
+		Build: func(b *Bindings) (Ast, bool, error) {
+			x := b.Node("a").Value.(int64)
+			y := b.Node("b").Value.(int64)
+			return Ast{ClassName: "Number", Type: AstType_Int, Value: fn(x, y)}, true, nil
+		},
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	rules := []Rule{
+		foldRule("Add", func(a, b int64) int64 { return a + b }),
+		foldRule("Mul", func(a, b int64) int64 { return a * b }),
+		{
+			// Desugar `-x` to `0 - x`.
+			Name:     "neg",
+			Pattern:  read(`(Neg ($x))`),
+			Template: read(`(Sub (Number 0) ($x))`),
+		},
+		{
+			// Flatten the nested blocks.
+			Name:     "flatten",
+			Pattern:  read(`(Block ($$a) (Block ($$b)) ($$c))`),
+			Template: read(`(Block ($$a) ($$b) ($$c))`),
+		},
+		{
+			// Normalize `x + x` to `x * 2`.
+			Name:     "double",
+			Pattern:  read(`(Add ($x) ($x))`),
+			Template: read(`(Mul ($x) (Number 2))`),
+			Where: func(b *Bindings) bool {
+				return b.Node("x").ClassName != "Number"
+			},
+		},
+		{
+			// Rename the class with `$name:Class` in the template.
+			Name:     "rename",
+			Pattern:  read(`(Print ($x:Ident))`),
+			Template: read(`(Call (Ident "print") ($x:Arg))`),
+		},
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"fold", `(Add (Number 1) (Mul (Number 2) (Number 3)))`, `(Number 7)`},
+		{"desugar", `(Neg (Ident "a"))`, `(Sub (Number 0) (Ident "a"))`},
+		{"splice", `(Block (S 1) (Block (S 2) (Block (S 3))) (S 4))`, `(Block (S 1) (S 2) (S 3) (S 4))`},
+		{"nonlinear pattern", `(Add (Ident "a") (Ident "a"))`, `(Mul (Ident "a") (Number 2))`},
+		{"nonlinear pattern unmatched", `(Add (Ident "a") (Ident "b"))`, `(Add (Ident "a") (Ident "b"))`},
+		{"where", `(Add (Number 2) (Number 2))`, `(Number 4)`},
+		{"class of var", `(Print (Ident "x"))`, `(Call (Ident "print") (Arg "x"))`},
+		{"no match", `(Sub (Number 1) (Ident "x"))`, `(Sub (Number 1) (Ident "x"))`},
+	}
+	for _, strategy := range []StrategyType{Strategy_BottomUp, Strategy_TopDown} {
+		r := NewRewriter(Options{Strategy: strategy}, rules...)
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := r.Rewrite(read(tt.src))
+				if err != nil {
+					t.Fatalf("Rewrite() error = %v", err)
+				}
+				if format(got) != tt.want {
+					t.Errorf("Rewrite() = %v, want %v (strategy %v)", format(got), tt.want, strategy)
+				}
+			})
+		}
+	}
+}
+
+func TestRewritePosition(t *testing.T) {
+	r := NewRewriter(Options{}, Rule{
+		Pattern:  read(`(A ($x))`),
+		Template: read(`(B ($x))`),
+	})
+	src := Ast{ClassName: "A", Type: AstType_ListOfAst, Value: AstSlice{{ClassName: "X"}},
+		SourcePosition: SourcePosition{Position: 3, Length: 2}}
+	got, err := r.Rewrite(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ClassName != "B" || got.SourcePosition != src.SourcePosition {
+		t.Errorf("Rewrite() = %v", got)
+	}
+}
+
+func TestRewriteStepLimit(t *testing.T) {
+	r := NewRewriter(Options{MaxSteps: 100},
+		Rule{Name: "ab", Pattern: read(`(A ($x))`), Template: read(`(B ($x))`)},
+		Rule{Name: "ba", Pattern: read(`(B ($x))`), Template: read(`(A ($x))`)},
+	)
+	_, err := r.Rewrite(read(`(Root (A (X 1)))`))
+	if !errors.Is(err, ErrStepLimit) {
+		t.Errorf("Rewrite() error = %v", err)
+	}
+}
+
+func TestRewriteBuildError(t *testing.T) {
+	errDiv := errors.New("Division by zero")
+	r := NewRewriter(Options{}, Rule{
+		Name:    "div",
+		Pattern: read(`(Div ($a:Number) (Number 0))`),
+		Build: func(b *Bindings) (Ast, bool, error) {
+			return Ast{}, false, errDiv
+		},
+	})
+	_, err := r.Rewrite(read(`(Root (Div (Number 1) (Number 0)))`))
+	if !errors.Is(err, errDiv) {
+		t.Errorf("Rewrite() error = %v", err)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	b, ok := MatchPattern(read(`(Call ($f) ($$args) ($_))`), read(`(Call (Ident "f") (N 1) (N 2) (N 3))`))
+	if !ok {
+		t.Fatal("MatchPattern() unmatched")
+	}
+	if got := format(b.Node("f")); got != `(Ident "f")` {
+		t.Errorf("$f = %v", got)
+	}
+	if got := sexpr.FormatSlice(b.Seq("args"), sexpr.Options{}); got != "(N 1)\n(N 2)" {
+		t.Errorf("$$args = %v", got)
+	}
+	if _, ok := b.Get("_"); ok {
+		t.Errorf("$_ is bound")
+	}
+	got := BuildTemplate(read(`(Apply ($f) (Args ($$args)))`), b)
+	if format(got) != `(Apply (Ident "f") (Args (N 1) (N 2)))` {
+		t.Errorf("BuildTemplate() = %v", format(got))
+	}
+}
+
+func TestMatchMismatchedCons(t *testing.T) {
+	bad := Ast{ClassName: "Pair", Type: AstType_AstCons, Value: "x"}
+	good := read(`(Pair (K 1) . (V 2))`)
+
+	if Equal(bad, good) || Equal(good, bad) || Equal(bad, bad) {
+		t.Errorf("Equal() is true for the mismatched cons")
+	}
+	if _, ok := MatchPattern(good, bad); ok {
+		t.Errorf("MatchPattern() matched the mismatched cons")
+	}
+	if _, ok := MatchPattern(bad, good); ok {
+		t.Errorf("MatchPattern() matched the mismatched pattern")
+	}
+	if got := BuildTemplate(bad, &Bindings{}); got.Value != "x" {
+		t.Errorf("BuildTemplate() = %v", got)
+	}
+}
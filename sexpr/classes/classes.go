@@ -0,0 +1,10 @@
+package classes
+
+const (
+	List   = ":sexpr:List"
+	Vector = ":sexpr:Vector"
+	String = ":sexpr:String"
+	Rune   = ":sexpr:Rune"
+	Symbol = ":sexpr:Symbol"
+	Word   = ":sexpr:Word"
+)
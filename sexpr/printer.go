@@ -0,0 +1,290 @@
+package sexpr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+)
+
+// Options of the printer.
+type Options struct {
+	// Print the opcodes. (`:op 1`)
+	OpCode bool
+	// Print the source positions. (`:pos 0 :len 1`, and `:file 1` if the file ID is not 0)
+	Position bool
+	// If it is not empty, the nested lists are printed on the new lines indented with it.
+	Indent string
+}
+
+// Characters that cannot be used in the unquoted symbols.
+const symbolDelimiters = " \t\r\n\v\f\u0085()[]\"'|;\\"
+
+// Print the class name as a symbol. The empty name and the name containing delimiters are quoted with `|`.
+func formatSymbol(s string) string {
+	if s != "" && !strings.ContainsAny(s, symbolDelimiters) {
+		return s
+	}
+	var sb strings.Builder
+	sb.WriteString("|")
+	for _, c := range s {
+		if c == '|' || c == '\\' {
+			sb.WriteString("\\")
+		}
+		sb.WriteRune(c)
+	}
+	sb.WriteString("|")
+	return sb.String()
+}
+
+// Print the float number. It always contains `.` or `e` to be distinguished from the integers.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "#nan"
+	case math.IsInf(v, 1):
+		return "#inf"
+	case math.IsInf(v, -1):
+		return "#-inf"
+	}
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// Print the element of ListOfAny or the value of Any.
+// The integers of all sizes (including rune) are printed as numbers, because the element has no AST type.
+// The types that cannot be represented are printed as strings by fmt.Sprint().
+func formatAny(sb *strings.Builder, v interface{}) {
+	switch w := v.(type) {
+	case nil:
+		sb.WriteString("nil")
+	case bool:
+		sb.WriteString(strconv.FormatBool(w))
+	case int:
+		sb.WriteString(strconv.FormatInt(int64(w), 10))
+	case int8:
+		sb.WriteString(strconv.FormatInt(int64(w), 10))
+	case int16:
+		sb.WriteString(strconv.FormatInt(int64(w), 10))
+	case int32:
+		sb.WriteString(strconv.FormatInt(int64(w), 10))
+	case int64:
+		sb.WriteString(strconv.FormatInt(w, 10))
+	case uint:
+		sb.WriteString(strconv.FormatUint(uint64(w), 10) + "u")
+	case uint8:
+		sb.WriteString(strconv.FormatUint(uint64(w), 10) + "u")
+	case uint16:
+		sb.WriteString(strconv.FormatUint(uint64(w), 10) + "u")
+	case uint32:
+		sb.WriteString(strconv.FormatUint(uint64(w), 10) + "u")
+	case uint64:
+		sb.WriteString(strconv.FormatUint(w, 10) + "u")
+	case float32:
+		sb.WriteString(formatFloat(float64(w)))
+	case float64:
+		sb.WriteString(formatFloat(w))
+	case string:
+		sb.WriteString(strconv.Quote(w))
+	case []interface{}:
+		sb.WriteString("[")
+		for i, x := range w {
+			if i != 0 {
+				sb.WriteString(" ")
+			}
+			formatAny(sb, x)
+		}
+		sb.WriteString("]")
+	case SliceLike:
+		sb.WriteString("[")
+		length := w.Len()
+		for i := 0; i < length; i++ {
+			if i != 0 {
+				sb.WriteString(" ")
+			}
+			formatAny(sb, w.Get(i))
+		}
+		sb.WriteString("]")
+	default:
+		sb.WriteString(strconv.Quote(fmt.Sprint(w)))
+	}
+}
+
+// Test whether the value matches the AST type.
+// The mismatched value is printed in the `#any` form.
+func valueMatches(ast Ast) bool {
+	var ok bool
+	switch ast.Type {
+	case AstType_Rune:
+		_, ok = ast.Value.(rune)
+	case AstType_Int:
+		_, ok = ast.Value.(int64)
+	case AstType_Uint:
+		_, ok = ast.Value.(uint64)
+	case AstType_Float:
+		_, ok = ast.Value.(float64)
+	case AstType_Bool:
+		_, ok = ast.Value.(bool)
+	case AstType_String:
+		_, ok = ast.Value.(string)
+	case AstType_AstCons:
+		_, ok = ast.Value.(AstCons)
+	default:
+		ok = true
+	}
+	return ok
+}
+
+// Printer state
+type printer struct {
+	opts Options
+	sb   strings.Builder
+}
+
+// Test whether the AST can be printed as a bare atom.
+func (p *printer) isBare(ast Ast) bool {
+	if ast.ClassName != "" {
+		return false
+	}
+	if p.opts.OpCode && ast.OpCode != 0 {
+		return false
+	}
+	if p.opts.Position && ast.SourcePosition != (SourcePosition{}) {
+		return false
+	}
+	switch ast.Type {
+	case AstType_AstCons, AstType_ListOfAst, AstType_Any:
+		return false
+	default:
+		return valueMatches(ast)
+	}
+}
+
+// Print the scalar value.
+func (p *printer) scalar(ast Ast) {
+	if !valueMatches(ast) {
+		p.sb.WriteString("#any ")
+		formatAny(&p.sb, ast.Value)
+		return
+	}
+	switch ast.Type {
+	case AstType_Nil:
+		p.sb.WriteString("nil")
+	case AstType_Rune:
+		p.sb.WriteString(strconv.QuoteRune(ast.Value.(rune)))
+	case AstType_Int:
+		p.sb.WriteString(strconv.FormatInt(ast.Value.(int64), 10))
+	case AstType_Uint:
+		p.sb.WriteString(strconv.FormatUint(ast.Value.(uint64), 10) + "u")
+	case AstType_Float:
+		p.sb.WriteString(formatFloat(ast.Value.(float64)))
+	case AstType_Bool:
+		p.sb.WriteString(strconv.FormatBool(ast.Value.(bool)))
+	case AstType_String:
+		p.sb.WriteString(strconv.Quote(ast.Value.(string)))
+	case AstType_ListOfAny:
+		formatAny(&p.sb, ast.Value)
+	case AstType_Function:
+		p.sb.WriteString("#function")
+	case AstType_Any:
+		p.sb.WriteString("#any ")
+		formatAny(&p.sb, ast.Value)
+	}
+}
+
+// Print the separator before the child.
+func (p *printer) separator(depth int, child Ast) {
+	if p.opts.Indent != "" && !p.isBare(child) {
+		p.sb.WriteString("\n")
+		p.sb.WriteString(strings.Repeat(p.opts.Indent, depth+1))
+	} else {
+		p.sb.WriteString(" ")
+	}
+}
+
+// Print the child. If bare is true, the class-less scalar is printed without parentheses.
+func (p *printer) child(depth int, ast Ast, bare bool) {
+	if bare && p.isBare(ast) {
+		p.scalar(ast)
+	} else {
+		p.node(depth+1, ast)
+	}
+}
+
+// Print the AST as a list.
+func (p *printer) node(depth int, ast Ast) {
+	p.sb.WriteString("(")
+	p.sb.WriteString(formatSymbol(ast.ClassName))
+
+	if p.opts.OpCode && ast.OpCode != 0 {
+		p.sb.WriteString(" :op " + strconv.FormatUint(uint64(ast.OpCode), 10))
+	}
+	if p.opts.Position {
+		p.sb.WriteString(" :pos " + strconv.Itoa(ast.Position))
+		p.sb.WriteString(" :len " + strconv.Itoa(ast.Length))
+		if ast.FileId != 0 {
+			p.sb.WriteString(" :file " + strconv.Itoa(ast.FileId))
+		}
+	}
+
+	switch cons, ok := ast.Value.(AstCons); {
+	case ast.Type == AstType_AstCons && ok:
+		p.separator(depth, cons.Car)
+		p.child(depth, cons.Car, true)
+		p.sb.WriteString(" .")
+		p.separator(depth, cons.Cdr)
+		p.child(depth, cons.Cdr, true)
+	case ast.Type == AstType_ListOfAst:
+		list, _ := ast.Value.(AstSlice)
+		// `(C (|| 1))` is a list of one item, and `(C 1)` is a scalar.
+		bare := 1 < len(list)
+		for _, item := range list {
+			if bare {
+				p.separator(depth, item)
+			} else {
+				p.separator(depth, Ast{Type: AstType_ListOfAst})
+			}
+			p.child(depth, item, bare)
+		}
+	default:
+		p.sb.WriteString(" ")
+		p.scalar(ast)
+	}
+	p.sb.WriteString(")")
+}
+
+// Print the AST as an S-expression.
+//
+//	scalar:    (Class value)          e.g. (Number 1), (Op "+"), (|| 'a'), (Name nil)
+//	list:      (Class item item ...)  e.g. (BinaryOperator "+" (Number 1) (Number 2))
+//	cons:      (Class car . cdr)
+//	ListOfAny: [value ...]            e.g. (Row [1 2u 3.0 "x"])
+//
+// ListOfAny accepts []interface{} and SliceLike, and the signed integers in it are printed as Int.
+// The values are nil, true, false, Int (1), Uint (1u), Float (1.0, 1e+21, #inf, #nan),
+// String ("..." Go syntax), Rune ('.' Go syntax), Function (#function) and Any (#any value).
+// In the list of two or more items, the class-less scalar item is printed as a bare value.
+// The value that does not match the AST type is printed in the Any form.
+// The empty class name is printed as `||`.
+func Format(ast Ast, opts Options) string {
+	p := &printer{opts: opts}
+	p.node(0, ast)
+	return p.sb.String()
+}
+
+// Print the ASTs as S-expressions separated by the line breaks.
+func FormatSlice(asts AstSlice, opts Options) string {
+	p := &printer{opts: opts}
+	for i, ast := range asts {
+		if i != 0 {
+			p.sb.WriteString("\n")
+		}
+		p.node(0, ast)
+	}
+	return p.sb.String()
+}
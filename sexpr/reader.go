@@ -0,0 +1,340 @@
+package sexpr
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	. "github.com/shellyln/takenoco/base"
+	clsz "github.com/shellyln/takenoco/sexpr/classes"
+	. "github.com/shellyln/takenoco/string"
+)
+
+var (
+	documentParser ParserFn
+)
+
+func init() {
+	documentParser = document()
+}
+
+// Whitespaces and comments (`;` to the end of line)
+func sp() ParserFn {
+	return Erased(ZeroOrMoreTimes(First(
+		Whitespace(),
+		FlatGroup(Seq(";"), ZeroOrMoreTimes(CharClassN(LineBreakCharacters...))),
+	)))
+}
+
+// Unquoted atom (number, keyword, nil, true, false, ...) or symbol
+func word() ParserFn {
+	return Trans(
+		OneOrMoreTimes(CharClassFn(func(c rune) bool {
+			return !strings.ContainsRune(symbolDelimiters, c)
+		})),
+		Token(clsz.Word),
+	)
+}
+
+// Parenthesized elements
+func list(open, close, className string) ParserFn {
+	return Trans(
+		FlatGroup(
+			Erased(Seq(open)),
+			sp(),
+			ZeroOrMoreTimes(element(), sp()),
+			First(
+				Seq(close),
+				Error("'"+close+"' is expected"),
+			),
+		),
+		Enclosed(className),
+	)
+}
+
+func element() ParserFn {
+	return Indirect(func() ParserFn {
+		return First(
+			list("(", ")", clsz.List),
+			list("[", "]", clsz.Vector),
+			Trans(Quoted("\""), Token(clsz.String)),
+			Trans(Quoted("'"), Token(clsz.Rune)),
+			Trans(Quoted("|"), Token(clsz.Symbol)),
+			word(),
+		)
+	})
+}
+
+func document() ParserFn {
+	return FlatGroup(
+		Start(),
+		sp(),
+		ZeroOrMoreTimes(element(), sp()),
+		First(End(), Error("Unexpected character")),
+	)
+}
+
+// Reader state
+type reader struct {
+	src string
+}
+
+// Make the error at the position of the element.
+func (r *reader) error(e Ast, msg string) error {
+	return NewParseError(e.SourcePosition, e.ClassName, msg).Locate(r.src, 4)
+}
+
+// Get the text of the word.
+func wordOf(e Ast) (string, bool) {
+	if e.ClassName != clsz.Word {
+		return "", false
+	}
+	return e.Value.(string), true
+}
+
+// Unquote the `|...|` symbol.
+func unquoteSymbol(s string) string {
+	s = s[1 : len(s)-1]
+	var sb strings.Builder
+	escaped := false
+	for _, c := range s {
+		if c == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// Convert the atom element to the AST type and value.
+func (r *reader) atom(e Ast) (AstType, interface{}, error) {
+	switch e.ClassName {
+	case clsz.String:
+		v, err := strconv.Unquote(e.Value.(string))
+		if err != nil {
+			return 0, nil, r.error(e, "Bad string literal")
+		}
+		return AstType_String, v, nil
+	case clsz.Rune:
+		v, _, tail, err := strconv.UnquoteChar(e.Value.(string)[1:], '\'')
+		if err != nil || tail != "'" {
+			return 0, nil, r.error(e, "Bad rune literal")
+		}
+		return AstType_Rune, v, nil
+	case clsz.Vector:
+		v, err := r.vector(e)
+		if err != nil {
+			return 0, nil, err
+		}
+		return AstType_ListOfAny, v, nil
+	case clsz.Word:
+		return r.word(e)
+	default:
+		return 0, nil, r.error(e, "An atom is expected")
+	}
+}
+
+// Convert the word to the AST type and value.
+func (r *reader) word(e Ast) (AstType, interface{}, error) {
+	s := e.Value.(string)
+	switch s {
+	case "nil":
+		return AstType_Nil, nil, nil
+	case "true":
+		return AstType_Bool, true, nil
+	case "false":
+		return AstType_Bool, false, nil
+	case "#function":
+		return AstType_Function, nil, nil
+	case "#inf":
+		return AstType_Float, math.Inf(1), nil
+	case "#-inf":
+		return AstType_Float, math.Inf(-1), nil
+	case "#nan":
+		return AstType_Float, math.NaN(), nil
+	}
+
+	if strings.HasSuffix(s, "u") {
+		if v, err := strconv.ParseUint(s[:len(s)-1], 10, 64); err == nil {
+			return AstType_Uint, v, nil
+		}
+	} else if strings.ContainsAny(s, ".eE") {
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return AstType_Float, v, nil
+		}
+	} else if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return AstType_Int, v, nil
+	}
+	return 0, nil, r.error(e, "Unknown atom: "+s)
+}
+
+// Convert the vector to []interface{}.
+func (r *reader) vector(e Ast) ([]interface{}, error) {
+	items := e.Value.(AstSlice)
+	v := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if item.ClassName == clsz.List || item.ClassName == clsz.Symbol {
+			return nil, r.error(item, "An atom is expected")
+		}
+		_, x, err := r.atom(item)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, x)
+	}
+	return v, nil
+}
+
+// Convert the item of the list to the AST.
+func (r *reader) item(e Ast) (Ast, error) {
+	if e.ClassName == clsz.List {
+		return r.node(e)
+	}
+	if e.ClassName == clsz.Symbol {
+		return Ast{}, r.error(e, "A symbol is allowed only at the head of the list")
+	}
+	ty, v, err := r.atom(e)
+	if err != nil {
+		return Ast{}, err
+	}
+	return Ast{Type: ty, Value: v}, nil
+}
+
+// Convert the list to the AST.
+func (r *reader) node(e Ast) (Ast, error) {
+	var ast Ast
+
+	if e.ClassName != clsz.List {
+		return ast, r.error(e, "A list is expected")
+	}
+	items := e.Value.(AstSlice)
+	if len(items) == 0 {
+		return ast, r.error(e, "The class name is required")
+	}
+
+	switch head := items[0]; head.ClassName {
+	case clsz.Word:
+		ast.ClassName = head.Value.(string)
+	case clsz.Symbol:
+		ast.ClassName = unquoteSymbol(head.Value.(string))
+	default:
+		return ast, r.error(head, "The class name is required")
+	}
+
+	rest := items[1:]
+	for 0 < len(rest) {
+		key, ok := wordOf(rest[0])
+		if !ok || !strings.HasPrefix(key, ":") {
+			break
+		}
+		if len(rest) < 2 {
+			return ast, r.error(rest[0], "A value is required after the keyword")
+		}
+		s, ok := wordOf(rest[1])
+		if !ok {
+			return ast, r.error(rest[1], "An integer is required")
+		}
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return ast, r.error(rest[1], "An integer is required")
+		}
+		switch key {
+		case ":op":
+			ast.OpCode = AstOpCodeType(n)
+		case ":pos":
+			ast.Position = int(n)
+		case ":len":
+			ast.Length = int(n)
+		case ":file":
+			ast.FileId = int(n)
+		default:
+			return ast, r.error(rest[0], "Unknown keyword: "+key)
+		}
+		rest = rest[2:]
+	}
+
+	if len(rest) == 3 {
+		if dot, _ := wordOf(rest[1]); dot == "." {
+			car, err := r.item(rest[0])
+			if err != nil {
+				return ast, err
+			}
+			cdr, err := r.item(rest[2])
+			if err != nil {
+				return ast, err
+			}
+			ast.Type = AstType_AstCons
+			ast.Value = AstCons{Car: car, Cdr: cdr}
+			return ast, nil
+		}
+	}
+
+	if len(rest) == 2 {
+		if tag, _ := wordOf(rest[0]); tag == "#any" {
+			_, v, err := r.atom(rest[1])
+			if err != nil {
+				return ast, err
+			}
+			ast.Type = AstType_Any
+			ast.Value = v
+			return ast, nil
+		}
+	}
+
+	if len(rest) == 1 && rest[0].ClassName != clsz.List {
+		ty, v, err := r.atom(rest[0])
+		if err != nil {
+			return ast, err
+		}
+		ast.Type = ty
+		ast.Value = v
+		return ast, nil
+	}
+
+	list := make(AstSlice, 0, len(rest))
+	for _, item := range rest {
+		child, err := r.item(item)
+		if err != nil {
+			return ast, err
+		}
+		list = append(list, child)
+	}
+	ast.Type = AstType_ListOfAst
+	ast.Value = list
+	return ast, nil
+}
+
+// Read the S-expressions printed by Format() and FormatSlice().
+// The returned error is a *ParseError located in the source.
+func Read(s string) (AstSlice, error) {
+	out, err := documentParser(*NewStringParserContext(s))
+	if err != nil {
+		return nil, ToParseError(out.SourcePosition, "", err).Locate(s, 4)
+	}
+	if out.MatchStatus != MatchStatus_Matched {
+		return nil, NewParseError(out.SourcePosition, out.ClassName, "Parse failed").Locate(s, 4)
+	}
+
+	r := &reader{src: s}
+	asts := make(AstSlice, 0, len(out.AstStack))
+	for _, e := range out.AstStack {
+		ast, err := r.node(e)
+		if err != nil {
+			return nil, err
+		}
+		asts = append(asts, ast)
+	}
+	return asts, nil
+}
+
+// Read the S-expressions. It panics if an error occurs. (for test fixtures)
+func MustRead(s string) AstSlice {
+	asts, err := Read(s)
+	if err != nil {
+		panic(errors.New("sexpr.MustRead: " + err.Error()))
+	}
+	return asts
+}
@@ -0,0 +1,228 @@
+package sexpr
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	. "github.com/shellyln/takenoco/base"
+	. "github.com/shellyln/takenoco/string"
+)
+
+// SliceLike of int for the tests.
+type sexprTestIntSlice []int
+
+func (s sexprTestIntSlice) Len() int                         { return len(s) }
+func (s sexprTestIntSlice) Get(i int) interface{}            { return s[i] }
+func (s sexprTestIntSlice) Set(i int, v interface{})         { s[i] = v.(int) }
+func (s sexprTestIntSlice) Reslice(start, end int) SliceLike { return s[start:end] }
+func (s sexprTestIntSlice) Copy(start, end int) SliceLike {
+	return append(sexprTestIntSlice{}, s[start:end]...)
+}
+func (s sexprTestIntSlice) Make(len, cap int) SliceLike      { return make(sexprTestIntSlice, len, cap) }
+func (s sexprTestIntSlice) ItemEquals(a, b interface{}) bool { return a == b }
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		ast  Ast
+		opts Options
+		want string
+	}{{
+		name: "binary operator",
+		ast: Ast{ClassName: "BinaryOperator", Type: AstType_ListOfAst, Value: AstSlice{
+			{Type: AstType_String, Value: "+"},
+			{ClassName: "Number", Type: AstType_Int, Value: int64(1)},
+			{ClassName: "Number", Type: AstType_Int, Value: int64(2)},
+		}},
+		want: `(BinaryOperator "+" (Number 1) (Number 2))`,
+	}, {
+		name: "single item list",
+		ast: Ast{ClassName: "Group", Type: AstType_ListOfAst, Value: AstSlice{
+			{Type: AstType_Int, Value: int64(1)},
+		}},
+		want: `(Group (|| 1))`,
+	}, {
+		name: "scalars",
+		ast: Ast{ClassName: ":string:Seq", Type: AstType_ListOfAst, Value: AstSlice{
+			{Type: AstType_Nil},
+			{Type: AstType_Rune, Value: 'あ'},
+			{Type: AstType_Uint, Value: uint64(3)},
+			{Type: AstType_Float, Value: 2.0},
+			{Type: AstType_Float, Value: math.Inf(-1)},
+			{Type: AstType_Bool, Value: false},
+			{Type: AstType_ListOfAny, Value: []interface{}{int64(1), "x", []interface{}{2.5}}},
+			{Type: AstType_Any, Value: "v"},
+		}},
+		want: `(:string:Seq nil 'あ' 3u 2.0 #-inf false [1 "x" [2.5]] (|| #any "v"))`,
+	}, {
+		name: "cons",
+		ast: Ast{ClassName: "Pair", Type: AstType_AstCons, Value: AstCons{
+			Car: Ast{Type: AstType_String, Value: "k"},
+			Cdr: Ast{ClassName: "V", Type: AstType_Int, Value: int64(1)},
+		}},
+		want: `(Pair "k" . (V 1))`,
+	}, {
+		name: "quoted symbol",
+		ast:  Ast{ClassName: "a b|c", Type: AstType_Nil},
+		want: `(|a b\|c| nil)`,
+	}, {
+		name: "opcode and position",
+		ast: Ast{ClassName: "Op", OpCode: 7, Type: AstType_String, Value: "*",
+			SourcePosition: SourcePosition{Position: 3, Length: 1, FileId: 2}},
+		opts: Options{OpCode: true, Position: true},
+		want: `(Op :op 7 :pos 3 :len 1 :file 2 "*")`,
+	}, {
+		name: "indent",
+		ast: Ast{ClassName: "Add", Type: AstType_ListOfAst, Value: AstSlice{
+			{ClassName: "Number", Type: AstType_Int, Value: int64(1)},
+			{ClassName: "Mul", Type: AstType_ListOfAst, Value: AstSlice{
+				{Type: AstType_Int, Value: int64(2)},
+				{Type: AstType_Int, Value: int64(3)},
+			}},
+		}},
+		opts: Options{Indent: "  "},
+		want: "(Add\n  (Number 1)\n  (Mul 2 3))",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Format(tt.ast, tt.opts)
+			if got != tt.want {
+				t.Errorf("Format() = %s, want %s", got, tt.want)
+			}
+
+			asts, err := Read(got)
+			if err != nil {
+				t.Fatalf("Read(%s) err = %v", got, err)
+			}
+			want := tt.ast
+			if !tt.opts.OpCode {
+				want.OpCode = 0
+			}
+			if !tt.opts.Position {
+				want.SourcePosition = SourcePosition{}
+			}
+			if len(asts) != 1 || !reflect.DeepEqual(asts[0], want) {
+				t.Errorf("Read(%s) = %#v, want %#v", got, asts, want)
+			}
+		})
+	}
+}
+
+func TestFormatListOfAny(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "slice like", value: sexprTestIntSlice{1, 2}, want: `(Row [1 2])`},
+		{name: "int32", value: []interface{}{int32(97), 'b'}, want: `(Row [97 98])`},
+		{name: "nested slice like", value: []interface{}{sexprTestIntSlice{1}}, want: `(Row [[1]])`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Format(Ast{ClassName: "Row", Type: AstType_ListOfAny, Value: tt.value}, Options{})
+			if got != tt.want {
+				t.Errorf("Format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMismatchedValue(t *testing.T) {
+	tests := []struct {
+		name string
+		ast  Ast
+		want string
+	}{
+		{name: "int", ast: Ast{ClassName: "Number", Type: AstType_Int, Value: 1}, want: `(Number #any 1)`},
+		{name: "string", ast: Ast{ClassName: "Name", Type: AstType_String}, want: `(Name #any nil)`},
+		{name: "cons", ast: Ast{ClassName: "Pair", Type: AstType_AstCons, Value: "x"}, want: `(Pair #any "x")`},
+		{name: "bare item", ast: Ast{ClassName: "List", Type: AstType_ListOfAst, Value: AstSlice{
+			{Type: AstType_Int, Value: 1},
+			{Type: AstType_Int, Value: int64(2)},
+		}}, want: `(List (|| #any 1) 2)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Format(tt.ast, Options{}); got != tt.want {
+				t.Errorf("Format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFixture(t *testing.T) {
+	number := Trans(OneOrMoreTimes(Number()), Concat, ParseInt)
+	parser := FlatGroup(
+		Start(),
+		Group(number, Trans(Seq(","), Erase), number),
+		End(),
+	)
+
+	out, err := parser(*NewStringParserContext("12,345"))
+	if err != nil || out.MatchStatus != MatchStatus_Matched {
+		t.Fatalf("parse failed: %v %v", out.MatchStatus, err)
+	}
+
+	want := MustRead(`
+		; Group of two numbers
+		(:base:Group
+			(:string:Number 12)
+			(:string:Number 345))
+	`)
+	if got, want := FormatSlice(out.AstStack, Options{}), FormatSlice(want, Options{}); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "unclosed", src: "(A 1", want: "')' is expected at Line 1, Col 5\n > | (A 1\n   |     ^^^^"},
+		{name: "unknown atom", src: "(A 1x)", want: "Unknown atom: 1x at Line 1, Col 4\n > | (A 1x)\n   |    ^^^^"},
+		{name: "no class name", src: "()", want: "The class name is required at Line 1, Col 1\n > | ()\n   | ^^^^"},
+		{name: "not a list", src: "1", want: "A list is expected at Line 1, Col 1\n > | 1\n   | ^^^^"},
+		{name: "stray", src: "(A 1))", want: "Unexpected character at Line 1, Col 6\n > | (A 1))\n   |      ^^^^"},
+		{name: "keyword", src: "(A :foo 1 2)", want: "Unknown keyword: :foo at Line 1, Col 4\n > | (A :foo 1 2)\n   |    ^^^^"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(tt.src)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Read() err = %v, want *ParseError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("Read() err = %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSpan(t *testing.T) {
+	out, err := documentParser(*NewStringParserContext(" (A (B  1) [2 ] )"))
+	if err != nil || out.MatchStatus != MatchStatus_Matched {
+		t.Fatalf("parse failed: %v %v", out.MatchStatus, err)
+	}
+
+	list := out.AstStack[0]
+	if got, want := list.SourcePosition, (SourcePosition{Position: 1, Length: 16}); got != want {
+		t.Errorf("list span = %+v, want %+v", got, want)
+	}
+	items := list.Value.(AstSlice)
+	if got, want := items[1].SourcePosition, (SourcePosition{Position: 4, Length: 6}); got != want {
+		t.Errorf("inner list span = %+v, want %+v", got, want)
+	}
+	if got, want := items[2].SourcePosition, (SourcePosition{Position: 11, Length: 4}); got != want {
+		t.Errorf("vector span = %+v, want %+v", got, want)
+	}
+}
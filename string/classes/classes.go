@@ -20,4 +20,6 @@ const (
 	HexNumber             = ":string:HexNumber"
 	Alnum                 = ":string:Alnum"
 	WordBoundary          = ":string:WordBoundary"
+	BytesByCount          = ":string:BytesByCount"
+	RunesByCount          = ":string:RunesByCount"
 )
@@ -20,3 +20,14 @@ func NewStringParserContextWithTag(s string, t interface{}) *ParserContext {
 		Tag:      t,
 	}
 }
+
+// Constructor
+// The file ID of the source is set to the ParserContext, and it is copied to the positions of the ASTs.
+func NewSourceFileParserContext(f *SourceFile) *ParserContext {
+	ctx := &ParserContext{
+		Str:      f.Src,
+		AstStack: make(AstSlice, 0, 1024),
+	}
+	ctx.FileId = f.Id
+	return ctx
+}
@@ -539,3 +539,65 @@ func WordBoundary() ParserFn {
 		return ctx, nil
 	})
 }
+
+// Assertion that match n bytes.
+// n is read from the AST produced by the prefix parser.
+func BytesByCount(prefix ParserFn) ParserFn {
+	const ClassName = clsz.BytesByCount
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		out, n, err := ParseCountPrefix(prefix, ctx)
+		if err != nil {
+			return out, err
+		}
+		if out.MatchStatus != MatchStatus_Matched || n > len(out.Str)-out.Position {
+			ctx.MatchStatus = MatchStatus_Unmatched
+			return ctx, nil
+		}
+
+		out.AstStack = append(out.AstStack, Ast{
+			ClassName:      ClassName,
+			Type:           AstType_String,
+			Value:          out.Str[out.Position : out.Position+n],
+			SourcePosition: SourcePosition{Position: out.Position, Length: n, FileId: out.FileId},
+		})
+		out.Position += n
+		out.Length = out.Position - ctx.Position
+		return out, nil
+	})
+}
+
+// Assertion that match n characters.
+// n is read from the AST produced by the prefix parser.
+func RunesByCount(prefix ParserFn) ParserFn {
+	const ClassName = clsz.RunesByCount
+	return LightBaseParser(ClassName, func(ctx ParserContext) (ParserContext, error) {
+		out, n, err := ParseCountPrefix(prefix, ctx)
+		if err != nil {
+			return out, err
+		}
+		if out.MatchStatus != MatchStatus_Matched {
+			ctx.MatchStatus = MatchStatus_Unmatched
+			return ctx, nil
+		}
+
+		end := out.Position
+		for i := 0; i < n; i++ {
+			_, length := utf8.DecodeRuneInString(out.Str[end:])
+			if length == 0 {
+				ctx.MatchStatus = MatchStatus_Unmatched
+				return ctx, nil
+			}
+			end += length
+		}
+
+		out.AstStack = append(out.AstStack, Ast{
+			ClassName:      ClassName,
+			Type:           AstType_String,
+			Value:          out.Str[out.Position:end],
+			SourcePosition: SourcePosition{Position: out.Position, Length: end - out.Position, FileId: out.FileId},
+		})
+		out.Position = end
+		out.Length = out.Position - ctx.Position
+		return out, nil
+	})
+}
@@ -108,3 +108,200 @@ func TestAlnum(t *testing.T) {
 
 func TestWordBoundary(t *testing.T) {
 }
+
+func TestBytesByCount(t *testing.T) {
+	// netstring
+	netstring := FlatGroup(
+		BytesByCount(FlatGroup(
+			Trans(OneOrMoreTimes(Number()), ParseUint),
+			Trans(Seq(":"), Erase),
+		)),
+		Trans(Seq(","), Erase),
+	)
+
+	type args struct {
+		text string
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want parserWant
+	}{{
+		name: "Case 1",
+		args: args{
+			text: "3:abc,",
+		},
+		want: parserWant{
+			hasErr:      false,
+			matchStatus: MatchStatus_Matched,
+			astStack: AstSlice{{
+				ClassName: ":string:BytesByCount",
+				Type:      AstType_String,
+				Value:     "abc",
+			}},
+		},
+	}, {
+		name: "Case 2",
+		args: args{
+			text: "0:,",
+		},
+		want: parserWant{
+			hasErr:      false,
+			matchStatus: MatchStatus_Matched,
+			astStack: AstSlice{{
+				ClassName: ":string:BytesByCount",
+				Type:      AstType_String,
+				Value:     "",
+			}},
+		},
+	}, {
+		name: "Case 3",
+		args: args{
+			text: "4:abc,",
+		},
+		want: parserWant{
+			hasErr:      false,
+			matchStatus: MatchStatus_Unmatched,
+			astStack:    nil,
+		},
+	}, {
+		name: "Case 4",
+		args: args{
+			text: "6:あい,",
+		},
+		want: parserWant{
+			hasErr:      false,
+			matchStatus: MatchStatus_Matched,
+			astStack: AstSlice{{
+				ClassName: ":string:BytesByCount",
+				Type:      AstType_String,
+				Value:     "あい",
+			}},
+		},
+	}, {
+		name: "Case 5",
+		args: args{
+			text: "9223372036854775807:abc,",
+		},
+		want: parserWant{
+			hasErr:      false,
+			matchStatus: MatchStatus_Unmatched,
+			astStack:    nil,
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := netstring(*NewStringParserContext(tt.args.text))
+			if tt.want.hasErr != (err != nil) {
+				t.Errorf("BytesByCount().err is %v, want %v", err, tt.want.hasErr)
+				return
+			}
+			if !tt.want.hasErr {
+				if tt.want.matchStatus != got.MatchStatus {
+					t.Errorf("BytesByCount().got.MatchStatus is %v, want %v", got.MatchStatus, tt.want.matchStatus)
+					return
+				}
+			}
+			if tt.want.matchStatus == MatchStatus_Matched {
+				if !astSliceEquals(got.AstStack, tt.want.astStack) {
+					t.Errorf("BytesByCount().got.AstStack = %v, want %v", got, tt.want.astStack)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestRunesByCount(t *testing.T) {
+	parser := RunesByCount(FlatGroup(
+		Trans(OneOrMoreTimes(Number()), ParseInt),
+		Trans(Seq(":"), Erase),
+	))
+
+	got, err := parser(*NewStringParserContext("2:あいう"))
+	if err != nil {
+		t.Errorf("RunesByCount().err is %v", err)
+		return
+	}
+	if got.MatchStatus != MatchStatus_Matched {
+		t.Errorf("RunesByCount().got.MatchStatus is %v", got.MatchStatus)
+		return
+	}
+	if got.AstStack[0].Value != "あい" || got.Position != 8 {
+		t.Errorf("RunesByCount().got = %v", got)
+	}
+
+	got, err = parser(*NewStringParserContext("4:あいう"))
+	if err != nil || got.MatchStatus != MatchStatus_Unmatched {
+		t.Errorf("RunesByCount().got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+}
+
+func TestRepeatByCount(t *testing.T) {
+	// "N rows follow"
+	rows := FlatGroup(
+		RepeatByCount(
+			FlatGroup(
+				Trans(OneOrMoreTimes(Number()), ParseInt),
+				Trans(Seq(" rows\n"), Erase),
+			),
+			Trans(OneOrMoreTimes(Alpha()), Concat),
+			Trans(Seq("\n"), Erase),
+		),
+		End(),
+	)
+
+	got, err := rows(*NewStringParserContext("2 rows\nfoo\nbar\n"))
+	if err != nil {
+		t.Errorf("RepeatByCount().err is %v", err)
+		return
+	}
+	want := AstSlice{{
+		ClassName: ":string:Alpha",
+		Type:      AstType_String,
+		Value:     "foo",
+	}, {
+		ClassName: ":string:Alpha",
+		Type:      AstType_String,
+		Value:     "bar",
+	}}
+	if got.MatchStatus != MatchStatus_Matched || !astSliceEquals(got.AstStack, want) {
+		t.Errorf("RepeatByCount().got = %v, want %v", got, want)
+	}
+
+	got, err = rows(*NewStringParserContext("3 rows\nfoo\nbar\n"))
+	if err != nil || got.MatchStatus != MatchStatus_Unmatched {
+		t.Errorf("RepeatByCount().got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+
+	got, err = rows(*NewStringParserContext("1 rows\nfoo\nbar\n"))
+	if err != nil || got.MatchStatus != MatchStatus_Unmatched {
+		t.Errorf("RepeatByCount().got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+
+	minus := RepeatByCount(
+		FlatGroup(
+			Trans(FlatGroup(Seq("-"), Number()), ParseInt),
+			Trans(Seq(":"), Erase),
+		),
+		Alpha(),
+	)
+	_, err = minus(*NewStringParserContext("-1:a"))
+	if err == nil {
+		t.Errorf("RepeatByCount().err is nil, want error")
+	}
+
+	// The prefix AST has the Int type but not the int64 value.
+	mismatch := RepeatByCount(
+		Trans(Number(), func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+			return AstSlice{{Type: AstType_Int, Value: asts[0].Value}}, nil
+		}),
+		Alpha(),
+	)
+	got, err = mismatch(*NewStringParserContext("1a"))
+	if err == nil || got.MatchStatus != MatchStatus_Error {
+		t.Errorf("RepeatByCount().got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+}
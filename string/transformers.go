@@ -2,6 +2,7 @@ package strparser
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -16,7 +17,7 @@ const (
 
 // Transform the result AST array.
 // Concatenate strings.
-func Concat(_ ParserContext, asts AstSlice) (AstSlice, error) {
+func Concat(ctx ParserContext, asts AstSlice) (AstSlice, error) {
 	if len(asts) == 0 {
 		return AstSlice{{
 			Type:  AstType_String,
@@ -26,7 +27,7 @@ func Concat(_ ParserContext, asts AstSlice) (AstSlice, error) {
 	var sb strings.Builder
 	for _, w := range asts {
 		if w.Type != AstType_String {
-			return nil, errors.New("Transformer:Concat: Bad source type:" + w.Type.String())
+			return nil, NewTransformError(ctx, AstSlice{w}, errors.New("Transformer:Concat: Bad source type:"+w.Type.String()))
 		}
 		sb.WriteString(w.Value.(string))
 	}
@@ -73,6 +74,7 @@ func TrimEnd(ctx ParserContext, asts AstSlice) (AstSlice, error) {
 
 // Transform the result AST array.
 func parseIntImpl(base int, ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	src := asts
 	asts, err := Concat(ctx, asts)
 	if err != nil {
 		return nil, err
@@ -80,7 +82,7 @@ func parseIntImpl(base int, ctx ParserContext, asts AstSlice) (AstSlice, error)
 	str := asts[0].Value.(string)
 	num, err := strconv.ParseInt(str, base, 64)
 	if err != nil {
-		return nil, errors.New("Transformer:parseIntImpl:Bad number format:" + str + ":" + err.Error())
+		return nil, NewTransformError(ctx, src, fmt.Errorf("Transformer:parseIntImpl:Bad number format:%s:%w", str, err))
 	}
 	asts[0].Type = AstType_Int
 	asts[0].Value = num
@@ -103,6 +105,7 @@ func ParseInt(ctx ParserContext, asts AstSlice) (AstSlice, error) {
 
 // Transform the result AST array.
 func parseUintImpl(base int, ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	src := asts
 	asts, err := Concat(ctx, asts)
 	if err != nil {
 		return nil, err
@@ -114,7 +117,7 @@ func parseUintImpl(base int, ctx ParserContext, asts AstSlice) (AstSlice, error)
 	}
 	num, err := strconv.ParseUint(str2, base, 64)
 	if err != nil {
-		return nil, errors.New("Transformer:parseUintImpl:Bad number format:" + str + ":" + err.Error())
+		return nil, NewTransformError(ctx, src, fmt.Errorf("Transformer:parseUintImpl:Bad number format:%s:%w", str, err))
 	}
 	asts[0].Type = AstType_Uint
 	asts[0].Value = num
@@ -138,6 +141,7 @@ func ParseUint(ctx ParserContext, asts AstSlice) (AstSlice, error) {
 // Transform the result AST array.
 // Parses a floating point number.
 func ParseFloat(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+	src := asts
 	asts, err := Concat(ctx, asts)
 	if err != nil {
 		return nil, err
@@ -145,7 +149,7 @@ func ParseFloat(ctx ParserContext, asts AstSlice) (AstSlice, error) {
 	str := asts[0].Value.(string)
 	num, err := strconv.ParseFloat(str, 64)
 	if err != nil {
-		return nil, errors.New("Transformer:ParseFloat:Bad number format:" + str + ":" + err.Error())
+		return nil, NewTransformError(ctx, src, fmt.Errorf("Transformer:ParseFloat:Bad number format:%s:%w", str, err))
 	}
 	asts[0].Type = AstType_Float
 	asts[0].Value = num
@@ -195,3 +199,124 @@ func StringFromRune(ctx ParserContext, asts AstSlice) (AstSlice, error) {
 		SourcePosition: asts[0].SourcePosition,
 	}}, nil
 }
+
+// Unquote the string literal quoted by `'` or `"`.
+// Both of them accept the Go escape sequences.
+func UnquoteString(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || s[0] != '"' && s[0] != '\'' {
+		return "", strconv.ErrSyntax
+	}
+	if s[0] == '"' {
+		return strconv.Unquote(s)
+	}
+	var sb strings.Builder
+	sb.WriteString("\"")
+	escaped := false
+	for _, c := range s[1 : len(s)-1] {
+		switch {
+		case escaped:
+			if c != '\'' {
+				sb.WriteRune('\\')
+			}
+			sb.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			sb.WriteString("\\\"")
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteString("\"")
+	return strconv.Unquote(sb.String())
+}
+
+// Remove the resulting AST.
+func Erased(fn ParserFn) ParserFn {
+	return Trans(fn, Erase)
+}
+
+// Zero or more whitespaces. The resulting ASTs are removed.
+func Spaces() ParserFn {
+	return Erased(ZeroOrMoreTimes(Whitespace()))
+}
+
+// Quoted text. The escape sequences are kept as is.
+// The resulting ASTs are not concatenated. Use Token() to concatenate them.
+func Quoted(quote string) ParserFn {
+	return FlatGroup(
+		Seq(quote),
+		ZeroOrMoreTimes(First(
+			FlatGroup(Seq("\\"), Any()),
+			CharClassN(quote, "\\"),
+		)),
+		First(Seq(quote), FlatGroup(End(), Error("Unexpected EOF"))),
+	)
+}
+
+// Get the span from the start position to the end of the ASTs.
+func SpanOf(ctx ParserContext, asts AstSlice) SourcePosition {
+	end := ctx.Position
+	for _, ast := range asts {
+		if e := ast.Position + ast.Length; end < e {
+			end = e
+		}
+	}
+	return SourcePosition{Position: ctx.Position, Length: end - ctx.Position, FileId: ctx.FileId}
+}
+
+// Make the transformer that concatenates the matched text and sets the class name and the span.
+func Token(className string) TransformerFn {
+	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		var sb strings.Builder
+		end := ctx.Position
+		for _, ast := range asts {
+			s, ok := ast.Value.(string)
+			if !ok {
+				return nil, NewTransformError(ctx, AstSlice{ast}, errors.New("Transformer:Token: Bad source type:"+ast.Type.String()))
+			}
+			sb.WriteString(s)
+			end = ast.Position + len(s)
+		}
+		return AstSlice{{
+			ClassName:      className,
+			Type:           AstType_String,
+			Value:          sb.String(),
+			SourcePosition: SourcePosition{Position: ctx.Position, Length: end - ctx.Position, FileId: ctx.FileId},
+		}}, nil
+	}
+}
+
+// Make the transformer that groups the items and sets the class name and the span.
+func GroupAs(className string) TransformerFn {
+	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		w := make(AstSlice, 0, len(asts))
+		w = append(w, asts...)
+		return AstSlice{{
+			ClassName:      className,
+			Type:           AstType_ListOfAst,
+			Value:          w,
+			SourcePosition: SpanOf(ctx, asts),
+		}}, nil
+	}
+}
+
+// Make the transformer that groups the items except the last close bracket, and sets the class name and the span.
+// The span ends at the end of the close bracket.
+func Enclosed(className string) TransformerFn {
+	return func(ctx ParserContext, asts AstSlice) (AstSlice, error) {
+		if len(asts) == 0 {
+			return nil, NewTransformError(ctx, asts, errors.New("Transformer:Enclosed: The close bracket is missing"))
+		}
+		close := asts[len(asts)-1]
+		w, err := GroupAs(className)(ctx, asts[:len(asts)-1])
+		if err != nil {
+			return w, err
+		}
+		if s, ok := close.Value.(string); ok {
+			w[0].Length = close.Position + len(s) - ctx.Position
+		}
+		return w, nil
+	}
+}
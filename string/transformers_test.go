@@ -1,7 +1,10 @@
 package strparser
 
 import (
+	"reflect"
 	"testing"
+
+	. "github.com/shellyln/takenoco/base"
 )
 
 func TestConcat(t *testing.T) {
@@ -24,3 +27,87 @@ func TestParseUint(t *testing.T) {
 
 func TestParseFloat(t *testing.T) {
 }
+
+func TestUnquoteString(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{"1", `"a\"b\n"`, "a\"b\n", false},
+		{"2", `'a\'b"cあ'`, "a'b\"cあ", false},
+		{"3", `''`, "", false},
+		{"4", `"a'`, "", true},
+		{"5", `'a`, "", true},
+		{"6", `a`, "", true},
+		{"7", `'\q'`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnquoteString(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("UnquoteString() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("UnquoteString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenHelpers(t *testing.T) {
+	parser := FlatGroup(
+		Spaces(),
+		Trans(
+			FlatGroup(
+				Erased(Seq("(")),
+				Spaces(),
+				Trans(Quoted("'"), Token("Str")),
+				Spaces(),
+				Trans(OneOrMoreTimes(Alpha()), Token("Word")),
+				Spaces(),
+				Seq(")"),
+			),
+			Enclosed("List"),
+		),
+		End(),
+	)
+
+	ctx := *NewStringParserContext(" ( 'a\\'b' cd )")
+	ctx.FileId = 2
+	got, err := parser(ctx)
+	if err != nil || got.MatchStatus != MatchStatus_Matched {
+		t.Fatalf("parser() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+	want := AstSlice{{
+		ClassName:      "List",
+		Type:           AstType_ListOfAst,
+		SourcePosition: SourcePosition{Position: 1, Length: 13, FileId: 2},
+		Value: AstSlice{{
+			ClassName:      "Str",
+			Type:           AstType_String,
+			Value:          "'a\\'b'",
+			SourcePosition: SourcePosition{Position: 3, Length: 6, FileId: 2},
+		}, {
+			ClassName:      "Word",
+			Type:           AstType_String,
+			Value:          "cd",
+			SourcePosition: SourcePosition{Position: 10, Length: 2, FileId: 2},
+		}},
+	}}
+	if !reflect.DeepEqual(got.AstStack, want) {
+		t.Errorf("parser() got.AstStack = %v, want %v", got.AstStack, want)
+	}
+
+	got, err = Quoted("'")(*NewStringParserContext("'ab"))
+	if err == nil || got.MatchStatus != MatchStatus_Error {
+		t.Errorf("Quoted() got.MatchStatus is %v, err is %v", got.MatchStatus, err)
+	}
+
+	_, err = Token("Str")(*NewStringParserContext(""), AstSlice{{Type: AstType_Int, Value: int64(1)}})
+	if err == nil {
+		t.Errorf("Token() err is nil, want error")
+	}
+}